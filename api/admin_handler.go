@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"minivault/domain"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type adminHandler struct {
+	runtime domain.RuntimePort
+	logger  domain.LoggerPort
+}
+
+func NewAdminHandler(runtime domain.RuntimePort, logger domain.LoggerPort) domain.AdminHandlerPort {
+	return &adminHandler{runtime: runtime, logger: logger}
+}
+
+// maintenanceRequest is the body of PUT /admin/maintenance.
+type maintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// Status handles GET /admin/runtime: build, uptime, modes, in-flight generations and upstream health.
+func (h *adminHandler) Status(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	audit(h.logger, r, reqID, "show runtime", "", nil)
+	writeJSON(w, h.logger, reqID, h.runtime.Status(r.Context()), http.StatusOK)
+}
+
+// Config handles GET /admin/config: the effective configuration with secrets redacted.
+func (h *adminHandler) Config(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	audit(h.logger, r, reqID, "show config", "", nil)
+	writeJSON(w, h.logger, reqID, h.runtime.Config(), http.StatusOK)
+}
+
+// Requests handles GET /admin/requests: the generations in progress, oldest first.
+func (h *adminHandler) Requests(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	audit(h.logger, r, reqID, "list requests", "", nil)
+	writeJSON(w, h.logger, reqID, map[string]any{"requests": h.runtime.InFlight()}, http.StatusOK)
+}
+
+// Cancel handles DELETE /admin/requests/{id}, cancelling that generation.
+func (h *adminHandler) Cancel(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	target := r.PathValue("id")
+	found := h.runtime.Cancel(target)
+	audit(h.logger, r, reqID, "cancel request", target, nil)
+	if !found {
+		writeError(w, h.logger, reqID, "Request not found", nil, http.StatusNotFound)
+		return
+	}
+	writeJSON(w, h.logger, reqID, map[string]string{"request_id": target, "status": "cancelled"}, http.StatusOK)
+}
+
+// Drain handles POST /admin/drain: stop taking generations and shut down once idle.
+func (h *adminHandler) Drain(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	audit(h.logger, r, reqID, "drain server", "", nil)
+	h.runtime.Drain()
+	writeJSON(w, h.logger, reqID, map[string]string{"status": "draining"}, http.StatusAccepted)
+}
+
+// Maintenance handles PUT /admin/maintenance with {"enabled": true|false}.
+func (h *adminHandler) Maintenance(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	var req maintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg, code := decodeErrorStatus(err)
+		writeError(w, h.logger, reqID, msg, err, code)
+		return
+	}
+	if req.Enabled == nil {
+		writeError(w, h.logger, reqID, "Validation error", errors.New("enabled must be true or false"), http.StatusBadRequest)
+		return
+	}
+	action := "end maintenance"
+	if *req.Enabled {
+		action = "start maintenance"
+	}
+	h.runtime.SetMaintenance(*req.Enabled)
+	audit(h.logger, r, reqID, action, "", nil)
+	writeJSON(w, h.logger, reqID, map[string]bool{"maintenance": *req.Enabled}, http.StatusOK)
+}
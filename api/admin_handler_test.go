@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"minivault/domain"
+	"minivault/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	runtime := &mocks.MockRuntime{
+		RuntimeStatus: domain.RuntimeStatus{Uptime: "1h0m0s", QueueDepth: 1},
+		Settings:      map[string]any{"VaultKey": "[REDACTED]"},
+		Requests:      []domain.InFlightRequest{{RequestID: "r1", Model: "gemma:2b"}},
+		Running:       []string{"r1"},
+	}
+	logger := &mocks.MockLogger{}
+	h := NewAdminHandler(runtime, logger)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/runtime", h.Status)
+	mux.HandleFunc("GET /admin/config", h.Config)
+	mux.HandleFunc("GET /admin/requests", h.Requests)
+	mux.HandleFunc("DELETE /admin/requests/{id}", h.Cancel)
+	mux.HandleFunc("POST /admin/drain", h.Drain)
+	mux.HandleFunc("PUT /admin/maintenance", h.Maintenance)
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	var status domain.RuntimeStatus
+	if err := json.NewDecoder(serve(http.MethodGet, "/admin/runtime", "").Body).Decode(&status); err != nil || status.Uptime != "1h0m0s" || status.QueueDepth != 1 {
+		t.Errorf("unexpected status: %+v %v", status, err)
+	}
+	if rec := serve(http.MethodGet, "/admin/config", ""); !strings.Contains(rec.Body.String(), `"VaultKey":"[REDACTED]"`) {
+		t.Errorf("unexpected config: %s", rec.Body)
+	}
+	var list struct {
+		Requests []domain.InFlightRequest `json:"requests"`
+	}
+	if err := json.NewDecoder(serve(http.MethodGet, "/admin/requests", "").Body).Decode(&list); err != nil || len(list.Requests) != 1 {
+		t.Errorf("unexpected requests: %+v %v", list, err)
+	}
+
+	if rec := serve(http.MethodDelete, "/admin/requests/r1", ""); rec.Code != http.StatusOK || len(runtime.Cancelled) != 1 {
+		t.Errorf("expected r1 to be cancelled, got %d %v", rec.Code, runtime.Cancelled)
+	}
+	if rec := serve(http.MethodDelete, "/admin/requests/r2", ""); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a request not in flight, got %d", rec.Code)
+	}
+
+	if rec := serve(http.MethodPut, "/admin/maintenance", `{}`); rec.Code != http.StatusBadRequest || runtime.Maintenance != nil {
+		t.Errorf("expected 400 without enabled, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPut, "/admin/maintenance", `{"enabled": true}`); rec.Code != http.StatusOK || runtime.Maintenance == nil || !*runtime.Maintenance {
+		t.Errorf("expected maintenance to be switched on, got %d", rec.Code)
+	}
+	if rec := serve(http.MethodPost, "/admin/drain", ""); rec.Code != http.StatusAccepted || !runtime.Drained {
+		t.Errorf("expected a drain, got %d", rec.Code)
+	}
+	if len(logger.Infos) != 7 {
+		t.Errorf("expected every admin action to be audited, got %v", logger.Infos)
+	}
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"minivault/domain"
+	"minivault/usecases"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// chatSubprotocol is the WebSocket subprotocol clients offer, and the server
+// selects, for /ws/chat.
+const chatSubprotocol = "minivault"
+
+type chatSocketHandler struct {
+	generator    domain.GeneratorPort
+	logger       domain.LoggerPort
+	inflight     domain.InFlightPort // nil when generations are not tracked
+	tenants      domain.TenantsPort  // nil with tenancy off
+	origins      []string
+	maxMessage   int64
+	maxHistory   int64
+	pingInterval time.Duration
+
+	closing   chan struct{} // closed by Shutdown
+	closeOnce sync.Once
+}
+
+// NewChatSocketHandler serves conversations over WebSocket to browsers on the
+// server's own origin or one of origins. Each connection keeps its own history,
+// forgetting the oldest turns beyond maxHistory bytes; messages are limited to
+// maxMessage bytes, and the server pings every pingInterval, dropping clients
+// silent for two intervals (0 turns both off).
+func NewChatSocketHandler(generator domain.GeneratorPort, logger domain.LoggerPort, inflight domain.InFlightPort, tenants domain.TenantsPort, origins []string, maxMessage, maxHistory int64, pingInterval time.Duration) domain.ChatSocketHandlerPort {
+	return &chatSocketHandler{
+		generator:    generator,
+		logger:       logger,
+		inflight:     inflight,
+		tenants:      tenants,
+		origins:      origins,
+		maxMessage:   maxMessage,
+		maxHistory:   maxHistory,
+		pingInterval: pingInterval,
+		closing:      make(chan struct{}),
+	}
+}
+
+// Shutdown implements ChatSocketHandlerPort
+func (h *chatSocketHandler) Shutdown() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+// chatTurn is the generation running on a connection.
+type chatTurn struct {
+	reqID  string
+	cancel context.CancelCauseFunc
+	done   chan struct{}
+}
+
+// Chat handles GET /ws/chat. After the upgrade the connection runs one turn at a
+// time: "user" frames start a turn whose reply is streamed back as "token" frames
+// followed by "usage" and "done", or "error"; "cancel" stops it.
+func (h *chatSocketHandler) Chat(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgradeWebSocket(w, r, chatSubprotocol, h.origins, h.maxMessage, 2*h.pingInterval)
+	if err != nil {
+		h.logger.LogWarn(fmt.Sprintf("websocket upgrade to %s from %s refused: %v", r.URL.Path, r.RemoteAddr, err))
+		return
+	}
+	defer conn.release()
+
+	connID := uuid.New().String()
+	caller, _ := domain.CallerFromContext(r.Context())
+	h.logger.LogInfo(fmt.Sprintf("chat connection %s opened by %q from %s", connID, caller.KeyID, r.RemoteAddr))
+	// the hijacked connection outlives the request's context, so turns get their own
+	ctx, cancel := context.WithCancel(domain.WithCaller(context.Background(), caller))
+	defer cancel()
+
+	messages := make(chan []byte)
+	readErr := make(chan error, 1)
+	go func() {
+		defer close(messages)
+		for {
+			op, data, err := conn.readMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			if op != wsText {
+				readErr <- conn.fail(wsCloseUnsupported, "frames must be JSON text")
+				return
+			}
+			select {
+			case messages <- data:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	session := usecases.NewChatSession(h.generator, "", h.maxHistory)
+	h.send(conn, domain.ChatFrame{Type: domain.ChatFrameReady, ID: connID})
+	var pings <-chan time.Time
+	if h.pingInterval > 0 {
+		ticker := time.NewTicker(h.pingInterval)
+		defer ticker.Stop()
+		pings = ticker.C
+	}
+	var turn *chatTurn
+	var turnDone <-chan struct{} // nil while idle
+	closing := h.closing
+	for {
+		select {
+		case data, ok := <-messages:
+			if !ok {
+				if turn != nil {
+					turn.cancel(domain.ErrTurnCancelled)
+					<-turn.done
+				}
+				h.logger.LogInfo(fmt.Sprintf("chat connection %s closed: %v", connID, <-readErr))
+				return
+			}
+			if next := h.handleFrame(ctx, conn, session, turn, data); next != nil {
+				turn, turnDone = next, next.done
+			}
+		case <-turnDone:
+			turn, turnDone = nil, nil
+			if closing == nil {
+				conn.close(wsCloseGoingAway, "server shutting down")
+			}
+		case <-closing:
+			// finish the turn in progress, then say goodbye; the client's close reply ends the loop
+			closing = nil
+			if turn == nil {
+				conn.close(wsCloseGoingAway, "server shutting down")
+			}
+		case <-pings:
+			conn.ping()
+		}
+	}
+}
+
+// handleFrame acts on one client message, returning the turn it started, if any.
+func (h *chatSocketHandler) handleFrame(ctx context.Context, conn *wsConn, session domain.ChatSessionPort, turn *chatTurn, data []byte) *chatTurn {
+	var frame domain.ChatFrame
+	if err := json.Unmarshal(data, &frame); err != nil {
+		h.sendError(conn, "", "Invalid JSON", err, http.StatusBadRequest)
+		return nil
+	}
+	busy := turn != nil
+	switch frame.Type {
+	case domain.ChatFramePing:
+		h.send(conn, domain.ChatFrame{Type: domain.ChatFramePong, RequestID: frame.RequestID})
+	case domain.ChatFrameCancel:
+		if busy && (frame.RequestID == "" || frame.RequestID == turn.reqID) {
+			turn.cancel(domain.ErrTurnCancelled)
+		}
+	case domain.ChatFrameReset, domain.ChatFrameSystem, domain.ChatFrameUser:
+		if busy {
+			h.sendError(conn, frame.RequestID, "Conflict", domain.ErrTurnInProgress, http.StatusConflict)
+			return nil
+		}
+		switch frame.Type {
+		case domain.ChatFrameReset:
+			session.Reset()
+			h.send(conn, domain.ChatFrame{Type: domain.ChatFrameDone})
+		case domain.ChatFrameSystem:
+			session.SetSystem(frame.Content)
+			h.send(conn, domain.ChatFrame{Type: domain.ChatFrameDone, Content: frame.Content})
+		default:
+			return h.startTurn(ctx, conn, session, frame)
+		}
+	default:
+		h.sendError(conn, frame.RequestID, "Validation error", fmt.Errorf("%w %q", domain.ErrUnknownChatFrame, frame.Type), http.StatusBadRequest)
+	}
+	return nil
+}
+
+// startTurn validates a "user" frame and runs its generation in the background.
+func (h *chatSocketHandler) startTurn(ctx context.Context, conn *wsConn, session domain.ChatSessionPort, frame domain.ChatFrame) *chatTurn {
+	reqID := uuid.New().String()
+	model := session.Model()
+	if frame.Model != "" {
+		model = frame.Model
+	}
+	req := domain.GenerateRequest{Prompt: frame.Content, Model: model}
+	if err := req.Validate(); err != nil {
+		h.sendError(conn, reqID, "Validation error", err, http.StatusBadRequest)
+		return nil
+	}
+	// every turn counts against the tenant's rate limit, like an HTTP request
+	if caller, _ := domain.CallerFromContext(ctx); caller.Tenant != "" && h.tenants != nil {
+		if allowed, retryAfter := h.tenants.Allow(caller.Tenant); !allowed {
+			h.logger.LogWarn(fmt.Sprintf("rate limited chat turn by %q of tenant %s [reqID: %s]", caller.KeyID, caller.Tenant, reqID))
+			h.send(conn, domain.ChatFrame{
+				Type:       domain.ChatFrameError,
+				RequestID:  reqID,
+				Error:      "Too Many Requests: " + domain.ErrRateLimited.Error(),
+				Status:     http.StatusTooManyRequests,
+				RetryAfter: int(math.Ceil(retryAfter.Seconds())),
+			})
+			return nil
+		}
+	}
+	session.SetModel(model)
+
+	turnCtx, cancel := context.WithCancelCause(domain.WithRequestID(ctx, reqID))
+	turn := &chatTurn{reqID: reqID, cancel: cancel, done: make(chan struct{})}
+	genCtx, done := turnCtx, func() {}
+	if h.inflight != nil {
+		genCtx, done = h.inflight.Start(turnCtx, req, false)
+	}
+	go func() {
+		defer close(turn.done)
+		defer cancel(nil)
+		h.runTurn(genCtx, conn, session, reqID, frame.Content)
+		done()
+	}()
+	return turn
+}
+
+// runTurn generates the reply to prompt, streaming it to the client.
+func (h *chatSocketHandler) runTurn(ctx context.Context, conn *wsConn, session domain.ChatSessionPort, reqID, prompt string) {
+	h.send(conn, domain.ChatFrame{Type: domain.ChatFrameStart, RequestID: reqID, Model: session.Model()})
+	ctx = domain.WithTokenSink(ctx, func(token string) {
+		h.send(conn, domain.ChatFrame{Type: domain.ChatFrameToken, RequestID: reqID, Token: token})
+	})
+	resp, err := session.Send(ctx, prompt)
+	err = cancelled(ctx, err)
+	switch {
+	case err != nil && errors.Is(context.Cause(ctx), domain.ErrTurnCancelled):
+		h.send(conn, domain.ChatFrame{Type: domain.ChatFrameCancelled, RequestID: reqID})
+	case err != nil:
+		msg, code := generateErrorStatus(err)
+		h.sendError(conn, reqID, msg, err, code)
+	default:
+		if resp.Usage != nil {
+			h.send(conn, domain.ChatFrame{Type: domain.ChatFrameUsage, RequestID: reqID, Usage: resp.Usage})
+		}
+		h.send(conn, domain.ChatFrame{
+			Type:      domain.ChatFrameDone,
+			RequestID: reqID,
+			Content:   resp.Response,
+			Model:     resp.Model,
+			Turns:     len(session.History()),
+		})
+	}
+}
+
+// send writes one frame; a connection that has gone away is noticed by the reader.
+func (h *chatSocketHandler) send(conn *wsConn, frame domain.ChatFrame) {
+	data, err := json.Marshal(frame)
+	if err != nil {
+		h.logger.LogError("failed to encode chat frame", err)
+		return
+	}
+	conn.writeText(data)
+}
+
+// sendError logs a failure and reports it to the client with the status it would
+// have had over HTTP.
+func (h *chatSocketHandler) sendError(conn *wsConn, reqID, msg string, err error, code int) {
+	if code >= http.StatusInternalServerError {
+		h.logger.LogError(msg+" [reqID: "+reqID+"]", err)
+	} else {
+		h.logger.LogWarn(msg + ": " + err.Error() + " [reqID: " + reqID + "]")
+	}
+	if code < http.StatusInternalServerError {
+		msg += ": " + err.Error()
+	}
+	h.send(conn, domain.ChatFrame{Type: domain.ChatFrameError, RequestID: reqID, Error: msg, Status: code})
+}
@@ -0,0 +1,325 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"minivault/domain"
+	"minivault/mocks"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsTestClient speaks just enough of RFC 6455 to drive the chat handler.
+type wsTestClient struct {
+	t    *testing.T
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialChat(t *testing.T, srv *httptest.Server) *wsTestClient {
+	t.Helper()
+	conn, br, resp := handshake(t, srv, "")
+	if resp.StatusCode != http.StatusSwitchingProtocols ||
+		resp.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" ||
+		resp.Header.Get("Sec-WebSocket-Protocol") != "minivault" {
+		t.Fatalf("unexpected handshake response: %d %v", resp.StatusCode, resp.Header)
+	}
+	return &wsTestClient{t: t, conn: conn, br: br}
+}
+
+// handshake opens /ws/chat on host "minivault", sending origin if not empty.
+func handshake(t *testing.T, srv *httptest.Server, origin string) (net.Conn, *bufio.Reader, *http.Response) {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	headers := "Host: minivault\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Protocol: minivault\r\n"
+	if origin != "" {
+		headers += "Origin: " + origin + "\r\n"
+	}
+	io.WriteString(conn, "GET /ws/chat HTTP/1.1\r\n"+headers+"\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return conn, br, resp
+}
+
+func (c *wsTestClient) writeFrame(op byte, payload []byte, masked bool) {
+	head := []byte{0x80 | op, byte(len(payload))}
+	mask := []byte{1, 2, 3, 4}
+	if masked {
+		head[1] |= 0x80
+		head = append(head, mask...)
+	}
+	body := append([]byte(nil), payload...)
+	for i := range body {
+		if masked {
+			body[i] ^= mask[i%4]
+		}
+	}
+	if _, err := c.conn.Write(append(head, body...)); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func (c *wsTestClient) send(frame domain.ChatFrame) {
+	data, _ := json.Marshal(frame)
+	c.writeFrame(wsText, data, true)
+}
+
+// readFrame returns the next frame from the server, which are never fragmented.
+func (c *wsTestClient) readFrame() (byte, []byte) {
+	c.t.Helper()
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		c.t.Fatal(err)
+	}
+	length := int(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.br, ext[:])
+		length = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		c.t.Fatal(err)
+	}
+	return head[0] & 0x0F, payload
+}
+
+// next returns the next chat frame, skipping keepalive pings.
+func (c *wsTestClient) next() domain.ChatFrame {
+	c.t.Helper()
+	for {
+		op, payload := c.readFrame()
+		if op == wsPing {
+			continue
+		}
+		if op != wsText {
+			c.t.Fatalf("expected a text frame, got opcode %d %q", op, payload)
+		}
+		var frame domain.ChatFrame
+		if err := json.Unmarshal(payload, &frame); err != nil {
+			c.t.Fatal(err)
+		}
+		return frame
+	}
+}
+
+// expectClose reads until the server's close frame and returns its code.
+func (c *wsTestClient) expectClose() int {
+	c.t.Helper()
+	for {
+		op, payload := c.readFrame()
+		if op == wsClose {
+			return int(binary.BigEndian.Uint16(payload))
+		}
+	}
+}
+
+// blockingGenerator streams one token and then waits for the turn to be cancelled.
+type blockingGenerator struct{}
+
+func (blockingGenerator) Generate(ctx context.Context, req domain.GenerateRequest) (*domain.GenerateResponse, error) {
+	domain.TokenSinkFromContext(ctx)("thinking")
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+// capturingGenerator hands each request to the test, which runs on another goroutine.
+type capturingGenerator struct {
+	mocks.MockGenerator
+	requests chan domain.GenerateRequest
+}
+
+func (g *capturingGenerator) Generate(ctx context.Context, req domain.GenerateRequest) (*domain.GenerateResponse, error) {
+	g.requests <- req
+	return g.MockGenerator.Generate(ctx, req)
+}
+
+func chatServer(generator domain.GeneratorPort) (*httptest.Server, domain.ChatSocketHandlerPort) {
+	h := NewChatSocketHandler(generator, &mocks.MockLogger{}, nil, nil, []string{"https://app.example.com"}, 4096, 1<<20, time.Minute)
+	return httptest.NewServer(http.HandlerFunc(h.Chat)), h
+}
+
+func TestChatSocket_Conversation(t *testing.T) {
+	gen := &capturingGenerator{
+		MockGenerator: mocks.MockGenerator{Response: "hello there", Usage: &domain.Usage{TotalTokens: 7}},
+		requests:      make(chan domain.GenerateRequest, 2),
+	}
+	srv, _ := chatServer(gen)
+	defer srv.Close()
+	c := dialChat(t, srv)
+	if ready := c.next(); ready.Type != domain.ChatFrameReady || ready.ID == "" {
+		t.Fatalf("expected a ready frame with the connection ID, got %+v", ready)
+	}
+
+	c.send(domain.ChatFrame{Type: domain.ChatFrameSystem, Content: "Be brief."})
+	if ack := c.next(); ack.Type != domain.ChatFrameDone {
+		t.Fatalf("expected the system prompt to be acknowledged, got %+v", ack)
+	}
+	c.send(domain.ChatFrame{Type: domain.ChatFrameUser, Content: "hi", Model: "gemma:2b"})
+	start := c.next()
+	if start.Type != domain.ChatFrameStart || start.RequestID == "" || start.Model != "gemma:2b" {
+		t.Fatalf("unexpected start frame: %+v", start)
+	}
+	var streamed string
+	frame := c.next()
+	for ; frame.Type == domain.ChatFrameToken; frame = c.next() {
+		streamed += frame.Token
+	}
+	if streamed != "hello there" || frame.Type != domain.ChatFrameUsage || frame.Usage.TotalTokens != 7 {
+		t.Fatalf("expected the streamed reply then usage, got %q and %+v", streamed, frame)
+	}
+	if done := c.next(); done.Type != domain.ChatFrameDone || done.Content != "hello there" || done.RequestID != start.RequestID || done.Turns != 2 {
+		t.Fatalf("unexpected done frame: %+v", done)
+	}
+	<-gen.requests
+
+	// the second turn carries the conversation so far and keeps the model
+	c.send(domain.ChatFrame{Type: domain.ChatFrameUser, Content: "again"})
+	for frame = c.next(); frame.Type != domain.ChatFrameDone; frame = c.next() {
+	}
+	if req := <-gen.requests; len(req.Messages) != 3 || req.Messages[0].Content != "Be brief." || req.Messages[1].Content != "hi" ||
+		req.Model != "gemma:2b" || frame.Turns != 4 {
+		t.Errorf("expected the history to be sent with the next turn, got %+v", req)
+	}
+
+	c.send(domain.ChatFrame{Type: domain.ChatFramePing, RequestID: "p1"})
+	if pong := c.next(); pong.Type != domain.ChatFramePong || pong.RequestID != "p1" {
+		t.Errorf("expected a pong, got %+v", pong)
+	}
+	c.send(domain.ChatFrame{Type: "shout"})
+	if e := c.next(); e.Type != domain.ChatFrameError || e.Status != http.StatusBadRequest {
+		t.Errorf("expected an error for an unknown frame, got %+v", e)
+	}
+	c.send(domain.ChatFrame{Type: domain.ChatFrameUser, Content: "  "})
+	if e := c.next(); e.Type != domain.ChatFrameError || e.Status != http.StatusBadRequest {
+		t.Errorf("expected a validation error for an empty prompt, got %+v", e)
+	}
+
+	c.writeFrame(wsClose, binary.BigEndian.AppendUint16(nil, wsCloseNormal), true)
+	if code := c.expectClose(); code != wsCloseNormal {
+		t.Errorf("expected the close to be echoed, got %d", code)
+	}
+}
+
+func TestChatSocket_CancelAndShutdown(t *testing.T) {
+	srv, h := chatServer(blockingGenerator{})
+	defer srv.Close()
+	c := dialChat(t, srv)
+	c.next() // ready
+
+	c.send(domain.ChatFrame{Type: domain.ChatFrameUser, Content: "think hard"})
+	start := c.next()
+	if token := c.next(); token.Type != domain.ChatFrameToken {
+		t.Fatalf("expected a token, got %+v", token)
+	}
+	c.send(domain.ChatFrame{Type: domain.ChatFrameUser, Content: "and another"})
+	if e := c.next(); e.Type != domain.ChatFrameError || e.Status != http.StatusConflict {
+		t.Fatalf("expected a second turn to be refused while one runs, got %+v", e)
+	}
+	c.send(domain.ChatFrame{Type: domain.ChatFrameCancel})
+	if cancelled := c.next(); cancelled.Type != domain.ChatFrameCancelled || cancelled.RequestID != start.RequestID {
+		t.Fatalf("expected the turn to be cancelled, got %+v", cancelled)
+	}
+
+	h.Shutdown()
+	if code := c.expectClose(); code != wsCloseGoingAway {
+		t.Errorf("expected shutdown to close with 1001, got %d", code)
+	}
+}
+
+func TestChatSocket_ProtocolErrors(t *testing.T) {
+	srv, _ := chatServer(&mocks.MockGenerator{})
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/ws/chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUpgradeRequired {
+		t.Errorf("expected 426 for a plain GET, got %d", resp.StatusCode)
+	}
+
+	c := dialChat(t, srv)
+	c.next() // ready
+	c.writeFrame(wsText, []byte(`{"type":"ping"}`), false)
+	if code := c.expectClose(); code != wsCloseProtocol {
+		t.Errorf("expected an unmasked frame to close with 1002, got %d", code)
+	}
+
+	srv, _ = chatServer(&mocks.MockGenerator{})
+	defer srv.Close()
+	c = dialChat(t, srv)
+	c.next()
+	c.writeFrame(wsPing, []byte("are you there"), true)
+	if op, payload := c.readFrame(); op != wsPong || string(payload) != "are you there" {
+		t.Errorf("expected a pong echoing the ping, got %d %q", op, payload)
+	}
+	c.writeFrame(wsBinary, []byte{0, 1}, true)
+	if code := c.expectClose(); code != wsCloseUnsupported {
+		t.Errorf("expected a binary message to close with 1003, got %d", code)
+	}
+}
+
+func TestChatSocket_Origin(t *testing.T) {
+	cases := map[string]int{
+		"":                        http.StatusSwitchingProtocols,
+		"http://minivault":        http.StatusSwitchingProtocols,
+		"https://app.example.com": http.StatusSwitchingProtocols,
+		"https://evil.example":    http.StatusForbidden,
+		"null":                    http.StatusForbidden,
+	}
+	for origin, want := range cases {
+		// a server each, as connections log from their own goroutines
+		srv, _ := chatServer(&mocks.MockGenerator{})
+		defer srv.Close()
+		if _, _, resp := handshake(t, srv, origin); resp.StatusCode != want {
+			t.Errorf("origin %q: got %d, want %d", origin, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestChatSocket_HistoryIsCapped(t *testing.T) {
+	gen := &capturingGenerator{
+		MockGenerator: mocks.MockGenerator{Response: strings.Repeat("a", 200)},
+		requests:      make(chan domain.GenerateRequest, 6),
+	}
+	h := NewChatSocketHandler(gen, &mocks.MockLogger{}, nil, nil, nil, 4096, 1000, time.Minute)
+	srv := httptest.NewServer(http.HandlerFunc(h.Chat))
+	defer srv.Close()
+	c := dialChat(t, srv)
+	c.next() // ready
+
+	for range 6 {
+		c.send(domain.ChatFrame{Type: domain.ChatFrameUser, Content: "tell me more"})
+		for frame := c.next(); frame.Type != domain.ChatFrameDone; frame = c.next() {
+			if frame.Type == domain.ChatFrameError {
+				t.Fatalf("unexpected error: %+v", frame)
+			}
+		}
+		if data, _ := json.Marshal(<-gen.requests); len(data) > 1000 {
+			t.Fatalf("expected the conversation to stay within 1000 bytes, sent %d", len(data))
+		}
+	}
+}
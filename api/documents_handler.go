@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"minivault/domain"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type documentsHandler struct {
+	kb     domain.KnowledgeBasePort
+	logger domain.LoggerPort
+}
+
+func NewDocumentsHandler(kb domain.KnowledgeBasePort, logger domain.LoggerPort) domain.DocumentsHandlerPort {
+	return &documentsHandler{kb: kb, logger: logger}
+}
+
+// Ingest handles POST /documents. The body is either a JSON DocumentRequest or, with a
+// text/plain or text/markdown Content-Type, the raw document with collection, id and
+// title in the query string.
+func (h *documentsHandler) Ingest(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	ctx := domain.WithRequestID(r.Context(), reqID)
+
+	var req domain.DocumentRequest
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case domain.ContentTypeText, domain.ContentTypeMarkdown:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			code := http.StatusBadRequest
+			if bodyTooLarge(err) {
+				code = http.StatusRequestEntityTooLarge
+			}
+			writeError(w, h.logger, reqID, "Failed to read body", err, code)
+			return
+		}
+		q := r.URL.Query()
+		req = domain.DocumentRequest{
+			Collection:  q.Get("collection"),
+			ID:          q.Get("id"),
+			Title:       q.Get("title"),
+			ContentType: mediaType,
+			Content:     string(body),
+		}
+	default:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			msg, code := decodeErrorStatus(err)
+			writeError(w, h.logger, reqID, msg, err, code)
+			return
+		}
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, h.logger, reqID, "Validation error", err, http.StatusBadRequest)
+		return
+	}
+
+	doc, err := h.kb.Ingest(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrEmbeddingDimensions):
+		writeError(w, h.logger, reqID, "Embedding model does not match the collection", err, http.StatusConflict)
+		return
+	case err != nil:
+		writeError(w, h.logger, reqID, "Failed to ingest document", err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, h.logger, reqID, doc, http.StatusCreated)
+}
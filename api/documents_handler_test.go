@@ -0,0 +1,70 @@
+package api
+
+import (
+	"minivault/domain"
+	"minivault/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDocuments_IngestJSON(t *testing.T) {
+	kb := &mocks.MockKnowledgeBase{Document: &domain.Document{ID: "d1", Collection: "docs", Chunks: 3}}
+	h := &documentsHandler{kb: kb, logger: &mocks.MockLogger{}}
+	body := `{"collection": "docs", "title": "Guide", "content_type": "text/markdown", "content": "# Hi\nthere"}`
+	rec := httptest.NewRecorder()
+	h.Ingest(rec, httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader(body)))
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if kb.LastRequest.Collection != "docs" || kb.LastRequest.ContentType != domain.ContentTypeMarkdown || kb.LastRequest.Title != "Guide" {
+		t.Errorf("unexpected request: %+v", kb.LastRequest)
+	}
+}
+
+func TestDocuments_IngestRawMarkdown(t *testing.T) {
+	kb := &mocks.MockKnowledgeBase{Document: &domain.Document{ID: "guide"}}
+	h := &documentsHandler{kb: kb, logger: &mocks.MockLogger{}}
+	req := httptest.NewRequest(http.MethodPost, "/documents?collection=docs&id=guide", strings.NewReader("# Title\nBody"))
+	req.Header.Set("Content-Type", "text/markdown; charset=utf-8")
+	rec := httptest.NewRecorder()
+	h.Ingest(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", rec.Code)
+	}
+	if kb.LastRequest.ID != "guide" || kb.LastRequest.Content != "# Title\nBody" || kb.LastRequest.ContentType != domain.ContentTypeMarkdown {
+		t.Errorf("unexpected request: %+v", kb.LastRequest)
+	}
+}
+
+func TestDocuments_Validation(t *testing.T) {
+	for _, body := range []string{
+		`{"collection": "../etc", "content": "x"}`,
+		`{"collection": "docs", "content": "  "}`,
+		`{"collection": "docs", "content": "x", "content_type": "text/html"}`,
+	} {
+		h := &documentsHandler{kb: &mocks.MockKnowledgeBase{}, logger: &mocks.MockLogger{}}
+		rec := httptest.NewRecorder()
+		h.Ingest(rec, httptest.NewRequest(http.MethodPost, "/documents", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestGenerate_RetrievalCollectionNotFound(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Error: domain.ErrCollectionNotFound}
+	h := &handler{generator: mockGen, logger: &mocks.MockLogger{}}
+	body := `{"prompt": "q", "retrieval": {"collection": "nope", "top_k": 3}}`
+	rec := httptest.NewRecorder()
+	h.Generate(rec, httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(body)))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if mockGen.LastRequest.Retrieval == nil || mockGen.LastRequest.Retrieval.TopK != 3 {
+		t.Errorf("retrieval options not passed: %+v", mockGen.LastRequest.Retrieval)
+	}
+}
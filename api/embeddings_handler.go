@@ -0,0 +1,50 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"minivault/domain"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type embeddingsHandler struct {
+	embedder domain.EmbedderPort
+	logger   domain.LoggerPort
+}
+
+func NewEmbeddingsHandler(embedder domain.EmbedderPort, logger domain.LoggerPort) domain.EmbeddingsHandlerPort {
+	return &embeddingsHandler{embedder: embedder, logger: logger}
+}
+
+// Embed handles POST /embeddings.
+func (h *embeddingsHandler) Embed(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	ctx := domain.WithRequestID(r.Context(), reqID)
+
+	var req domain.EmbeddingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg, code := decodeErrorStatus(err)
+		writeError(w, h.logger, reqID, msg, err, code)
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, h.logger, reqID, "Validation error", err, http.StatusBadRequest)
+		return
+	}
+
+	resp, err := h.embedder.Embed(ctx, req)
+	switch {
+	case errors.Is(err, domain.ErrTooManyEmbeddingInputs), errors.Is(err, domain.ErrEmbeddingInputTooLong):
+		writeError(w, h.logger, reqID, "Input too large", err, http.StatusRequestEntityTooLarge)
+		return
+	case errors.Is(err, domain.ErrModelNotAllowed):
+		writeError(w, h.logger, reqID, "Model not allowed", err, http.StatusForbidden)
+		return
+	case err != nil:
+		writeError(w, h.logger, reqID, "Failed to compute embeddings", err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, h.logger, reqID, resp, http.StatusOK)
+}
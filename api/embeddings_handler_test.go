@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"minivault/domain"
+	"minivault/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddings_StringAndArrayInput(t *testing.T) {
+	for body, want := range map[string]int{
+		`{"input": "hello"}`:                       1,
+		`{"input": ["a", "b"], "model": "bge-m3"}`: 2,
+	} {
+		embedder := &mocks.MockEmbedder{Response: &domain.EmbeddingResponse{Model: "m", Dimensions: 2, Embeddings: [][]float64{{1, 2}}}}
+		h := &embeddingsHandler{embedder: embedder, logger: &mocks.MockLogger{}}
+		rec := httptest.NewRecorder()
+		h.Embed(rec, httptest.NewRequest(http.MethodPost, "/embeddings", strings.NewReader(body)))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", body, rec.Code)
+		}
+		if len(embedder.LastRequest.Input) != want {
+			t.Errorf("%s: got %d inputs, want %d", body, len(embedder.LastRequest.Input), want)
+		}
+		var resp domain.EmbeddingResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Dimensions != 2 {
+			t.Errorf("unexpected response: %+v %v", resp, err)
+		}
+	}
+}
+
+func TestEmbeddings_InvalidInput(t *testing.T) {
+	for _, body := range []string{`{"input": 42}`, `{"input": []}`, `{"input": ["ok", " "]}`, `{}`, `{"input": "x", "model": "../etc"}`} {
+		h := &embeddingsHandler{embedder: &mocks.MockEmbedder{}, logger: &mocks.MockLogger{}}
+		rec := httptest.NewRecorder()
+		h.Embed(rec, httptest.NewRequest(http.MethodPost, "/embeddings", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestEmbeddings_TooLarge(t *testing.T) {
+	embedder := &mocks.MockEmbedder{Error: fmt.Errorf("%w: 100, max 64", domain.ErrTooManyEmbeddingInputs)}
+	h := &embeddingsHandler{embedder: embedder, logger: &mocks.MockLogger{}}
+	rec := httptest.NewRecorder()
+	h.Embed(rec, httptest.NewRequest(http.MethodPost, "/embeddings", strings.NewReader(`{"input": "x"}`)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", rec.Code)
+	}
+}
+
+func TestEmbeddings_ModelNotAllowed(t *testing.T) {
+	embedder := &mocks.MockEmbedder{Error: fmt.Errorf("%w: bge-m3", domain.ErrModelNotAllowed)}
+	h := &embeddingsHandler{embedder: embedder, logger: &mocks.MockLogger{}}
+	rec := httptest.NewRecorder()
+	h.Embed(rec, httptest.NewRequest(http.MethodPost, "/embeddings", strings.NewReader(`{"input": "x", "model": "bge-m3"}`)))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestEmbeddings_BodyOverLimit(t *testing.T) {
+	h := &embeddingsHandler{embedder: &mocks.MockEmbedder{}, logger: &mocks.MockLogger{}}
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/embeddings", strings.NewReader(`{"input": "`+strings.Repeat("x", 64)+`"}`))
+	req.Body = http.MaxBytesReader(rec, req.Body, 32)
+	h.Embed(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for a body over the limit, got %d", rec.Code)
+	}
+}
@@ -2,9 +2,13 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"minivault/domain"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -12,10 +16,12 @@ import (
 type handler struct {
 	generator domain.GeneratorPort
 	logger    domain.LoggerPort
+	notifier  domain.CallbackPort
+	inflight  domain.InFlightPort // nil when generations are not tracked
 }
 
-func NewHttpHandler(generator domain.GeneratorPort, logger domain.LoggerPort) domain.HttpHandlerPort {
-	return &handler{generator: generator, logger: logger}
+func NewHttpHandler(generator domain.GeneratorPort, logger domain.LoggerPort, notifier domain.CallbackPort, inflight domain.InFlightPort) domain.HttpHandlerPort {
+	return &handler{generator: generator, logger: logger, notifier: notifier, inflight: inflight}
 }
 
 // Generate handles /generate POST requests with improved error logging and structured responses.
@@ -32,9 +38,7 @@ func writeError(w http.ResponseWriter, logger domain.LoggerPort, reqID string, m
 func (h *handler) Generate(w http.ResponseWriter, r *http.Request) {
 	// Assign a request ID for tracing
 	reqID := uuid.New().String()
-
-	// Limit body size
-	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+	ctx := domain.WithRequestID(r.Context(), reqID)
 
 	// Validate request method
 	if r.Method != http.MethodPost {
@@ -46,7 +50,8 @@ func (h *handler) Generate(w http.ResponseWriter, r *http.Request) {
 	var req domain.GenerateRequest
 	dec := json.NewDecoder(r.Body)
 	if err := dec.Decode(&req); err != nil {
-		writeError(w, h.logger, reqID, "Invalid JSON", err, http.StatusBadRequest)
+		msg, code := decodeErrorStatus(err)
+		writeError(w, h.logger, reqID, msg, err, code)
 		return
 	}
 
@@ -56,22 +61,234 @@ func (h *handler) Generate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Callback requests are accepted immediately and delivered in the background
+	if req.CallbackURL != "" {
+		if h.notifier == nil {
+			writeError(w, h.logger, reqID, "Validation error", domain.ErrCallbacksDisabled, http.StatusBadRequest)
+			return
+		}
+		if err := h.notifier.Validate(req.CallbackURL); err != nil {
+			writeError(w, h.logger, reqID, "Validation error", err, http.StatusBadRequest)
+			return
+		}
+		jobCtx, done := h.track(context.WithoutCancel(ctx), req, true)
+		go h.generateAndNotify(jobCtx, req, done)
+		writeJSON(w, h.logger, reqID, domain.GenerateAcceptedResponse{RequestID: reqID, Status: "accepted"}, http.StatusAccepted)
+		return
+	}
+
 	// Generate response
-	resp, err := h.generator.Generate(req.Prompt)
+	genCtx, done := h.track(ctx, req, false)
+	if req.Stream {
+		h.generateStream(w, genCtx, reqID, req)
+		done()
+		return
+	}
+	resp, err := h.generator.Generate(genCtx, req)
+	err = cancelled(genCtx, err)
+	done()
 	if err != nil {
-		writeError(w, h.logger, reqID, "Failed to generate response", err, http.StatusInternalServerError)
+		h.writeGenerateError(w, reqID, err)
 		return
 	}
 
-	// Encode response
+	if resp.Usage != nil {
+		w.Header().Set("Server-Timing", serverTiming(resp.Usage))
+	}
+	writeJSON(w, h.logger, reqID, resp, http.StatusOK)
+}
+
+// generateStream answers a "stream": true request with NDJSON stream events. The
+// status line goes out with the first token, so failures before it are answered
+// like unstreamed ones; later failures end the stream with an "error" event.
+func (h *handler) generateStream(w http.ResponseWriter, ctx context.Context, reqID string, req domain.GenerateRequest) {
+	rc := http.NewResponseController(w)
+	enc := json.NewEncoder(w)
+	started := false
+	send := func(event domain.GenerateStreamEvent) {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.Header().Set("X-Request-ID", reqID)
+			w.WriteHeader(http.StatusOK)
+		}
+		enc.Encode(event)
+		rc.Flush()
+	}
+
+	ctx = domain.WithTokenSink(ctx, func(token string) {
+		send(domain.GenerateStreamEvent{Type: domain.StreamEventToken, Token: token})
+	})
+	resp, err := h.generator.Generate(ctx, req)
+	err = cancelled(ctx, err)
+	switch {
+	case err != nil && !started:
+		h.writeGenerateError(w, reqID, err)
+	case err != nil:
+		msg, code := generateErrorStatus(err)
+		h.logger.LogError(msg+" [reqID: "+reqID+"]", err)
+		send(domain.GenerateStreamEvent{Type: domain.StreamEventError, Error: msg, Status: code, RequestID: reqID})
+	default:
+		send(domain.GenerateStreamEvent{Type: domain.StreamEventDone, Response: resp, RequestID: reqID})
+	}
+}
+
+// writeGenerateError answers a failed generation. Quota, policy and filter failures
+// get JSON bodies with their details.
+func (h *handler) writeGenerateError(w http.ResponseWriter, reqID string, err error) {
+	var policyErr *domain.PolicyError
+	var filterErr *domain.FilterError
+	var quotaErr *domain.QuotaError
+	switch {
+	case errors.As(err, &quotaErr):
+		writeQuotaError(w, h.logger, reqID, quotaErr)
+	case errors.As(err, &policyErr):
+		h.logger.LogWarn(err.Error() + " [reqID: " + reqID + "]")
+		writeJSON(w, h.logger, reqID, domain.PolicyErrorResponse{
+			Error:     "Request blocked by input policy",
+			RequestID: reqID,
+			Policies:  policyErr.Results,
+		}, http.StatusUnprocessableEntity)
+	case errors.As(err, &filterErr):
+		h.logger.LogWarn(err.Error() + " [reqID: " + reqID + "]")
+		writeJSON(w, h.logger, reqID, domain.FilterErrorResponse{
+			Error:     "Response blocked by output filter",
+			RequestID: reqID,
+			Filters:   filterErr.Results,
+		}, http.StatusUnprocessableEntity)
+	default:
+		msg, code := generateErrorStatus(err)
+		writeError(w, h.logger, reqID, msg, err, code)
+	}
+}
+
+// generateErrorStatus maps a generation failure to the message and status it is answered with.
+func generateErrorStatus(err error) (string, int) {
+	switch {
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		return "Quota exceeded", http.StatusTooManyRequests
+	case errors.Is(err, domain.ErrInputBlocked):
+		return "Request blocked by input policy", http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrOutputBlocked):
+		return "Response blocked by output filter", http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrUnknownTool), errors.Is(err, domain.ErrInvalidTool):
+		return "Validation error", http.StatusBadRequest
+	case errors.Is(err, domain.ErrGenerationCancelled), errors.Is(err, domain.ErrServerDraining):
+		return "Generation cancelled", http.StatusServiceUnavailable
+	case errors.Is(err, domain.ErrModelNotAllowed):
+		return "Model not allowed", http.StatusForbidden
+	case errors.Is(err, domain.ErrCollectionNotFound):
+		return "Collection not found", http.StatusNotFound
+	case errors.Is(err, domain.ErrEmbeddingDimensions):
+		return "Embedding model does not match the collection", http.StatusConflict
+	case errors.Is(err, domain.ErrInvalidStructuredOutput):
+		return "Model output did not match the requested format", http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrToolRoundsExceeded):
+		return "Model did not produce an answer", http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrConversationTooLarge):
+		return "Request body too large", http.StatusRequestEntityTooLarge
+	default:
+		return "Failed to generate response", http.StatusInternalServerError
+	}
+}
+
+// decodeErrorStatus maps a failure to decode a JSON request body: one over the
+// route's body limit is 413, anything else malformed input.
+func decodeErrorStatus(err error) (string, int) {
+	if bodyTooLarge(err) {
+		return "Request body too large", http.StatusRequestEntityTooLarge
+	}
+	return "Invalid JSON", http.StatusBadRequest
+}
+
+// bodyTooLarge reports whether reading a request body stopped at the route's body limit.
+func bodyTooLarge(err error) bool {
+	var maxBytes *http.MaxBytesError
+	return errors.As(err, &maxBytes)
+}
+
+// writeQuotaError answers 429 with the quota that was hit and when it resets.
+func writeQuotaError(w http.ResponseWriter, logger domain.LoggerPort, reqID string, err *domain.QuotaError) {
+	retry := max(int(time.Until(err.ResetsAt).Seconds()+0.999), 1)
+	w.Header().Set("Retry-After", strconv.Itoa(retry))
+	writeJSON(w, logger, reqID, domain.QuotaErrorResponse{
+		Error:     "Quota exceeded",
+		Code:      domain.ErrorCodeQuotaExceeded,
+		RequestID: reqID,
+		Quota:     err.Quota,
+		Limit:     err.Limit,
+		Used:      err.Used,
+		ResetsAt:  err.ResetsAt,
+	}, http.StatusTooManyRequests)
+}
+
+// serverTiming formats Ollama's timings as a Server-Timing header value, so they
+// show up in browser dev tools next to the network timings.
+func serverTiming(u *domain.Usage) string {
+	return "load;dur=" + formatMS(u.LoadMS) +
+		", prompt_eval;dur=" + formatMS(u.PromptEvalMS) +
+		", eval;dur=" + formatMS(u.EvalMS) +
+		", ollama;dur=" + formatMS(u.TotalMS)
+}
+
+func formatMS(ms float64) string {
+	return strconv.FormatFloat(ms, 'f', -1, 64)
+}
+
+// writeJSON encodes v before writing headers so encoding failures can still produce a 500.
+func writeJSON(w http.ResponseWriter, logger domain.LoggerPort, reqID string, v any, code int) {
 	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(domain.GenerateResponse{Response: resp}); err != nil {
-		writeError(w, h.logger, reqID, "Failed to encode response", err, http.StatusInternalServerError)
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		writeError(w, logger, reqID, "Failed to encode response", err, http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("X-Request-ID", reqID)
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(code)
 	w.Write(buf.Bytes())
 }
+
+// track registers a generation with the in-flight tracker, if there is one.
+func (h *handler) track(ctx context.Context, req domain.GenerateRequest, async bool) (context.Context, func()) {
+	if h.inflight == nil {
+		return ctx, func() {}
+	}
+	return h.inflight.Start(ctx, req, async)
+}
+
+// cancelled reports a generation an admin or a shutdown cancelled as such, rather
+// than as the error the cancellation caused further down.
+func cancelled(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if cause := context.Cause(ctx); errors.Is(cause, domain.ErrGenerationCancelled) || errors.Is(cause, domain.ErrServerDraining) {
+		return cause
+	}
+	return err
+}
+
+// generateAndNotify runs a generation detached from the HTTP request and pushes the result to its callback URL.
+// done is called once the callback has been delivered or given up on.
+func (h *handler) generateAndNotify(ctx context.Context, req domain.GenerateRequest, done func()) {
+	defer done()
+	payload := domain.CallbackPayload{RequestID: domain.RequestIDFromContext(ctx), Status: domain.CallbackStatusCompleted}
+	resp, err := h.generator.Generate(ctx, req)
+	err = cancelled(ctx, err)
+	if err != nil {
+		payload.Status = domain.CallbackStatusFailed
+		payload.Error = err.Error()
+	} else {
+		payload.Response = resp.Response
+		payload.Model = resp.Model
+		payload.JSON = resp.JSON
+		payload.ToolCalls = resp.ToolCalls
+		payload.ToolsUsed = resp.ToolsUsed
+		payload.Citations = resp.Citations
+		payload.Usage = resp.Usage
+	}
+	payload.CompletedAt = time.Now().UTC()
+	h.notifier.Deliver(ctx, req.CallbackURL, payload)
+}
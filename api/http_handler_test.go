@@ -3,12 +3,14 @@ package api
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"minivault/domain"
 	"minivault/mocks"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 )
 
 // contains is a helper for substring checks
@@ -227,8 +229,271 @@ func TestGenerate_GeneratorError(t *testing.T) {
 	}
 }
 
+func TestGenerate_BlockedByPolicy(t *testing.T) {
+	results := []domain.PolicyResult{{Policy: "blocklist", Action: domain.PolicyActionBlock, Reason: "matched blocklist entry on line 3"}}
+	mockGen := &mocks.MockGenerator{Error: &domain.PolicyError{Results: results}}
+	mockLog := &mocks.MockLogger{}
+	h := &handler{generator: mockGen, logger: mockLog}
+
+	req := httptest.NewRequest(http.MethodPost, "/generate", bytes.NewReader([]byte(`{"prompt": "x"}`)))
+	rec := httptest.NewRecorder()
+
+	h.Generate(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rec.Code)
+	}
+	var body domain.PolicyErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if body.RequestID == "" || len(body.Policies) != 1 || body.Policies[0].Reason != results[0].Reason {
+		t.Errorf("unexpected body: %+v", body)
+	}
+	if len(mockLog.Errors) != 0 || len(mockLog.Warnings) != 1 {
+		t.Error("a blocked request is a warning, not an error")
+	}
+}
+
+func TestGenerate_ModelNotAllowed(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Error: fmt.Errorf("%w: mistral:7b", domain.ErrModelNotAllowed)}
+	h := &handler{generator: mockGen, logger: &mocks.MockLogger{}}
+
+	rec := httptest.NewRecorder()
+	h.Generate(rec, httptest.NewRequest(http.MethodPost, "/generate", bytes.NewReader([]byte(`{"prompt": "x", "model": "mistral:7b"}`))))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+}
+
+func TestGenerate_BlockedByOutputFilter(t *testing.T) {
+	results := []domain.FilterResult{{Filter: "deny", Action: domain.FilterActionBlocked, Detail: "deny list line 2"}}
+	mockGen := &mocks.MockGenerator{Error: &domain.FilterError{Results: results}}
+	mockLog := &mocks.MockLogger{}
+	h := &handler{generator: mockGen, logger: mockLog}
+
+	req := httptest.NewRequest(http.MethodPost, "/generate", bytes.NewReader([]byte(`{"prompt": "x"}`)))
+	rec := httptest.NewRecorder()
+
+	h.Generate(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rec.Code)
+	}
+	var body domain.FilterErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if body.Error != "Response blocked by output filter" || len(body.Filters) != 1 || body.Filters[0].Detail != results[0].Detail {
+		t.Errorf("unexpected body: %+v", body)
+	}
+}
+
+func TestGenerate_ServerTiming(t *testing.T) {
+	usage := &domain.Usage{TotalMS: 912.5, LoadMS: 3, PromptEvalMS: 40.2, EvalMS: 850}
+	mockGen := &mocks.MockGenerator{Response: "hello", Usage: usage}
+	h := &handler{generator: mockGen, logger: &mocks.MockLogger{}}
+
+	rec := httptest.NewRecorder()
+	h.Generate(rec, httptest.NewRequest(http.MethodPost, "/generate", bytes.NewReader([]byte(`{"prompt": "hi"}`))))
+
+	want := "load;dur=3, prompt_eval;dur=40.2, eval;dur=850, ollama;dur=912.5"
+	if got := rec.Header().Get("Server-Timing"); got != want {
+		t.Errorf("Server-Timing = %q, want %q", got, want)
+	}
+	var resp domain.GenerateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Usage == nil || resp.Usage.EvalMS != 850 {
+		t.Errorf("usage missing from body: %+v %v", resp, err)
+	}
+}
+
+func TestGenerate_QuotaExceeded(t *testing.T) {
+	resets := time.Now().Add(90 * time.Second)
+	mockGen := &mocks.MockGenerator{Error: &domain.QuotaError{Quota: domain.QuotaRequestsPerDay, Limit: 100, Used: 100, ResetsAt: resets}}
+	h := &handler{generator: mockGen, logger: &mocks.MockLogger{}}
+
+	rec := httptest.NewRecorder()
+	h.Generate(rec, httptest.NewRequest(http.MethodPost, "/generate", bytes.NewReader([]byte(`{"prompt": "hi"}`))))
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "90" {
+		t.Errorf("Retry-After = %q, want 90", got)
+	}
+	var body domain.QuotaErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Code != domain.ErrorCodeQuotaExceeded || body.Quota != domain.QuotaRequestsPerDay || body.Limit != 100 {
+		t.Errorf("unexpected body: %+v %v", body, err)
+	}
+}
+
 var errTest = &mockError{"fail"}
 
 type mockError struct{ msg string }
 
 func (e *mockError) Error() string { return e.msg }
+
+func TestGenerate_CallbackAccepted(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Response: "later"}
+	mockLog := &mocks.MockLogger{}
+	mockCb := &mocks.MockCallback{Delivered: make(chan domain.CallbackPayload, 1)}
+	h := &handler{generator: mockGen, logger: mockLog, notifier: mockCb}
+
+	reqBody := []byte(`{"prompt": "hi", "callback_url": "https://hooks.example.com/cb"}`)
+	req := httptest.NewRequest(http.MethodPost, "/generate", bytes.NewReader(reqBody))
+	rec := httptest.NewRecorder()
+
+	h.Generate(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rec.Code)
+	}
+	var accepted domain.GenerateAcceptedResponse
+	if err := json.NewDecoder(rec.Body).Decode(&accepted); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if accepted.RequestID == "" || accepted.RequestID != rec.Header().Get("X-Request-ID") {
+		t.Errorf("expected request ID in body and header, got %q", accepted.RequestID)
+	}
+
+	payload := <-mockCb.Delivered
+	if payload.Status != domain.CallbackStatusCompleted || payload.Response != "later" || payload.RequestID != accepted.RequestID {
+		t.Errorf("unexpected callback payload: %+v", payload)
+	}
+}
+
+func TestGenerate_CallbackHostRejected(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Response: "x"}
+	mockLog := &mocks.MockLogger{}
+	mockCb := &mocks.MockCallback{ValidateError: domain.ErrCallbackHostNotAllowed}
+	h := &handler{generator: mockGen, logger: mockLog, notifier: mockCb}
+
+	reqBody := []byte(`{"prompt": "hi", "callback_url": "https://evil.example.com/cb"}`)
+	req := httptest.NewRequest(http.MethodPost, "/generate", bytes.NewReader(reqBody))
+	rec := httptest.NewRecorder()
+
+	h.Generate(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+	if mockGen.LastPrompt != "" {
+		t.Error("generator should not run for a rejected callback")
+	}
+}
+
+func TestGenerate_PassesRequestIDInContext(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Response: "ok"}
+	h := &handler{generator: mockGen, logger: &mocks.MockLogger{}}
+
+	req := httptest.NewRequest(http.MethodPost, "/generate", bytes.NewReader([]byte(`{"prompt":"hi"}`)))
+	rec := httptest.NewRecorder()
+	h.Generate(rec, req)
+
+	if got := domain.RequestIDFromContext(mockGen.LastCtx); got == "" || got != rec.Header().Get("X-Request-ID") {
+		t.Errorf("expected generator context to carry request ID %q, got %q", rec.Header().Get("X-Request-ID"), got)
+	}
+}
+
+func TestGenerate_InvalidFormat(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Response: "ok"}
+	mockLog := &mocks.MockLogger{}
+	h := &handler{generator: mockGen, logger: mockLog}
+
+	for _, format := range []string{`"xml"`, `{"type": "strng"}`, `{"properties": {"n": {"pattern": "("}}}`, `{"required": "name"}`, `{"anyOf": []}`} {
+		req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"prompt": "hi", "format": `+format+`}`))
+		rec := httptest.NewRecorder()
+		h.Generate(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("format %s: expected 400, got %d", format, rec.Code)
+		}
+	}
+	if mockGen.LastRequest.Prompt != "" {
+		t.Error("an invalid format should not reach the generator")
+	}
+}
+
+func TestGenerate_StructuredOutputRejected(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Error: fmt.Errorf("%w: bad", domain.ErrInvalidStructuredOutput)}
+	mockLog := &mocks.MockLogger{}
+	h := &handler{generator: mockGen, logger: mockLog}
+
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"prompt": "hi", "format": {"type": "object"}}`))
+	rec := httptest.NewRecorder()
+	h.Generate(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422, got %d", rec.Code)
+	}
+	if string(mockGen.LastRequest.Format) != `{"type": "object"}` {
+		t.Errorf("format not passed to generator: %s", mockGen.LastRequest.Format)
+	}
+}
+
+func TestGenerate_UnknownServerTool(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Error: fmt.Errorf("%w: %q", domain.ErrUnknownTool, "shell")}
+	mockLog := &mocks.MockLogger{}
+	h := &handler{generator: mockGen, logger: mockLog}
+
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"prompt": "hi", "server_tools": ["shell"]}`))
+	rec := httptest.NewRecorder()
+	h.Generate(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestGenerate_InvalidToolDefinition(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Response: "ok"}
+	mockLog := &mocks.MockLogger{}
+	h := &handler{generator: mockGen, logger: mockLog}
+
+	req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"prompt": "hi", "tools": [{"type": "function", "function": {}}]}`))
+	rec := httptest.NewRecorder()
+	h.Generate(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestGenerate_Stream(t *testing.T) {
+	streamEvents := func(body string) []domain.GenerateStreamEvent {
+		var events []domain.GenerateStreamEvent
+		dec := json.NewDecoder(strings.NewReader(body))
+		for dec.More() {
+			var e domain.GenerateStreamEvent
+			if err := dec.Decode(&e); err != nil {
+				t.Fatal(err)
+			}
+			events = append(events, e)
+		}
+		return events
+	}
+	h := &handler{generator: &mocks.MockGenerator{Response: "hello there"}, logger: &mocks.MockLogger{}}
+	rec := httptest.NewRecorder()
+	h.Generate(rec, httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"prompt": "hi", "stream": true}`)))
+	events := streamEvents(rec.Body.String())
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" || len(events) != 3 ||
+		events[0].Token != "hello " || events[2].Type != domain.StreamEventDone || events[2].Response.Response != "hello there" {
+		t.Errorf("unexpected stream: %d %s", rec.Code, rec.Body)
+	}
+
+	blocked := &domain.FilterError{}
+	h.generator = &mocks.MockGenerator{Response: "hello there", Error: blocked}
+	rec = httptest.NewRecorder()
+	h.Generate(rec, httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"prompt": "hi", "stream": true}`)))
+	events = streamEvents(rec.Body.String())
+	if last := events[len(events)-1]; rec.Code != http.StatusOK || last.Type != domain.StreamEventError || last.Status != http.StatusUnprocessableEntity {
+		t.Errorf("a failure mid-stream should end it with an error event, got %d %s", rec.Code, rec.Body)
+	}
+
+	h.generator = &mocks.MockGenerator{Error: &domain.QuotaError{}}
+	rec = httptest.NewRecorder()
+	h.Generate(rec, httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(`{"prompt": "hi", "stream": true}`)))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("a failure before the first token should keep its status, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"minivault/domain"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type interactionsHandler struct {
+	store        domain.InteractionStorePort
+	tenantStores map[string]domain.InteractionStorePort // tenant ID -> the tenant's interaction log
+	logger       domain.LoggerPort
+}
+
+// NewInteractionsHandler serves store to callers outside any tenant and each tenant's
+// store to that tenant's callers only.
+func NewInteractionsHandler(store domain.InteractionStorePort, tenantStores map[string]domain.InteractionStorePort, logger domain.LoggerPort) domain.InteractionsHandlerPort {
+	return &interactionsHandler{store: store, tenantStores: tenantStores, logger: logger}
+}
+
+// scope returns the interaction log the caller may read and, unless the caller is an
+// admin, the API key ID whose records are the only ones they may see. Without
+// authentication there is nobody to scope to, so ok is false and nothing is served.
+func (h *interactionsHandler) scope(r *http.Request) (store domain.InteractionStorePort, keyID string, ok bool) {
+	caller, ok := domain.CallerFromContext(r.Context())
+	if !ok {
+		return nil, "", false
+	}
+	store = h.store
+	if caller.Tenant != "" {
+		store = h.tenantStores[caller.Tenant]
+	}
+	if !caller.Admin {
+		keyID = caller.KeyID
+	}
+	return store, keyID, true
+}
+
+// List handles GET /interactions with filters and cursor pagination. Admins may
+// filter by any api_key_id; other callers only ever see their own key's records.
+func (h *interactionsHandler) List(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+
+	store, keyID, ok := h.scope(r)
+	if !ok {
+		writeError(w, h.logger, reqID, "Forbidden", domain.ErrHistoryNeedsAuth, http.StatusForbidden)
+		return
+	}
+	q, err := parseInteractionQuery(r)
+	if err != nil {
+		writeError(w, h.logger, reqID, "Validation error", err, http.StatusBadRequest)
+		return
+	}
+	if keyID != "" {
+		q.APIKeyID = keyID
+	}
+
+	page, err := store.Query(q)
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		writeError(w, h.logger, reqID, "Validation error", err, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		writeError(w, h.logger, reqID, "Failed to query interactions", err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, h.logger, reqID, page, http.StatusOK)
+}
+
+// Get handles GET /interactions/{id}.
+func (h *interactionsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+
+	store, keyID, ok := h.scope(r)
+	if !ok {
+		writeError(w, h.logger, reqID, "Forbidden", domain.ErrHistoryNeedsAuth, http.StatusForbidden)
+		return
+	}
+	interaction, err := store.Get(r.PathValue("id"))
+	// other keys' records are reported missing rather than forbidden, so IDs cannot be probed
+	if errors.Is(err, domain.ErrInteractionNotFound) || (err == nil && keyID != "" && interaction.APIKeyID != keyID) {
+		writeError(w, h.logger, reqID, "Interaction not found", nil, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		writeError(w, h.logger, reqID, "Failed to load interaction", err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, h.logger, reqID, interaction, http.StatusOK)
+}
+
+// Stats handles GET /interactions/stats: token usage and speed per model, filtered by
+// since, until, model and api_key_id.
+func (h *interactionsHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+
+	store, keyID, ok := h.scope(r)
+	if !ok {
+		writeError(w, h.logger, reqID, "Forbidden", domain.ErrHistoryNeedsAuth, http.StatusForbidden)
+		return
+	}
+	q, err := parseInteractionQuery(r)
+	if err != nil {
+		writeError(w, h.logger, reqID, "Validation error", err, http.StatusBadRequest)
+		return
+	}
+	if keyID != "" {
+		q.APIKeyID = keyID
+	}
+	stats, err := store.Stats(q)
+	if err != nil {
+		writeError(w, h.logger, reqID, "Failed to aggregate interactions", err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, h.logger, reqID, map[string]any{"models": stats}, http.StatusOK)
+}
+
+// parseInteractionQuery reads filters from the query string:
+// since, until (RFC 3339), kind, model, request_id, api_key_id, q, cursor, limit, order.
+func parseInteractionQuery(r *http.Request) (domain.InteractionQuery, error) {
+	v := r.URL.Query()
+	q := domain.InteractionQuery{
+		Kind:      v.Get("kind"),
+		Model:     v.Get("model"),
+		RequestID: v.Get("request_id"),
+		APIKeyID:  v.Get("api_key_id"),
+		Search:    v.Get("q"),
+		Cursor:    v.Get("cursor"),
+		Order:     v.Get("order"),
+	}
+	for name, dst := range map[string]*time.Time{"since": &q.Since, "until": &q.Until} {
+		if raw := v.Get(name); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return q, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+			}
+			*dst = t
+		}
+	}
+	if raw := v.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return q, errors.New("limit must be a positive integer")
+		}
+		q.Limit = n
+	}
+	switch q.Order {
+	case "", domain.OrderDesc, domain.OrderAsc:
+	default:
+		return q, errors.New("order must be asc or desc")
+	}
+	return q, nil
+}
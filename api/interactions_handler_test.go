@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"minivault/domain"
+	"minivault/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// asCaller returns req as authenticated by caller.
+func asCaller(req *http.Request, caller domain.Caller) *http.Request {
+	return req.WithContext(domain.WithCaller(req.Context(), caller))
+}
+
+var admin = domain.Caller{KeyID: "ops", Admin: true}
+
+func TestInteractions_ListParsesFilters(t *testing.T) {
+	store := &mocks.MockInteractionStore{Page: &domain.InteractionPage{
+		Interactions: []domain.Interaction{{ID: "a", Prompt: "p", Response: "r"}},
+		NextCursor:   "next",
+	}}
+	h := &interactionsHandler{store: store, logger: &mocks.MockLogger{}}
+
+	req := httptest.NewRequest(http.MethodGet, "/interactions?since=2025-01-01T00:00:00Z&model=gemma:2b&request_id=r1&api_key_id=alice&q=hello&limit=10&order=asc&cursor=c", nil)
+	rec := httptest.NewRecorder()
+	h.List(rec, asCaller(req, admin))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	q := store.LastQuery
+	if !q.Since.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) || q.Model != "gemma:2b" || q.RequestID != "r1" ||
+		q.APIKeyID != "alice" || q.Search != "hello" || q.Limit != 10 || q.Order != domain.OrderAsc || q.Cursor != "c" {
+		t.Errorf("unexpected query: %+v", q)
+	}
+	var page domain.InteractionPage
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if len(page.Interactions) != 1 || page.NextCursor != "next" {
+		t.Errorf("unexpected page: %+v", page)
+	}
+}
+
+func TestInteractions_ListValidation(t *testing.T) {
+	for _, query := range []string{"since=yesterday", "limit=0", "order=random"} {
+		store := &mocks.MockInteractionStore{}
+		h := &interactionsHandler{store: store, logger: &mocks.MockLogger{}}
+		rec := httptest.NewRecorder()
+		h.List(rec, asCaller(httptest.NewRequest(http.MethodGet, "/interactions?"+query, nil), admin))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+
+	store := &mocks.MockInteractionStore{Error: domain.ErrInvalidCursor}
+	h := &interactionsHandler{store: store, logger: &mocks.MockLogger{}}
+	rec := httptest.NewRecorder()
+	h.List(rec, asCaller(httptest.NewRequest(http.MethodGet, "/interactions?cursor=x", nil), admin))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid cursor: expected 400, got %d", rec.Code)
+	}
+}
+
+func TestInteractions_Get(t *testing.T) {
+	store := &mocks.MockInteractionStore{Interaction: &domain.Interaction{ID: "abc", Prompt: "p"}}
+	h := &interactionsHandler{store: store, logger: &mocks.MockLogger{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /interactions/{id}", h.Get)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, asCaller(httptest.NewRequest(http.MethodGet, "/interactions/abc", nil), admin))
+	if rec.Code != http.StatusOK || store.LastID != "abc" {
+		t.Errorf("expected 200 for abc, got %d (%q)", rec.Code, store.LastID)
+	}
+
+	store.Error = domain.ErrInteractionNotFound
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, asCaller(httptest.NewRequest(http.MethodGet, "/interactions/missing", nil), admin))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestInteractions_Stats(t *testing.T) {
+	store := &mocks.MockInteractionStore{ModelStats: []domain.ModelStats{{Model: "gemma:2b", Requests: 2, TokensPerSecond: 42.5}}}
+	h := &interactionsHandler{store: store, logger: &mocks.MockLogger{}}
+
+	rec := httptest.NewRecorder()
+	h.Stats(rec, asCaller(httptest.NewRequest(http.MethodGet, "/interactions/stats?model=gemma:2b&since=2025-01-01T00:00:00Z", nil), admin))
+	if rec.Code != http.StatusOK || store.LastQuery.Model != "gemma:2b" || store.LastQuery.Since.IsZero() {
+		t.Fatalf("expected filters to be passed, got %d %+v", rec.Code, store.LastQuery)
+	}
+	var body struct {
+		Models []domain.ModelStats `json:"models"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || len(body.Models) != 1 || body.Models[0].TokensPerSecond != 42.5 {
+		t.Errorf("unexpected body: %+v %v", body, err)
+	}
+}
+
+func TestInteractions_TenantScoped(t *testing.T) {
+	shared := &mocks.MockInteractionStore{Page: &domain.InteractionPage{}}
+	red := &mocks.MockInteractionStore{Page: &domain.InteractionPage{}, Interaction: &domain.Interaction{ID: "abc", Tenant: "red", APIKeyID: "alice"}}
+	h := NewInteractionsHandler(shared, map[string]domain.InteractionStorePort{"red": red}, &mocks.MockLogger{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /interactions", h.List)
+	mux.HandleFunc("GET /interactions/{id}", h.Get)
+
+	serve := func(path string, caller domain.Caller) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req.WithContext(domain.WithCaller(req.Context(), caller)))
+		return rec.Code
+	}
+	if code := serve("/interactions/abc", domain.Caller{KeyID: "alice", Tenant: "red"}); code != http.StatusOK || red.LastID != "abc" || shared.LastID != "" {
+		t.Errorf("a tenant's caller should read the tenant's log, got %d", code)
+	}
+	serve("/interactions?model=gemma:2b", domain.Caller{KeyID: "ops"})
+	if shared.LastQuery.Model != "gemma:2b" || red.LastQuery.Model != "" {
+		t.Errorf("callers outside any tenant should read the shared log, got %+v / %+v", shared.LastQuery, red.LastQuery)
+	}
+}
+
+func TestInteractions_ScopedToCaller(t *testing.T) {
+	store := &mocks.MockInteractionStore{Page: &domain.InteractionPage{}, Interaction: &domain.Interaction{ID: "abc", APIKeyID: "bob"}}
+	h := NewInteractionsHandler(store, nil, &mocks.MockLogger{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /interactions", h.List)
+	mux.HandleFunc("GET /interactions/stats", h.Stats)
+	mux.HandleFunc("GET /interactions/{id}", h.Get)
+	serve := func(path string, caller *domain.Caller) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if caller != nil {
+			req = asCaller(req, *caller)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for _, path := range []string{"/interactions", "/interactions/stats", "/interactions/abc"} {
+		if code := serve(path, nil); code != http.StatusForbidden {
+			t.Errorf("%s: expected 403 without authentication, got %d", path, code)
+		}
+	}
+	alice := &domain.Caller{KeyID: "alice"}
+	if serve("/interactions?api_key_id=bob", alice); store.LastQuery.APIKeyID != "alice" {
+		t.Errorf("a caller's query should be limited to their own key, got %q", store.LastQuery.APIKeyID)
+	}
+	if serve("/interactions/stats", alice); store.LastQuery.APIKeyID != "alice" {
+		t.Errorf("a caller's stats should be limited to their own key, got %q", store.LastQuery.APIKeyID)
+	}
+	if code := serve("/interactions/abc", alice); code != http.StatusNotFound {
+		t.Errorf("another key's record should be reported missing, got %d", code)
+	}
+	if code := serve("/interactions/abc", &domain.Caller{KeyID: "bob"}); code != http.StatusOK {
+		t.Errorf("a caller should read their own record, got %d", code)
+	}
+	if serve("/interactions?api_key_id=bob", &admin); store.LastQuery.APIKeyID != "bob" {
+		t.Errorf("admins should filter by any key, got %q", store.LastQuery.APIKeyID)
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"minivault/domain"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type modelsHandler struct {
+	models domain.ModelManagerPort
+	logger domain.LoggerPort
+}
+
+func NewModelsHandler(models domain.ModelManagerPort, logger domain.LoggerPort) domain.ModelsHandlerPort {
+	return &modelsHandler{models: models, logger: logger}
+}
+
+// List handles GET /admin/models.
+func (h *modelsHandler) List(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	models, err := h.models.ListModels(r.Context())
+	audit(h.logger, r, reqID, "list models", "", err)
+	if err != nil {
+		writeError(w, h.logger, reqID, "Failed to list models", err, http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, h.logger, reqID, map[string]any{"models": models}, http.StatusOK)
+}
+
+// Show handles GET /admin/models/{name...}.
+func (h *modelsHandler) Show(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	name := r.PathValue("name")
+	if !domain.ValidModelName(name) {
+		writeError(w, h.logger, reqID, "Validation error", domain.ErrInvalidModelName, http.StatusBadRequest)
+		return
+	}
+	info, err := h.models.ShowModel(r.Context(), name)
+	audit(h.logger, r, reqID, "show model", name, err)
+	if err != nil {
+		writeModelError(w, h.logger, reqID, "Failed to show model", err)
+		return
+	}
+	writeJSON(w, h.logger, reqID, info, http.StatusOK)
+}
+
+// Delete handles DELETE /admin/models/{name...}.
+func (h *modelsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	name := r.PathValue("name")
+	if !domain.ValidModelName(name) {
+		writeError(w, h.logger, reqID, "Validation error", domain.ErrInvalidModelName, http.StatusBadRequest)
+		return
+	}
+	err := h.models.DeleteModel(r.Context(), name)
+	audit(h.logger, r, reqID, "delete model", name, err)
+	if err != nil {
+		writeModelError(w, h.logger, reqID, "Failed to delete model", err)
+		return
+	}
+	w.Header().Set("X-Request-ID", reqID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Running handles GET /admin/ps.
+func (h *modelsHandler) Running(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	models, err := h.models.RunningModels(r.Context())
+	audit(h.logger, r, reqID, "list running models", "", err)
+	if err != nil {
+		writeError(w, h.logger, reqID, "Failed to list running models", err, http.StatusBadGateway)
+		return
+	}
+	writeJSON(w, h.logger, reqID, map[string]any{"models": models}, http.StatusOK)
+}
+
+// Pull handles POST /admin/models/pull, streaming progress as server-sent events:
+// "progress" events carry domain.PullProgress, then one "done" or "error" event.
+func (h *modelsHandler) Pull(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	var req domain.PullRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		msg, code := decodeErrorStatus(err)
+		writeError(w, h.logger, reqID, msg, err, code)
+		return
+	}
+	if !domain.ValidModelName(req.Model) {
+		writeError(w, h.logger, reqID, "Validation error", domain.ErrInvalidModelName, http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, h.logger, reqID, "Streaming not supported", nil, http.StatusInternalServerError)
+		return
+	}
+
+	audit(h.logger, r, reqID, "pull model started", req.Model, nil)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Request-ID", reqID)
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	err := h.models.PullModel(r.Context(), req.Model, func(p domain.PullProgress) {
+		writeSSE(w, "progress", p)
+		flusher.Flush()
+	})
+	audit(h.logger, r, reqID, "pull model", req.Model, err)
+	if err != nil {
+		writeSSE(w, "error", map[string]string{"error": err.Error()})
+	} else {
+		writeSSE(w, "done", map[string]string{"model": req.Model, "status": "success"})
+	}
+	flusher.Flush()
+}
+
+// writeSSE writes one server-sent event with a JSON data line.
+func writeSSE(w http.ResponseWriter, event string, v any) {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(v) // Encode's trailing newline ends the data line
+	fmt.Fprintf(w, "event: %s\ndata: %s\n", event, buf.Bytes())
+}
+
+func writeModelError(w http.ResponseWriter, logger domain.LoggerPort, reqID, msg string, err error) {
+	if errors.Is(err, domain.ErrModelNotFound) {
+		writeError(w, logger, reqID, "Model not found", err, http.StatusNotFound)
+		return
+	}
+	writeError(w, logger, reqID, msg, err, http.StatusBadGateway)
+}
+
+// audit records an admin action on target (if any) with the caller's key ID.
+func audit(logger domain.LoggerPort, r *http.Request, reqID, action, target string, err error) {
+	caller, _ := domain.CallerFromContext(r.Context())
+	msg := fmt.Sprintf("admin %s", action)
+	if target != "" {
+		msg += fmt.Sprintf(" %q", target)
+	}
+	msg += fmt.Sprintf(" by %q [reqID: %s]", caller.KeyID, reqID)
+	if err != nil {
+		logger.LogError(msg+" failed", err)
+		return
+	}
+	logger.LogInfo(msg)
+}
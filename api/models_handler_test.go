@@ -0,0 +1,135 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"minivault/domain"
+	"minivault/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestModelsHandler(models *mocks.MockModelManager) (*modelsHandler, *mocks.MockLogger) {
+	logger := &mocks.MockLogger{}
+	return &modelsHandler{models: models, logger: logger}, logger
+}
+
+func adminRequest(method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	return r.WithContext(domain.WithCaller(r.Context(), domain.Caller{KeyID: "ops", Admin: true}))
+}
+
+func TestModels_ListIsAudited(t *testing.T) {
+	h, logger := newTestModelsHandler(&mocks.MockModelManager{Models: []domain.ModelSummary{{Name: "gemma:2b"}}})
+	rec := httptest.NewRecorder()
+	h.List(rec, adminRequest(http.MethodGet, "/admin/models", ""))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var resp struct {
+		Models []domain.ModelSummary `json:"models"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || len(resp.Models) != 1 || resp.Models[0].Name != "gemma:2b" {
+		t.Errorf("unexpected response: %+v %v", resp, err)
+	}
+	if len(logger.Infos) != 1 || !strings.Contains(logger.Infos[0], `by "ops"`) {
+		t.Errorf("expected audit entry naming the caller, got %v", logger.Infos)
+	}
+}
+
+func TestModels_ShowAndDelete(t *testing.T) {
+	models := &mocks.MockModelManager{Info: domain.ModelInfo(`{"license":"MIT"}`)}
+	h, _ := newTestModelsHandler(models)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/models/{name...}", h.Show)
+	mux.HandleFunc("DELETE /admin/models/{name...}", h.Delete)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/models/library/gemma:2b", ""))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"license":"MIT"`) {
+		t.Errorf("show: got %d %s", rec.Code, rec.Body)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodDelete, "/admin/models/gemma:2b", ""))
+	if rec.Code != http.StatusNoContent || len(models.Deleted) != 1 || models.Deleted[0] != "gemma:2b" {
+		t.Errorf("delete: got %d, deleted %v", rec.Code, models.Deleted)
+	}
+}
+
+func TestModels_Errors(t *testing.T) {
+	h, _ := newTestModelsHandler(&mocks.MockModelManager{Error: domain.ErrModelNotFound})
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/models/{name...}", h.Show)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/models/missing", ""))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, adminRequest(http.MethodGet, "/admin/models/bad%20name", ""))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid name, got %d", rec.Code)
+	}
+
+	h, _ = newTestModelsHandler(&mocks.MockModelManager{Error: errors.New("connection refused")})
+	rec = httptest.NewRecorder()
+	h.Running(rec, adminRequest(http.MethodGet, "/admin/ps", ""))
+	if rec.Code != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", rec.Code)
+	}
+}
+
+func TestModels_PullStreamsProgress(t *testing.T) {
+	h, logger := newTestModelsHandler(&mocks.MockModelManager{Progress: []domain.PullProgress{
+		{Status: "pulling manifest"},
+		{Status: "downloading", Digest: "sha256:abc", Total: 100, Completed: 50},
+		{Status: "success"},
+	}})
+	rec := httptest.NewRecorder()
+	h.Pull(rec, adminRequest(http.MethodPost, "/admin/models/pull", `{"model":"gemma:2b"}`))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+	body := rec.Body.String()
+	if n := strings.Count(body, "event: progress\n"); n != 3 {
+		t.Errorf("expected 3 progress events, got %d:\n%s", n, body)
+	}
+	if !strings.Contains(body, `data: {"status":"downloading","digest":"sha256:abc","total":100,"completed":50}`) {
+		t.Errorf("missing progress data:\n%s", body)
+	}
+	if !strings.HasSuffix(body, "event: done\ndata: {\"model\":\"gemma:2b\",\"status\":\"success\"}\n\n") {
+		t.Errorf("expected trailing done event:\n%s", body)
+	}
+	if len(logger.Infos) != 2 {
+		t.Errorf("expected start and finish audit entries, got %v", logger.Infos)
+	}
+}
+
+func TestModels_PullFailureEndsWithErrorEvent(t *testing.T) {
+	h, logger := newTestModelsHandler(&mocks.MockModelManager{Error: errors.New("pull failed: file does not exist")})
+	rec := httptest.NewRecorder()
+	h.Pull(rec, adminRequest(http.MethodPost, "/admin/models/pull", `{"model":"nope"}`))
+
+	if !strings.Contains(rec.Body.String(), "event: error\ndata: {\"error\":\"pull failed: file does not exist\"}") {
+		t.Errorf("expected error event, got:\n%s", rec.Body)
+	}
+	if len(logger.Errors) != 1 {
+		t.Errorf("expected failed pull to be audited as an error, got %v", logger.Errors)
+	}
+}
+
+func TestModels_PullValidatesName(t *testing.T) {
+	h, _ := newTestModelsHandler(&mocks.MockModelManager{})
+	rec := httptest.NewRecorder()
+	h.Pull(rec, adminRequest(http.MethodPost, "/admin/models/pull", `{"model":"../etc"}`))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
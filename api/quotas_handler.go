@@ -0,0 +1,45 @@
+package api
+
+import (
+	"minivault/domain"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+type quotasHandler struct {
+	quotas domain.QuotaPort
+	logger domain.LoggerPort
+}
+
+func NewQuotasHandler(quotas domain.QuotaPort, logger domain.LoggerPort) domain.QuotasHandlerPort {
+	return &quotasHandler{quotas: quotas, logger: logger}
+}
+
+// List handles GET /admin/quotas: usage of every key that has any.
+func (h *quotasHandler) List(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	audit(h.logger, r, reqID, "list quotas", "", nil)
+	writeJSON(w, h.logger, reqID, map[string]any{"quotas": h.quotas.List()}, http.StatusOK)
+}
+
+// Get handles GET /admin/quotas/{key}.
+func (h *quotasHandler) Get(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	keyID := r.PathValue("key")
+	audit(h.logger, r, reqID, "show quota", keyID, nil)
+	writeJSON(w, h.logger, reqID, h.quotas.Usage(keyID), http.StatusOK)
+}
+
+// Reset handles DELETE /admin/quotas/{key}, clearing the key's current usage.
+func (h *quotasHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	reqID := uuid.New().String()
+	keyID := r.PathValue("key")
+	err := h.quotas.Reset(keyID)
+	audit(h.logger, r, reqID, "reset quota", keyID, err)
+	if err != nil {
+		writeError(w, h.logger, reqID, "Failed to reset quota", err, http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, h.logger, reqID, h.quotas.Usage(keyID), http.StatusOK)
+}
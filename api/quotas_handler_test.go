@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"minivault/domain"
+	"minivault/mocks"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestQuotasHandler(t *testing.T) {
+	quotas := &mocks.MockQuota{}
+	quotas.Check("alice")
+	quotas.Debit("alice", 30)
+	logger := &mocks.MockLogger{}
+	h := NewQuotasHandler(quotas, logger)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/quotas", h.List)
+	mux.HandleFunc("GET /admin/quotas/{key}", h.Get)
+	mux.HandleFunc("DELETE /admin/quotas/{key}", h.Reset)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/quotas", nil))
+	var list struct {
+		Quotas []domain.QuotaUsage `json:"quotas"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&list); err != nil || len(list.Quotas) != 1 || list.Quotas[0].Day.Tokens != 30 {
+		t.Errorf("unexpected list: %+v %v", list, err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/quotas/alice", nil))
+	var usage domain.QuotaUsage
+	if err := json.NewDecoder(rec.Body).Decode(&usage); err != nil || usage.KeyID != "alice" || usage.Day.Requests != 1 {
+		t.Errorf("unexpected usage: %+v %v", usage, err)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/quotas/alice", nil))
+	if rec.Code != http.StatusOK || len(quotas.Resets) != 1 || quotas.Resets[0] != "alice" {
+		t.Errorf("reset not applied: %d %v", rec.Code, quotas.Resets)
+	}
+	if len(logger.Infos) != 3 {
+		t.Errorf("expected every admin action to be audited, got %v", logger.Infos)
+	}
+}
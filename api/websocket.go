@@ -0,0 +1,316 @@
+package api
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// A minimal RFC 6455 server: the opening handshake, masked client frames,
+// fragmented messages, ping/pong and the closing handshake. Extensions such as
+// compression are not negotiated.
+
+// websocketGUID is appended to the client's key to compute Sec-WebSocket-Accept.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket opcodes.
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// WebSocket close codes.
+const (
+	wsCloseNormal       = 1000
+	wsCloseGoingAway    = 1001
+	wsCloseProtocol     = 1002
+	wsCloseUnsupported  = 1003
+	wsCloseInvalidData  = 1007
+	wsCloseTooBig       = 1009
+	wsWriteTimeout      = 10 * time.Second
+	wsMaxControlPayload = 125
+)
+
+// wsCloseError is returned by readMessage once the connection is closing, with the
+// close code sent to or received from the peer.
+type wsCloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *wsCloseError) Error() string {
+	return fmt.Sprintf("websocket closed: %d %s", e.Code, e.Reason)
+}
+
+type wsConn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	maxMessage  int64
+	readTimeout time.Duration // reset by every frame received, pongs included
+
+	wmu    sync.Mutex // serialises writers
+	closed bool       // a close frame has been sent
+}
+
+// isWebSocketUpgrade reports whether r asks to open a WebSocket.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerHasToken(r.Header, "Connection", "upgrade") && headerHasToken(r.Header, "Upgrade", "websocket")
+}
+
+// upgradeWebSocket completes the opening handshake and takes over the connection.
+// protocol, if not empty, is selected from the client's Sec-WebSocket-Protocol
+// list. Browsers are only let in from the server's own origin or one in origins.
+// On failure an HTTP error has already been written.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, protocol string, origins []string, maxMessage int64, readTimeout time.Duration) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	switch {
+	case r.Method != http.MethodGet:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return nil, errors.New("websocket handshake must be a GET")
+	case !isWebSocketUpgrade(r):
+		w.Header().Set("Upgrade", "websocket")
+		http.Error(w, "Upgrade Required: this endpoint only speaks WebSocket", http.StatusUpgradeRequired)
+		return nil, errors.New("not a websocket upgrade")
+	case r.Header.Get("Sec-WebSocket-Version") != "13":
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "Unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("unsupported websocket version")
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, "Invalid Sec-WebSocket-Key", http.StatusBadRequest)
+		return nil, errors.New("invalid Sec-WebSocket-Key")
+	}
+	if !originAllowed(r, origins) {
+		http.Error(w, "Forbidden: origin not allowed", http.StatusForbidden)
+		return nil, fmt.Errorf("origin %q not allowed", r.Header.Get("Origin"))
+	}
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return nil, fmt.Errorf("failed to take over the connection: %w", err)
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	response := "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n"
+	if protocol != "" && headerHasToken(r.Header, "Sec-WebSocket-Protocol", protocol) {
+		response += "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if _, err := io.WriteString(conn, response+"\r\n"); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to complete the handshake: %w", err)
+	}
+	conn.SetDeadline(time.Time{})
+	return &wsConn{conn: conn, br: brw.Reader, maxMessage: maxMessage, readTimeout: readTimeout}, nil
+}
+
+// originAllowed guards against cross-site WebSocket hijacking: browsers send the
+// page's Origin, which must be the server's own host or listed in allowed. Clients
+// that send no Origin are not browsers and carry their own credentials.
+func originAllowed(r *http.Request, allowed []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, a := range allowed {
+		if strings.EqualFold(strings.TrimSuffix(a, "/"), origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host)
+}
+
+// headerHasToken reports whether a comma-separated header contains token, ignoring case.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readMessage returns the next text or binary message. Pings are answered and
+// pongs skipped along the way. A close frame from the peer is answered and
+// returned as *wsCloseError, as are protocol violations after closing with the
+// matching code.
+func (c *wsConn) readMessage() (int, []byte, error) {
+	var message []byte
+	opcode := -1
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			code, reason := wsCloseNormal, ""
+			if len(payload) >= 2 {
+				code, reason = int(binary.BigEndian.Uint16(payload)), string(payload[2:])
+			}
+			c.close(code, "")
+			return 0, nil, &wsCloseError{Code: code, Reason: reason}
+		case wsText, wsBinary:
+			if opcode != -1 {
+				return 0, nil, c.fail(wsCloseProtocol, "expected a continuation frame")
+			}
+			opcode = op
+		case wsContinuation:
+			if opcode == -1 {
+				return 0, nil, c.fail(wsCloseProtocol, "continuation without a message")
+			}
+		default:
+			return 0, nil, c.fail(wsCloseProtocol, "unknown opcode")
+		}
+		if int64(len(message)+len(payload)) > c.maxMessage {
+			return 0, nil, c.fail(wsCloseTooBig, "message too big")
+		}
+		message = append(message, payload...)
+		if fin {
+			if opcode == wsText && !utf8.Valid(message) {
+				return 0, nil, c.fail(wsCloseInvalidData, "text is not valid UTF-8")
+			}
+			return opcode, message, nil
+		}
+	}
+}
+
+// readFrame reads and unmasks one frame.
+func (c *wsConn) readFrame() (fin bool, op int, payload []byte, err error) {
+	if c.readTimeout > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+	}
+	var head [2]byte
+	if _, err := io.ReadFull(c.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, op = head[0]&0x80 != 0, int(head[0]&0x0F)
+	if head[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(wsCloseProtocol, "reserved bits set")
+	}
+	if head[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(wsCloseProtocol, "client frames must be masked")
+	}
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if op >= wsClose && (length > wsMaxControlPayload || !fin) {
+		return false, 0, nil, c.fail(wsCloseProtocol, "invalid control frame")
+	}
+	if length > uint64(c.maxMessage) {
+		return false, 0, nil, c.fail(wsCloseTooBig, "message too big")
+	}
+	var mask [4]byte
+	if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+		return false, 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// writeText sends one text message.
+func (c *wsConn) writeText(data []byte) error {
+	return c.writeFrame(wsText, data)
+}
+
+// ping sends a ping the client must answer within the read timeout.
+func (c *wsConn) ping() error {
+	return c.writeFrame(wsPing, nil)
+}
+
+func (c *wsConn) writeFrame(op int, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+	return c.writeFrameLocked(op, payload)
+}
+
+func (c *wsConn) writeFrameLocked(op int, payload []byte) error {
+	head := make([]byte, 2, 10)
+	head[0] = 0x80 | byte(op) // server frames are unfragmented and unmasked
+	switch n := len(payload); {
+	case n <= 125:
+		head[1] = byte(n)
+	case n <= 0xFFFF:
+		head[1] = 126
+		head = binary.BigEndian.AppendUint16(head, uint16(n))
+	default:
+		head[1] = 127
+		head = binary.BigEndian.AppendUint64(head, uint64(n))
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	_, err := (&net.Buffers{head, payload}).WriteTo(c.conn)
+	return err
+}
+
+// close sends a close frame, once; the peer's reply is not awaited.
+func (c *wsConn) close(code int, reason string) {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return
+	}
+	c.closed = true
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	if len(reason) > wsMaxControlPayload-2 {
+		reason = reason[:wsMaxControlPayload-2]
+	}
+	c.writeFrameLocked(wsClose, append(payload, reason...))
+}
+
+// fail closes the connection for a protocol violation and returns the error.
+func (c *wsConn) fail(code int, reason string) error {
+	c.close(code, reason)
+	return &wsCloseError{Code: code, Reason: reason}
+}
+
+// release closes the underlying connection.
+func (c *wsConn) release() error {
+	return c.conn.Close()
+}
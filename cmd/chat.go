@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/infrastructure"
+	"minivault/usecases"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+const chatUsage = `usage: minivault chat [flags]
+
+Starts an interactive conversation that keeps its history and streams replies.
+By default it talks to the MiniVault server at MINIVAULT_URL with the API key
+MINIVAULT_API_KEY; -local generates in-process against the configured Ollama.
+When a conversation outgrows the server's GENERATE_MAX_BODY_BYTES, its oldest
+turns are no longer sent.
+
+commands:
+  /model [name]     show or switch the model; "/model -" goes back to the default
+  /system [prompt]  show or set the system prompt; "/system -" removes it
+  /reset            forget the conversation, keeping model and system prompt
+  /save <file.md>   write the conversation to a Markdown file
+  /load <file.md>   continue a saved conversation
+  /help             list the commands
+  /exit             leave (or Ctrl-D); Ctrl-C stops a reply being generated
+
+flags:
+`
+
+const chatHelp = `/model [name|-]  /system [prompt|-]  /reset  /save <file.md>  /load <file.md>  /help  /exit
+`
+
+// runChat implements the "chat" subcommand and returns the process exit code.
+func runChat(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("chat", flag.ContinueOnError)
+	url := fs.String("url", cfg.ClientURL, "MiniVault server URL")
+	local := fs.Bool("local", false, "generate in-process instead of calling a server")
+	model := fs.String("model", "", "model to answer with (default: the server's)")
+	system := fs.String("system", "", "system prompt")
+	load := fs.String("load", "", "continue the conversation saved in this file")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, chatUsage); fs.PrintDefaults() }
+	if err := fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return 2
+	}
+
+	// room for the "stream" field the client adds to each request
+	maxBytes := cfg.GenerateMaxBodyBytes - 16
+	generator := infrastructure.NewMiniVaultClient(*url, cfg.ClientAPIKey)
+	if *local {
+		// console logs would interleave with the conversation; interactions are still logged
+		localCfg := *cfg
+		localCfg.LogConsole = "none"
+		gen, logger, err := buildGenerator(&localCfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "chat: %v\n", err)
+			return 1
+		}
+		defer logger.Close()
+		generator = gen
+		maxBytes = 0
+	}
+	chat := usecases.NewChatSession(generator, *model, maxBytes)
+	if *load != "" {
+		if err := loadTranscript(chat, *load); err != nil {
+			fmt.Fprintf(os.Stderr, "chat: %v\n", err)
+			return 1
+		}
+	}
+	if *system != "" {
+		chat.SetSystem(*system)
+	}
+
+	// Ctrl-C cancels the reply in progress, or leaves at the prompt
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(os.Stdin)
+		scanner.Buffer(nil, 1<<20)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	fmt.Fprintf(os.Stderr, "minivault chat (%s) - /help for commands\n", chatTarget(*local, *url))
+	for {
+		fmt.Print("> ")
+		var line string
+		select {
+		case l, ok := <-lines:
+			if !ok {
+				fmt.Println()
+				return 0
+			}
+			line = strings.TrimSpace(l)
+		case <-interrupts:
+			fmt.Println()
+			return 0
+		}
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "/"):
+			if !chatCommand(chat, line) {
+				return 0
+			}
+		default:
+			chatTurn(chat, line, interrupts)
+		}
+	}
+}
+
+// chatTurn sends one prompt and streams the reply; an interrupt cancels it.
+func chatTurn(chat domain.ChatSessionPort, prompt string, interrupts <-chan os.Signal) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-interrupts:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	streamed := false
+	ctx = domain.WithTokenSink(ctx, func(token string) {
+		streamed = true
+		io.WriteString(os.Stdout, token)
+	})
+	before := len(chat.History())
+	resp, err := chat.Send(ctx, prompt)
+	if streamed {
+		fmt.Println()
+	}
+	var remote *domain.RemoteError
+	switch {
+	case errors.Is(err, context.Canceled):
+		fmt.Fprintln(os.Stderr, "(cancelled)")
+	case errors.As(err, &remote) && remote.StatusCode == http.StatusRequestEntityTooLarge:
+		fmt.Fprintln(os.Stderr, "error: the server refused the conversation as too large; /reset it or match GENERATE_MAX_BODY_BYTES to the server's")
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	case !streamed:
+		fmt.Println(resp.Response)
+	}
+	if err == nil && len(chat.History()) < before+2 {
+		fmt.Fprintf(os.Stderr, "(the oldest %d message(s) no longer fit in a request and were forgotten)\n", before+2-len(chat.History()))
+	}
+}
+
+// chatCommand runs a slash command, returning false when the session should end.
+func chatCommand(chat domain.ChatSessionPort, line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "/exit", "/quit":
+		return false
+	case "/help":
+		fmt.Fprint(os.Stderr, chatHelp)
+	case "/model":
+		switch {
+		case arg == "":
+		case arg == "-":
+			chat.SetModel("")
+		case !domain.ValidModelName(arg):
+			fmt.Fprintf(os.Stderr, "error: %v\n", domain.ErrInvalidModelName)
+			return true
+		default:
+			chat.SetModel(arg)
+		}
+		fmt.Fprintf(os.Stderr, "model: %s\n", orDefault(chat.Model()))
+	case "/system":
+		switch arg {
+		case "":
+		case "-":
+			chat.SetSystem("")
+		default:
+			chat.SetSystem(arg)
+		}
+		fmt.Fprintf(os.Stderr, "system prompt: %s\n", orDefault(chat.System()))
+	case "/reset":
+		chat.Reset()
+		fmt.Fprintln(os.Stderr, "conversation cleared")
+	case "/save":
+		if arg == "" {
+			fmt.Fprintln(os.Stderr, "usage: /save <file.md>")
+			break
+		}
+		if err := saveTranscript(chat, arg); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			break
+		}
+		fmt.Fprintf(os.Stderr, "saved %d turn(s) to %s\n", len(chat.History()), arg)
+	case "/load":
+		if arg == "" {
+			fmt.Fprintln(os.Stderr, "usage: /load <file.md>")
+			break
+		}
+		if err := loadTranscript(chat, arg); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			break
+		}
+		fmt.Fprintf(os.Stderr, "loaded %d turn(s) from %s\n", len(chat.History()), arg)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %s\n%s", name, chatHelp)
+	}
+	return true
+}
+
+func saveTranscript(chat domain.ChatSessionPort, path string) error {
+	messages := chat.History()
+	if chat.System() != "" {
+		messages = append([]domain.OllamaChatMessage{{Role: "system", Content: chat.System()}}, messages...)
+	}
+	return os.WriteFile(path, usecases.FormatTranscript(messages), 0600)
+}
+
+func loadTranscript(chat domain.ChatSessionPort, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	messages, err := usecases.ParseTranscript(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	chat.Load(messages)
+	return nil
+}
+
+func chatTarget(local bool, url string) string {
+	if local {
+		return "in-process"
+	}
+	return url
+}
+
+func orDefault(s string) string {
+	if s == "" {
+		return "(default)"
+	}
+	return s
+}
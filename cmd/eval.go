@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/usecases"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+const evalUsage = `usage: minivault eval -suite <file.json> [flags]
+
+Runs a golden-set suite through the same generator the server uses and prints
+PASS/FAIL per case with an aggregate score. Exits 1 if the score is below
+-min-score (default: every case must pass). Its generations are logged under
+-log-dir, apart from the server's interaction log.
+`
+
+// runEval implements the "eval" subcommand and returns the process exit code.
+func runEval(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	suitePath := fs.String("suite", "", "suite file (JSON) to run (required)")
+	jsonOut := fs.String("json", "", "write the full report as JSON to this file")
+	junitOut := fs.String("junit", "", "write a JUnit XML report to this file")
+	minScore := fs.Float64("min-score", 1, "minimum fraction of passing cases for a zero exit code")
+	verbose := fs.Bool("v", false, "print responses of failing cases and server logs")
+	logDir := fs.String("log-dir", filepath.Join(cfg.LogDir, "eval"), "directory for the interaction log of the run")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, evalUsage); fs.PrintDefaults() }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *suitePath == "" {
+		fs.Usage()
+		return 2
+	}
+
+	suite, err := loadEvalSuite(*suitePath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+		return 2
+	}
+
+	if !*verbose {
+		cfg.LogConsole = "none"
+	}
+	// a log of its own: the server may be writing, and rotating, the one in LOG_DIR
+	cfg.LogDir = *logDir
+	generator, logger, err := buildGenerator(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+		return 1
+	}
+	defer logger.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	report := usecases.NewEvaluator(generator).Run(ctx, suite)
+
+	writeEvalText(os.Stdout, report, *verbose)
+	if *jsonOut != "" {
+		if err := writeFile(*jsonOut, func(w io.Writer) error {
+			enc := json.NewEncoder(w)
+			enc.SetIndent("", "  ")
+			return enc.Encode(report)
+		}); err != nil {
+			fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+			return 1
+		}
+	}
+	if *junitOut != "" {
+		if err := writeFile(*junitOut, func(w io.Writer) error { return writeJUnit(w, report) }); err != nil {
+			fmt.Fprintf(os.Stderr, "eval: %v\n", err)
+			return 1
+		}
+	}
+	if report.Score < *minScore {
+		return 1
+	}
+	return 0
+}
+
+func loadEvalSuite(path string) (domain.EvalSuite, error) {
+	var suite domain.EvalSuite
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return suite, err
+	}
+	if err := json.Unmarshal(data, &suite); err != nil {
+		return suite, fmt.Errorf("invalid suite %s: %w", path, err)
+	}
+	if suite.Name == "" {
+		suite.Name = strings.TrimSuffix(path, ".json")
+	}
+	if len(suite.Cases) == 0 {
+		return suite, fmt.Errorf("suite %s has no cases", path)
+	}
+	return suite, nil
+}
+
+func writeEvalText(w io.Writer, r domain.EvalReport, verbose bool) {
+	for _, c := range r.Cases {
+		status := "PASS"
+		if !c.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s  %s (%d ms)\n", status, c.Name, c.LatencyMS)
+		if c.Error != "" {
+			fmt.Fprintf(w, "      error: %s\n", c.Error)
+		}
+		for _, a := range c.Assertions {
+			if !a.Passed {
+				fmt.Fprintf(w, "      %s: %s\n", a.Type, a.Message)
+			}
+		}
+		if verbose && !c.Passed && c.Response != "" {
+			fmt.Fprintf(w, "      response: %s\n", truncate(c.Response, 200))
+		}
+	}
+	fmt.Fprintf(w, "\n%s: %d/%d cases passed, score %.3f (assertions %.3f) in %d ms\n",
+		r.Suite, r.Passed, r.Total, r.Score, r.AssertionScore, r.DurationMS)
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Errors    int         `xml:"errors,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+func writeJUnit(w io.Writer, r domain.EvalReport) error {
+	suite := junitSuite{
+		Name:      r.Suite,
+		Tests:     r.Total,
+		Time:      seconds(r.DurationMS),
+		Timestamp: r.StartedAt.Format("2006-01-02T15:04:05"),
+	}
+	for _, c := range r.Cases {
+		jc := junitCase{Name: c.Name, Classname: r.Suite, Time: seconds(c.LatencyMS), SystemOut: c.Response}
+		if c.Error != "" {
+			suite.Errors++
+			jc.Error = &junitMessage{Message: c.Error}
+		} else if !c.Passed {
+			suite.Failures++
+			var msgs []string
+			for _, a := range c.Assertions {
+				if !a.Passed {
+					msgs = append(msgs, a.Type+": "+a.Message)
+				}
+			}
+			jc.Failure = &junitMessage{Message: msgs[0], Body: strings.Join(msgs, "\n")}
+		}
+		suite.Cases = append(suite.Cases, jc)
+	}
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	return enc.Encode(junitSuites{Suites: []junitSuite{suite}})
+}
+
+func seconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
+
+func writeFile(path string, write func(io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/infrastructure"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+const generateUsage = `usage: minivault generate [flags] [prompt...]
+
+Sends a prompt to a MiniVault server and streams the answer to stdout. The prompt
+is the arguments, the file given with -file, or else stdin. The server is
+MINIVAULT_URL and the API key MINIVAULT_API_KEY.
+
+exit codes:
+  0  success
+  1  the server failed to generate an answer
+  2  usage error
+  3  missing or invalid API key, or not allowed
+  4  request refused: invalid, blocked by a policy or filter, or not found
+  5  rate limit or quota exceeded
+  6  server unreachable, unavailable or timed out
+
+flags:
+`
+
+// Exit codes of the generate command, by error class.
+const (
+	exitFailed      = 1
+	exitUsage       = 2
+	exitAuth        = 3
+	exitRefused     = 4
+	exitLimited     = 5
+	exitUnavailable = 6
+)
+
+// optionFlags collects repeated -option name=value flags into Ollama options.
+// Values that parse as JSON (numbers, booleans) are sent as such.
+type optionFlags map[string]any
+
+func (o optionFlags) String() string { return "" }
+
+func (o optionFlags) Set(s string) error {
+	name, raw, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return errors.New("want name=value")
+	}
+	var value any
+	if json.Unmarshal([]byte(raw), &value) != nil {
+		value = raw
+	}
+	o[name] = value
+	return nil
+}
+
+// runGenerate implements the "generate" subcommand and returns the process exit code.
+func runGenerate(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	url := fs.String("url", cfg.ClientURL, "MiniVault server URL")
+	model := fs.String("model", "", "model to answer with (default: the server's)")
+	system := fs.String("system", "", "system prompt sent before the prompt")
+	file := fs.String("file", "", `read the prompt from this file ("-" for stdin)`)
+	options := optionFlags{}
+	fs.Var(options, "option", "model option as name=value, repeatable (e.g. temperature=0.2)")
+	jsonOut := fs.Bool("json", false, "print the whole JSON response instead of streaming the text")
+	verbose := fs.Bool("v", false, "print the model and token usage to stderr")
+	timeout := fs.Duration("timeout", 0, "give up after this long (0 = no limit)")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, generateUsage); fs.PrintDefaults() }
+	if err := fs.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	prompt, err := readPrompt(fs.Args(), *file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "generate: %v\n", err)
+		return exitUsage
+	}
+	if strings.TrimSpace(prompt) == "" {
+		fs.Usage()
+		return exitUsage
+	}
+	req := domain.GenerateRequest{Prompt: prompt, Model: *model}
+	if *system != "" {
+		req.Messages = []domain.OllamaChatMessage{{Role: "system", Content: *system}}
+	}
+	if len(options) > 0 {
+		req.Options = options
+	}
+	if err := req.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "generate: %v\n", err)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	streamed := false
+	if !*jsonOut {
+		ctx = domain.WithTokenSink(ctx, func(token string) {
+			streamed = true
+			io.WriteString(os.Stdout, token)
+		})
+	}
+
+	resp, err := infrastructure.NewMiniVaultClient(*url, cfg.ClientAPIKey).Generate(ctx, req)
+	if streamed {
+		fmt.Println()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "generate: %v\n", err)
+		return generateExitCode(err)
+	}
+	if *jsonOut {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(resp)
+	} else if !streamed {
+		fmt.Println(resp.Response) // e.g. only client-side tool calls, or a JSON format
+	}
+	if *verbose && resp.Usage != nil {
+		fmt.Fprintf(os.Stderr, "model %s: %d prompt + %d completion tokens, %.1f tokens/s\n",
+			resp.Model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, resp.Usage.TokensPerSecond)
+	}
+	return 0
+}
+
+// readPrompt takes the prompt from the arguments, the -file flag or stdin, in that order.
+func readPrompt(args []string, file string) (string, error) {
+	switch {
+	case len(args) > 0 && file != "":
+		return "", errors.New("give the prompt as arguments or with -file, not both")
+	case len(args) > 0:
+		return strings.Join(args, " "), nil
+	case file != "" && file != "-":
+		b, err := os.ReadFile(file)
+		return string(b), err
+	}
+	if stat, err := os.Stdin.Stat(); err == nil && stat.Mode()&os.ModeCharDevice != 0 && file == "" {
+		return "", nil // nothing piped in
+	}
+	b, err := io.ReadAll(os.Stdin)
+	return string(b), err
+}
+
+// generateExitCode maps a failure to the exit code of its class.
+func generateExitCode(err error) int {
+	var remote *domain.RemoteError
+	switch {
+	case errors.As(err, &remote):
+		switch code := remote.StatusCode; {
+		case code == http.StatusUnauthorized, code == http.StatusForbidden:
+			return exitAuth
+		case code == http.StatusTooManyRequests:
+			return exitLimited
+		case code == http.StatusServiceUnavailable, code == http.StatusBadGateway, code == http.StatusGatewayTimeout:
+			return exitUnavailable
+		case code >= 400 && code < 500:
+			return exitRefused
+		}
+		return exitFailed
+	case errors.Is(err, domain.ErrUpstreamUnavailable), errors.Is(err, domain.ErrUpstreamTimeout),
+		errors.Is(err, context.DeadlineExceeded):
+		return exitUnavailable
+	}
+	return exitFailed
+}
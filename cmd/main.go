@@ -2,8 +2,12 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"minivault/config"
+	"minivault/domain"
+	"minivault/infrastructure"
 	"minivault/server"
+	"minivault/usecases"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,10 +15,89 @@ import (
 	"github.com/joho/godotenv"
 )
 
+const usage = `usage: minivault [command]
+
+commands:
+  serve                      run the API server (default)
+  vault decrypt|rekey        read, export or re-encrypt interaction logs
+  replay -model <name>       re-run logged prompts against another model
+  eval -suite <file>         run a golden-set evaluation suite
+  generate [prompt]          send a prompt to a MiniVault server and stream the answer
+  chat                       interactive conversation with history and streamed replies
+`
+
 func main() {
 	_ = godotenv.Load() // Load .env file if present, ignore error if missing
 	cfg := config.Load()
+
+	args := os.Args[1:]
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		os.Exit(runServe(cfg))
+	case "vault":
+		os.Exit(runVault(cfg, args))
+	case "replay":
+		os.Exit(runReplay(cfg, args))
+	case "eval":
+		os.Exit(runEval(cfg, args))
+	case "generate":
+		os.Exit(runGenerate(cfg, args))
+	case "chat":
+		os.Exit(runChat(cfg, args))
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
+	}
+}
+
+// runServe runs the API server until SIGINT, SIGTERM or an admin drain. It exits
+// 1 if the server failed or in-flight work outlived the shutdown grace period.
+func runServe(cfg *config.Config) int {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
-	server.Run(ctx, cfg)
+	if err := server.Run(ctx, cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// buildGenerator wires the generation use case the same way the server does, for
+// commands that generate in-process. The caller must close the returned logger.
+func buildGenerator(cfg *config.Config) (domain.GeneratorPort, domain.LoggerPort, error) {
+	redactor, err := infrastructure.NewRedactor(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	vault, err := infrastructure.NewVault(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	tools, err := infrastructure.NewToolbox(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	guard, err := infrastructure.NewGuardrails(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter, err := infrastructure.NewOutputFilters(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger := infrastructure.NewLogger(cfg, redactor, vault, nil)
+	backend, err := server.NewBackend(cfg, logger)
+	if err != nil {
+		logger.Close()
+		return nil, nil, err
+	}
+	kb := usecases.NewKnowledgeBase(backend.Embeddings, infrastructure.NewVectorStore(cfg), nil, logger, cfg)
+	return usecases.NewGenerator(backend.Ollama, logger, tools, kb, guard, filter, nil, cfg.StructuredOutputRetries, cfg.ToolsMaxRounds), logger, nil
 }
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/infrastructure"
+	"minivault/usecases"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const replayUsage = `usage: minivault replay -model <name> [flags]
+
+Re-runs prompts from the interaction log against another model and reports how
+the answers changed: a JSONL file pairing old and new responses with similarity
+scores and length/latency deltas, plus a human-readable summary.
+`
+
+// runReplay implements the "replay" subcommand and returns the process exit code.
+func runReplay(cfg *config.Config, args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	model := fs.String("model", "", "model to replay prompts against (required)")
+	ollamaURL := fs.String("ollama-url", cfg.OllamaURL, "Ollama chat endpoint to replay against")
+	logDir := fs.String("log-dir", cfg.LogDir, "directory containing the interaction log")
+	since := fs.String("since", "", "only interactions at or after this RFC 3339 time")
+	until := fs.String("until", "", "only interactions before this RFC 3339 time")
+	fromModel := fs.String("from-model", "", "only interactions originally answered by this model")
+	apiKeyID := fs.String("api-key-id", "", "only interactions made with this API key ID")
+	search := fs.String("q", "", "only interactions whose prompt or response contains this text")
+	sample := fs.Float64("sample", 1, "fraction of matching interactions to replay (0-1]")
+	maxN := fs.Int("max", 0, "replay at most this many interactions, chosen at random (0 = all)")
+	seed := fs.Uint64("seed", 1, "random seed for sampling")
+	concurrency := fs.Int("concurrency", 2, "maximum concurrent requests")
+	out := fs.String("out", "replay.jsonl", "JSONL report path")
+	summaryPath := fs.String("summary", "", "write the summary here instead of stdout")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, replayUsage); fs.PrintDefaults() }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *model == "" || *sample <= 0 || *sample > 1 {
+		fs.Usage()
+		return 2
+	}
+
+	q := domain.InteractionQuery{Kind: domain.InteractionKindGenerate, Model: *fromModel, APIKeyID: *apiKeyID, Search: *search, Order: domain.OrderAsc}
+	for _, f := range []struct {
+		raw string
+		dst *time.Time
+	}{{*since, &q.Since}, {*until, &q.Until}} {
+		if f.raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, f.raw)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "replay: invalid time %q: %v\n", f.raw, err)
+			return 2
+		}
+		*f.dst = t
+	}
+
+	vault, err := infrastructure.NewVault(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 1
+	}
+	storeCfg := *cfg
+	storeCfg.LogDir = *logDir
+	interactions, err := loadInteractions(infrastructure.NewInteractionStore(&storeCfg, vault), q)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 1
+	}
+	interactions = sampleInteractions(interactions, *sample, *maxN, *seed)
+	if len(interactions) == 0 {
+		fmt.Fprintln(os.Stderr, "replay: no interactions match")
+		return 1
+	}
+
+	report, err := os.Create(*out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+		return 1
+	}
+	defer report.Close()
+	enc := json.NewEncoder(report)
+	enc.SetEscapeHTML(false)
+
+	ollamaCfg := *cfg
+	ollamaCfg.OllamaURL = *ollamaURL
+	replayer := usecases.NewReplayer(infrastructure.NewOllamaClient(&ollamaCfg), *model, *concurrency)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	fmt.Fprintf(os.Stderr, "replaying %d interaction(s) against %s\n", len(interactions), *model)
+	n := 0
+	summary := replayer.Run(ctx, interactions, func(res domain.ReplayResult) {
+		n++
+		if err := enc.Encode(res); err != nil {
+			fmt.Fprintf(os.Stderr, "replay: failed to write report: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "\r%d/%d", n, len(interactions))
+	})
+	fmt.Fprintln(os.Stderr)
+
+	var w io.Writer = os.Stdout
+	if *summaryPath != "" {
+		f, err := os.Create(*summaryPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "replay: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	writeReplaySummary(w, *model, *out, summary)
+	return 0
+}
+
+// loadInteractions pages through the store collecting every match.
+func loadInteractions(store domain.InteractionStorePort, q domain.InteractionQuery) ([]domain.Interaction, error) {
+	var all []domain.Interaction
+	q.Limit = 500
+	for {
+		page, err := store.Query(q)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page.Interactions...)
+		if page.NextCursor == "" {
+			return all, nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// sampleInteractions keeps each interaction with probability fraction, then caps
+// the result at maxN random picks, preserving log order.
+func sampleInteractions(in []domain.Interaction, fraction float64, maxN int, seed uint64) []domain.Interaction {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	var idx []int
+	for i := range in {
+		if fraction >= 1 || rng.Float64() < fraction {
+			idx = append(idx, i)
+		}
+	}
+	if maxN > 0 && len(idx) > maxN {
+		rng.Shuffle(len(idx), func(i, j int) { idx[i], idx[j] = idx[j], idx[i] })
+		idx = idx[:maxN]
+	}
+	keep := make([]bool, len(in))
+	for _, i := range idx {
+		keep[i] = true
+	}
+	out := make([]domain.Interaction, 0, len(idx))
+	for i, ok := range keep {
+		if ok {
+			out = append(out, in[i])
+		}
+	}
+	return out
+}
+
+func writeReplaySummary(w io.Writer, model, reportPath string, s domain.ReplaySummary) {
+	fmt.Fprintf(w, "Replay against %s\n", model)
+	fmt.Fprintf(w, "  replayed:           %d (%d failed)\n", s.Total, s.Failed)
+	fmt.Fprintf(w, "  similarity:         mean %.3f, median %.3f\n", s.MeanSimilarity, s.MedianSimilarity)
+	fmt.Fprintf(w, "  length delta:       %+.1f chars on average\n", s.MeanLengthDelta)
+	fmt.Fprintf(w, "  latency delta:      %+.0f ms on average\n", s.MeanLatencyDeltaMS)
+	fmt.Fprintf(w, "  report:             %s\n", reportPath)
+	if len(s.LeastSimilar) > 0 {
+		fmt.Fprintf(w, "\nLeast similar answers:\n")
+		for _, r := range s.LeastSimilar {
+			fmt.Fprintf(w, "  %.3f  %s  %s\n", r.Similarity, r.InteractionID, truncate(r.Prompt, 60))
+		}
+	}
+}
+
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
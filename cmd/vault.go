@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/infrastructure"
+	"os"
+)
+
+const vaultUsage = `usage: minivault vault <decrypt|rekey> [flags] [log files...]
+
+  decrypt   print log records with prompt/response decrypted (as JSONL)
+  rekey     re-encrypt log files in place with the active key (VAULT_KEY_ID),
+            encrypting any plaintext records as well
+
+With no files, every segment in MINIVAULT_LOG_DIR and in the log directory of
+each tenant in TENANTS_FILE is processed. Stop the server
+(or only pass rotated segments) before running rekey.
+`
+
+// runVault implements the "vault" subcommand and returns the process exit code.
+func runVault(cfg *config.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, vaultUsage)
+		return 2
+	}
+	action, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("vault "+action, flag.ContinueOnError)
+	out := fs.String("out", "", "write decrypted records to this file instead of stdout (decrypt only)")
+	fs.Usage = func() { fmt.Fprint(os.Stderr, vaultUsage); fs.PrintDefaults() }
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	// The commands need the keyring even if the server runs without encryption.
+	cfg.VaultEncrypt = true
+	v, err := infrastructure.NewVault(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vault: %v\n", err)
+		return 1
+	}
+
+	files := fs.Args()
+	if len(files) == 0 {
+		files = infrastructure.LogFiles(cfg.LogDir)
+		tenants, err := infrastructure.NewTenants(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "vault: %v\n", err)
+			return 1
+		}
+		if tenants != nil {
+			for _, t := range tenants.List() {
+				files = append(files, infrastructure.LogFiles(t.LogDir)...)
+			}
+		}
+	}
+
+	switch action {
+	case "decrypt":
+		err = vaultDecrypt(v, files, *out)
+	case "rekey":
+		err = vaultRekey(v, files)
+	default:
+		fmt.Fprintf(os.Stderr, "vault: unknown action %q\n\n%s", action, vaultUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "vault %s: %v\n", action, err)
+		return 1
+	}
+	return 0
+}
+
+func vaultDecrypt(v domain.VaultPort, files []string, out string) error {
+	var w io.Writer = os.Stdout
+	if out != "" {
+		f, err := os.OpenFile(out, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, path := range files {
+		err := infrastructure.ReadLogRecords(path, func(rec map[string]any) error {
+			if err := infrastructure.DecryptRecord(v, rec); err != nil {
+				return err
+			}
+			return enc.Encode(rec)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func vaultRekey(v domain.VaultPort, files []string) error {
+	for _, path := range files {
+		count := 0
+		err := infrastructure.RewriteLogFile(path, func(rec map[string]any) error {
+			if _, ok := rec["prompt"]; !ok {
+				return nil
+			}
+			count++
+			return infrastructure.EncryptRecord(v, rec)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "%s: %d record(s) sealed with key %q\n", path, count, v.ActiveKeyID())
+	}
+	return nil
+}
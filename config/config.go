@@ -1,27 +1,353 @@
 package config
 
 import (
+	"net/url"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type Config struct {
-	ServerPort  string
-	OllamaURL   string
-	OllamaModel string
+	ServerPort    string
+	OllamaURL     string
+	OllamaAPIURL  string // base of Ollama's REST API, for model management
+	OllamaModel   string
+	OllamaTimeout time.Duration
+	MaxBodyBytes  int64
+	// GenerateMaxBodyBytes is the /generate body limit, larger than MaxBodyBytes
+	// since a request carries its conversation and tool definitions
+	GenerateMaxBodyBytes int64
+
+	// Shutdown: how long readiness fails before the listener closes, then how long
+	// in-flight generations and callback jobs get to finish
+	DrainDelay          time.Duration
+	ShutdownGracePeriod time.Duration
+
+	// Interval of the keepalive pings on /ws/chat; clients silent for two are dropped
+	WSPingInterval time.Duration
+	// Origins besides the server's own that browsers may open /ws/chat from
+	WSAllowedOrigins []string
+
+	// Several Ollama hosts as "http://host:11434[;weight=N]"; replaces OLLAMA_URL and
+	// OLLAMA_EMBED_URL for generation and embeddings when set
+	OllamaUpstreams        []string
+	UpstreamHealthInterval time.Duration
+	UpstreamMaxFails       int           // consecutive failures before an upstream is ejected
+	UpstreamEjectTime      time.Duration // how long an ejected upstream gets no traffic
+
+	// Models tried in order when the primary model fails, as
+	// "model[;timeout=30s][;url=http://host:11434/api/chat]"
+	FallbackChain []string
+	FallbackOn    []string // failure classes that move on to the next model
+
+	// Model residency
+	OllamaKeepAlive  string        // keep_alive sent with every Ollama request; empty uses Ollama's default
+	WarmupModels     []string      // loaded at startup before the server reports ready
+	WarmupTimeout    time.Duration // per model
+	KeepWarmModels   []string      // pinged periodically so Ollama keeps them loaded
+	KeepWarmInterval time.Duration
+	KeepWarmHours    string // daily "HH:MM-HH:MM" window for pings; empty means always
+
+	// Embeddings
+	OllamaEmbedURL     string
+	EmbedModel         string
+	EmbedMaxInputs     int // texts per request
+	EmbedMaxInputChars int // characters per text
+	EmbedBatchSize     int // texts per Ollama call
+
+	// Retrieval-augmented generation
+	RAGDir              string // vector index files, one per collection
+	RAGChunkSize        int    // bytes per chunk
+	RAGChunkOverlap     int    // bytes shared by consecutive chunks
+	RAGTopK             int    // chunks retrieved when a request does not say
+	RAGMaxDocumentBytes int64
+
+	// Input guardrails, as "policy:action" in evaluation order
+	Guardrails             []string
+	GuardrailMaxChars      int
+	GuardrailBlocklistFile string
+	GuardrailLanguages     []string // ISO 639-1 codes
+
+	// Output filters, in the order they run over a response
+	OutputFilters  []string
+	OutputMaxChars int
+	OutputDenyFile string
+
+	// Re-prompts allowed when output does not satisfy a requested JSON format
+	StructuredOutputRetries int
+
+	// Server-side tools
+	Tools          []string      // enabled registry tools, e.g. calculator,clock,read_file
+	ToolsFileRoot  string        // directory read_file may read from
+	ToolsMaxRounds int           // tool-call rounds before giving up on a request
+	ToolsTimeout   time.Duration // per tool call
+
+	// API keys as key ID -> secret; empty disables authentication
+	APIKeys map[string]string
+	// IDs of the API keys allowed to use the admin endpoints
+	AdminKeyIDs []string
+
+	// Per-key quotas as "keyID:quota=N;quota=N", with "*" for every key
+	QuotaLimits    []string
+	QuotaStatePath string // where usage counters are persisted
+
+	// JSON file defining the tenants and the API keys bound to each; empty disables tenancy
+	TenantsFile string
+
+	// Interaction log location, rotation and retention
+	LogConsole        string // stdout, stderr or none
+	LogDir            string
+	LogMaxSizeMB      int64
+	LogRotateInterval time.Duration
+	LogCompress       bool
+	LogMaxAge         time.Duration
+	LogMaxBackups     int
+
+	// PII and secret redaction applied before anything is logged
+	RedactEnabled   bool
+	RedactRulesFile string
+
+	// Encryption at rest for interaction prompts and responses
+	VaultEncrypt bool
+	VaultKey     string
+	VaultKeyFile string
+	VaultKeyID   string
+
+	// Webhook callbacks for completed generations
+	CallbackAllowedHosts   []string
+	CallbackSecret         string
+	CallbackMaxAttempts    int
+	CallbackBackoff        time.Duration
+	CallbackTimeout        time.Duration
+	CallbackDeadLetterPath string
+
+	// Command-line clients: the server they talk to and the API key they send
+	ClientURL    string
+	ClientAPIKey string
 }
 
 func Load() *Config {
+	port := getEnv("MINIVAULT_PORT", ":8080")
+	logDir := getEnv("MINIVAULT_LOG_DIR", "logs")
+	ollamaURL := getEnv("OLLAMA_URL", "http://localhost:11434/api/chat")
 	cfg := &Config{
-		ServerPort:  getEnv("MINIVAULT_PORT", ":8080"),
-		OllamaURL:   getEnv("OLLAMA_URL", "http://localhost:11434/api/chat"),
-		OllamaModel: getEnv("OLLAMA_MODEL", "gemma:2b"),
+		ServerPort:    port,
+		OllamaURL:     ollamaURL,
+		OllamaAPIURL:  getEnv("OLLAMA_API_URL", strings.TrimSuffix(ollamaURL, "/chat")),
+		OllamaModel:   getEnv("OLLAMA_MODEL", "gemma:2b"),
+		OllamaTimeout: getEnvDuration("OLLAMA_TIMEOUT", 30*time.Second),
+		MaxBodyBytes:  int64(getEnvInt("MAX_BODY_BYTES", 4096)),
+
+		GenerateMaxBodyBytes: int64(getEnvInt("GENERATE_MAX_BODY_BYTES", 1<<20)),
+
+		DrainDelay:          getEnvDuration("DRAIN_DELAY", 0),
+		ShutdownGracePeriod: getEnvDuration("SHUTDOWN_GRACE_PERIOD", 30*time.Second),
+
+		WSPingInterval:   getEnvDuration("WS_PING_INTERVAL", 30*time.Second),
+		WSAllowedOrigins: getEnvList("WS_ALLOWED_ORIGINS"),
+
+		OllamaUpstreams:        getEnvList("OLLAMA_UPSTREAMS"),
+		UpstreamHealthInterval: getEnvDuration("UPSTREAM_HEALTH_INTERVAL", 10*time.Second),
+		UpstreamMaxFails:       getEnvInt("UPSTREAM_MAX_FAILS", 3),
+		UpstreamEjectTime:      getEnvDuration("UPSTREAM_EJECT_TIME", 30*time.Second),
+
+		FallbackChain: getEnvList("FALLBACK_CHAIN"),
+		FallbackOn:    splitList(getEnv("FALLBACK_ON", "timeout,missing,unavailable")),
+
+		OllamaKeepAlive:  getEnv("OLLAMA_KEEP_ALIVE", ""),
+		WarmupModels:     getEnvList("WARMUP_MODELS"),
+		WarmupTimeout:    getEnvDuration("WARMUP_TIMEOUT", 2*time.Minute),
+		KeepWarmModels:   getEnvList("KEEP_WARM_MODELS"),
+		KeepWarmInterval: getEnvDuration("KEEP_WARM_INTERVAL", 4*time.Minute),
+		KeepWarmHours:    getEnv("KEEP_WARM_HOURS", ""),
+
+		OllamaEmbedURL:     getEnv("OLLAMA_EMBED_URL", strings.TrimSuffix(ollamaURL, "/chat")+"/embed"),
+		EmbedModel:         getEnv("OLLAMA_EMBED_MODEL", "nomic-embed-text"),
+		EmbedMaxInputs:     getEnvInt("EMBED_MAX_INPUTS", 64),
+		EmbedMaxInputChars: getEnvInt("EMBED_MAX_INPUT_CHARS", 8192),
+		EmbedBatchSize:     getEnvInt("EMBED_BATCH_SIZE", 16),
+
+		RAGDir:              getEnv("RAG_DIR", "data/rag"),
+		RAGChunkSize:        getEnvInt("RAG_CHUNK_SIZE", 1000),
+		RAGChunkOverlap:     getEnvInt("RAG_CHUNK_OVERLAP", 200),
+		RAGTopK:             getEnvInt("RAG_TOP_K", 4),
+		RAGMaxDocumentBytes: int64(getEnvInt("RAG_MAX_DOCUMENT_BYTES", 1<<20)),
+
+		Guardrails:             getEnvList("GUARDRAILS"),
+		GuardrailMaxChars:      getEnvInt("GUARDRAIL_MAX_CHARS", 8000),
+		GuardrailBlocklistFile: getEnv("GUARDRAIL_BLOCKLIST_FILE", ""),
+		GuardrailLanguages:     getEnvList("GUARDRAIL_LANGUAGES"),
+
+		OutputFilters:  getEnvList("OUTPUT_FILTERS"),
+		OutputMaxChars: getEnvInt("OUTPUT_MAX_CHARS", 8000),
+		OutputDenyFile: getEnv("OUTPUT_DENY_FILE", ""),
+
+		StructuredOutputRetries: getEnvInt("STRUCTURED_OUTPUT_RETRIES", 2),
+
+		Tools:          getEnvList("TOOLS"),
+		ToolsFileRoot:  getEnv("TOOLS_FILE_ROOT", ""),
+		ToolsMaxRounds: getEnvInt("TOOLS_MAX_ROUNDS", 5),
+		ToolsTimeout:   getEnvDuration("TOOLS_TIMEOUT", 5*time.Second),
+
+		APIKeys:     getEnvPairs("MINIVAULT_API_KEYS", ":"),
+		AdminKeyIDs: getEnvList("MINIVAULT_ADMIN_KEY_IDS"),
+
+		QuotaLimits:    getEnvList("QUOTA_LIMITS"),
+		QuotaStatePath: getEnv("QUOTA_STATE_PATH", "data/quotas.json"),
+
+		TenantsFile: getEnv("TENANTS_FILE", ""),
+
+		LogConsole:        getEnv("LOG_CONSOLE", "stdout"),
+		LogDir:            logDir,
+		LogMaxSizeMB:      int64(getEnvInt("LOG_MAX_SIZE_MB", 100)),
+		LogRotateInterval: getEnvDuration("LOG_ROTATE_INTERVAL", 24*time.Hour),
+		LogCompress:       getEnvBool("LOG_COMPRESS", false),
+		LogMaxAge:         getEnvDuration("LOG_MAX_AGE", 0),
+		LogMaxBackups:     getEnvInt("LOG_MAX_BACKUPS", 0),
+
+		RedactEnabled:   getEnvBool("LOG_REDACT", true),
+		RedactRulesFile: getEnv("REDACT_RULES_FILE", ""),
+
+		VaultEncrypt: getEnvBool("VAULT_ENCRYPT", false),
+		VaultKey:     getEnv("VAULT_KEY", ""),
+		VaultKeyFile: getEnv("VAULT_KEY_FILE", ""),
+		VaultKeyID:   getEnv("VAULT_KEY_ID", ""),
+
+		CallbackAllowedHosts:   getEnvList("CALLBACK_ALLOWED_HOSTS"),
+		CallbackSecret:         getEnv("CALLBACK_SECRET", ""),
+		CallbackMaxAttempts:    getEnvInt("CALLBACK_MAX_ATTEMPTS", 5),
+		CallbackBackoff:        getEnvDuration("CALLBACK_BACKOFF", time.Second),
+		CallbackTimeout:        getEnvDuration("CALLBACK_TIMEOUT", 10*time.Second),
+		CallbackDeadLetterPath: getEnv("CALLBACK_DEAD_LETTER_PATH", filepath.Join(logDir, "callbacks_dead.jsonl")),
+
+		ClientURL:    getEnv("MINIVAULT_URL", localURL(port)),
+		ClientAPIKey: getEnv("MINIVAULT_API_KEY", ""),
 	}
 	return cfg
 }
 
+// redactedValue replaces secrets in Redacted.
+const redactedValue = "[REDACTED]"
+
+// secretFields are the settings Redacted never shows.
+var secretFields = map[string]bool{"VaultKey": true, "CallbackSecret": true, "ClientAPIKey": true}
+
+// Redacted returns the effective settings for display, keyed by field name. Secrets
+// and API keys are replaced by "[REDACTED]", passwords are removed from URLs and
+// durations are written as strings such as "30s".
+func (c *Config) Redacted() map[string]any {
+	out := make(map[string]any)
+	v := reflect.ValueOf(*c)
+	for i := range v.NumField() {
+		name := v.Type().Field(i).Name
+		switch value := v.Field(i).Interface().(type) {
+		case string:
+			if secretFields[name] && value != "" {
+				out[name] = redactedValue
+			} else {
+				out[name] = RedactURL(value)
+			}
+		case []string:
+			items := make([]string, len(value))
+			for j, item := range value {
+				items[j] = RedactURL(item)
+			}
+			out[name] = items
+		case map[string]string:
+			keys := make(map[string]string, len(value))
+			for k := range value {
+				keys[k] = redactedValue
+			}
+			out[name] = keys
+		case time.Duration:
+			out[name] = value.String()
+		default:
+			out[name] = value
+		}
+	}
+	return out
+}
+
+// RedactURL hides the password of a URL with credentials; other strings are returned as is.
+func RedactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.User == nil {
+		return s
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
+
+// localURL is the address of a server listening on port, as seen from this host.
+func localURL(port string) string {
+	if strings.HasPrefix(port, ":") {
+		return "http://localhost" + port
+	}
+	return "http://" + port
+}
+
 func getEnv(key, fallback string) string {
 	if v := os.Getenv(key); v != "" {
 		return v
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if v := os.Getenv(key); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return d
+		}
+	}
+	return fallback
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
+// getEnvList splits a comma-separated variable into trimmed, non-empty items.
+func getEnvList(key string) []string {
+	return splitList(os.Getenv(key))
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// getEnvPairs parses a comma-separated list of "name<sep>value" items into a map.
+// Items without a separator are ignored.
+func getEnvPairs(key, sep string) map[string]string {
+	out := make(map[string]string)
+	for _, item := range getEnvList(key) {
+		if name, value, ok := strings.Cut(item, sep); ok && strings.TrimSpace(name) != "" {
+			out[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,70 @@
+package domain
+
+import "context"
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	callerKey
+	tokenSinkKey
+	allowedModelsKey
+)
+
+// Caller identifies the API key a request was authenticated with.
+type Caller struct {
+	KeyID  string
+	Admin  bool   // the key is listed in MINIVAULT_ADMIN_KEY_IDS
+	Tenant string // ID of the tenant the key is bound to; empty outside any tenant
+}
+
+// WithRequestID returns a context carrying the request ID used for tracing.
+func WithRequestID(ctx context.Context, reqID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, reqID)
+}
+
+// RequestIDFromContext returns the request ID, or "" if none was set.
+func RequestIDFromContext(ctx context.Context) string {
+	reqID, _ := ctx.Value(requestIDKey).(string)
+	return reqID
+}
+
+// WithCaller returns a context carrying the authenticated caller.
+func WithCaller(ctx context.Context, caller Caller) context.Context {
+	return context.WithValue(ctx, callerKey, caller)
+}
+
+// CallerFromContext returns the authenticated caller; ok is false for anonymous requests.
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	caller, ok := ctx.Value(callerKey).(Caller)
+	return caller, ok
+}
+
+// TokenSink receives the text of a reply as the model produces it.
+type TokenSink func(token string)
+
+// WithTokenSink returns a context asking for generated text to be streamed to sink
+// as it arrives. A nil sink turns streaming off.
+func WithTokenSink(ctx context.Context, sink TokenSink) context.Context {
+	return context.WithValue(ctx, tokenSinkKey, sink)
+}
+
+// TokenSinkFromContext returns the sink for streamed text, or nil if the caller
+// does not stream.
+func TokenSinkFromContext(ctx context.Context) TokenSink {
+	sink, _ := ctx.Value(tokenSinkKey).(TokenSink)
+	return sink
+}
+
+// WithAllowedModels returns a context limiting a generation that names no model to
+// the given models, for the fallback chain to pick from.
+func WithAllowedModels(ctx context.Context, models []string) context.Context {
+	return context.WithValue(ctx, allowedModelsKey, models)
+}
+
+// AllowedModelsFromContext returns the models a generation is limited to, or nil
+// if any model may be used.
+func AllowedModelsFromContext(ctx context.Context) []string {
+	models, _ := ctx.Value(allowedModelsKey).([]string)
+	return models
+}
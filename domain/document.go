@@ -0,0 +1,112 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Document content types accepted for ingestion.
+const (
+	ContentTypeText     = "text/plain"
+	ContentTypeMarkdown = "text/markdown"
+)
+
+// MaxRetrievalTopK caps how many chunks one request may retrieve.
+const MaxRetrievalTopK = 20
+
+var collectionNameRE = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// DocumentRequest is a document to ingest into a collection. Ingesting an existing
+// ID replaces that document's chunks.
+type DocumentRequest struct {
+	Collection  string `json:"collection"`
+	ID          string `json:"id,omitempty"` // generated when empty
+	Title       string `json:"title,omitempty"`
+	ContentType string `json:"content_type,omitempty"` // ContentTypeText (default) or ContentTypeMarkdown
+	Content     string `json:"content"`
+}
+
+// Document describes an ingested document.
+type Document struct {
+	ID         string    `json:"id"`
+	Collection string    `json:"collection"`
+	Title      string    `json:"title,omitempty"`
+	Chunks     int       `json:"chunks"`
+	Model      string    `json:"model"`
+	IngestedAt time.Time `json:"ingested_at"`
+}
+
+// TextSpan is a byte range [Start, End) of a document's content.
+type TextSpan struct {
+	Start int
+	End   int
+}
+
+// Chunk is an embedded piece of a document as kept in the vector index.
+type Chunk struct {
+	DocumentID string    `json:"document_id"`
+	Title      string    `json:"title,omitempty"`
+	Index      int       `json:"index"`
+	Start      int       `json:"start"`
+	End        int       `json:"end"`
+	Text       string    `json:"text"`
+	Embedding  []float64 `json:"embedding"`
+}
+
+// ScoredChunk is a search hit with its cosine similarity to the query.
+type ScoredChunk struct {
+	Chunk
+	Score float64
+}
+
+// RetrievalOptions asks /generate to ground its answer in a document collection.
+type RetrievalOptions struct {
+	Collection string `json:"collection"`
+	TopK       int    `json:"top_k,omitempty"` // defaults to RAG_TOP_K
+}
+
+// Citation points at a retrieved chunk that was given to the model.
+type Citation struct {
+	DocumentID string  `json:"document_id"`
+	Title      string  `json:"title,omitempty"`
+	Chunk      int     `json:"chunk"`
+	Start      int     `json:"start"`
+	End        int     `json:"end"`
+	Score      float64 `json:"score"`
+}
+
+// ValidCollectionName reports whether name can be used as a collection name.
+func ValidCollectionName(name string) bool {
+	return collectionNameRE.MatchString(name)
+}
+
+// Validate checks if the request is valid according to business rules.
+func (r *DocumentRequest) Validate() error {
+	if !ValidCollectionName(r.Collection) {
+		return ErrInvalidCollection
+	}
+	if len(r.ID) > 128 || strings.ContainsAny(r.ID, "\x00\n") {
+		return ErrInvalidDocumentID
+	}
+	switch r.ContentType {
+	case "", ContentTypeText, ContentTypeMarkdown:
+	default:
+		return ErrUnsupportedContentType
+	}
+	if strings.TrimSpace(r.Content) == "" {
+		return ErrEmptyDocument
+	}
+	return nil
+}
+
+// Validate checks if the retrieval options are valid according to business rules.
+func (o *RetrievalOptions) Validate() error {
+	if !ValidCollectionName(o.Collection) {
+		return ErrInvalidCollection
+	}
+	if o.TopK < 0 || o.TopK > MaxRetrievalTopK {
+		return ErrInvalidTopK
+	}
+	return nil
+}
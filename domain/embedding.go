@@ -0,0 +1,53 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// EmbeddingInput is one or more texts to embed. It decodes from either a JSON
+// string or an array of strings.
+type EmbeddingInput []string
+
+func (in *EmbeddingInput) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*in = EmbeddingInput{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return ErrInvalidEmbeddingInput
+	}
+	*in = many
+	return nil
+}
+
+// EmbeddingRequest represents an embeddings request.
+type EmbeddingRequest struct {
+	Input EmbeddingInput `json:"input"`
+	Model string         `json:"model,omitempty"` // defaults to the configured embedding model
+}
+
+// EmbeddingResponse holds one vector per input, in input order.
+type EmbeddingResponse struct {
+	Model      string      `json:"model"`
+	Dimensions int         `json:"dimensions"`
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// Validate checks if the request is valid according to business rules.
+func (r *EmbeddingRequest) Validate() error {
+	if len(r.Input) == 0 {
+		return ErrEmptyEmbeddingInput
+	}
+	if r.Model != "" && !ValidModelName(r.Model) {
+		return ErrInvalidModelName
+	}
+	for _, text := range r.Input {
+		if strings.TrimSpace(text) == "" {
+			return ErrEmptyEmbeddingInput
+		}
+	}
+	return nil
+}
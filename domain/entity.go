@@ -1,21 +1,202 @@
 package domain
 
-import "strings"
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
 
 // GenerateRequest represents a prompt generation request.
 type GenerateRequest struct {
-	Prompt string `json:"prompt"`
+	Prompt      string              `json:"prompt"`
+	Model       string              `json:"model,omitempty"`    // empty uses the configured model and fallback chain
+	Messages    []OllamaChatMessage `json:"messages,omitempty"` // earlier turns, sent before prompt
+	Options     map[string]any      `json:"options,omitempty"`
+	Format      json.RawMessage     `json:"format,omitempty"`       // "json" or a JSON Schema object
+	Tools       []Tool              `json:"tools,omitempty"`        // client-side tools, returned as tool_calls
+	ServerTools []string            `json:"server_tools,omitempty"` // registry tools MiniVault runs itself
+	Retrieval   *RetrievalOptions   `json:"retrieval,omitempty"`    // ground the answer in a document collection
+	CallbackURL string              `json:"callback_url,omitempty"`
+	Stream      bool                `json:"stream,omitempty"` // answer with NDJSON stream events
 }
 
 // GenerateResponse represents a prompt generation response.
 type GenerateResponse struct {
-	Response string `json:"response"`
+	Response  string           `json:"response"`
+	Model     string           `json:"model,omitempty"`      // the model that answered, after any fallback
+	JSON      json.RawMessage  `json:"json,omitempty"`       // parsed output when a format was requested
+	ToolCalls []ToolCall       `json:"tool_calls,omitempty"` // client-side tool calls awaiting results
+	ToolsUsed []ToolInvocation `json:"tools_used,omitempty"` // server-side tool calls made on the way
+	Citations []Citation       `json:"citations,omitempty"`  // retrieved chunks given to the model
+	Filters   []FilterResult   `json:"filters,omitempty"`    // output filters that changed the response
+	Usage     *Usage           `json:"usage,omitempty"`      // token counts and timings reported by Ollama
+}
+
+// Stream event types.
+const (
+	StreamEventToken = "token"
+	StreamEventDone  = "done"
+	StreamEventError = "error"
+)
+
+// GenerateStreamEvent is one NDJSON line of a streamed /generate response: tokens
+// as they are generated, then "done" with the whole response or "error".
+type GenerateStreamEvent struct {
+	Type      string            `json:"type"`
+	Token     string            `json:"token,omitempty"`
+	Response  *GenerateResponse `json:"response,omitempty"`   // with "done"
+	Error     string            `json:"error,omitempty"`      // with "error"
+	Status    int               `json:"status,omitempty"`     // with "error", the HTTP status the failure maps to
+	RequestID string            `json:"request_id,omitempty"` // with "done" and "error"
+}
+
+// Chat frame types. Clients send "user", "cancel", "reset", "system" and "ping";
+// the server answers with the rest.
+const (
+	ChatFrameUser      = "user"
+	ChatFrameCancel    = "cancel"
+	ChatFrameReset     = "reset"
+	ChatFrameSystem    = "system"
+	ChatFramePing      = "ping"
+	ChatFrameReady     = "ready"
+	ChatFrameStart     = "start"
+	ChatFrameToken     = "token"
+	ChatFrameUsage     = "usage"
+	ChatFrameDone      = "done"
+	ChatFrameCancelled = "cancelled"
+	ChatFrameError     = "error"
+	ChatFramePong      = "pong"
+)
+
+// ChatFrame is one JSON message on the /ws/chat WebSocket, in either direction.
+type ChatFrame struct {
+	Type       string `json:"type"`
+	ID         string `json:"id,omitempty"`          // with "ready", the connection ID
+	RequestID  string `json:"request_id,omitempty"`  // of the turn the frame belongs to
+	Content    string `json:"content,omitempty"`     // the prompt, system prompt or whole reply
+	Model      string `json:"model,omitempty"`       // with "user", switches the conversation's model
+	Token      string `json:"token,omitempty"`       // with "token"
+	Usage      *Usage `json:"usage,omitempty"`       // with "usage"
+	Turns      int    `json:"turns,omitempty"`       // with "done", the messages in the conversation
+	Error      string `json:"error,omitempty"`       // with "error"
+	Status     int    `json:"status,omitempty"`      // with "error", the HTTP status the failure maps to
+	RetryAfter int    `json:"retry_after,omitempty"` // with "error", seconds until a rate limit allows another turn
+}
+
+// GenerateAcceptedResponse is returned when a generation will be delivered via callback.
+type GenerateAcceptedResponse struct {
+	RequestID string `json:"request_id"`
+	Status    string `json:"status"`
+}
+
+// Callback delivery statuses.
+const (
+	CallbackStatusCompleted = "completed"
+	CallbackStatusFailed    = "failed"
+)
+
+// CallbackPayload is the JSON body POSTed to a request's callback_url.
+type CallbackPayload struct {
+	RequestID   string           `json:"request_id"`
+	Status      string           `json:"status"`
+	Response    string           `json:"response,omitempty"`
+	Model       string           `json:"model,omitempty"`
+	JSON        json.RawMessage  `json:"json,omitempty"`
+	ToolCalls   []ToolCall       `json:"tool_calls,omitempty"`
+	ToolsUsed   []ToolInvocation `json:"tools_used,omitempty"`
+	Citations   []Citation       `json:"citations,omitempty"`
+	Usage       *Usage           `json:"usage,omitempty"`
+	Error       string           `json:"error,omitempty"`
+	CompletedAt time.Time        `json:"completed_at"`
 }
 
 // Validate checks if the request is valid according to business rules.
 func (r *GenerateRequest) Validate() error {
-	if len(strings.TrimSpace(r.Prompt)) == 0 {
+	if len(strings.TrimSpace(r.Prompt)) == 0 && len(r.Messages) == 0 {
 		return ErrEmptyPrompt
 	}
+	if r.Model != "" && !ValidModelName(r.Model) {
+		return ErrInvalidModelName
+	}
+	for _, m := range r.Messages {
+		switch m.Role {
+		case "system", "user", "assistant", "tool":
+		default:
+			return ErrInvalidMessageRole
+		}
+	}
+	for _, t := range r.Tools {
+		if t.Type != ToolTypeFunction || t.Function.Name == "" {
+			return ErrInvalidTool
+		}
+	}
+	if r.Retrieval != nil {
+		if err := r.Retrieval.Validate(); err != nil {
+			return err
+		}
+	}
+	if len(r.Format) > 0 && !r.WantsJSON() {
+		schema := r.Schema()
+		if schema == nil {
+			return ErrInvalidFormat
+		}
+		if err := CheckJSONSchema(schema); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+		}
+	}
+	if r.Stream && r.CallbackURL != "" {
+		return ErrStreamWithCallback
+	}
 	return nil
 }
+
+// ChatMessages returns the conversation to send to the model: the earlier turns
+// followed by the prompt as a user message, if there is one.
+func (r *GenerateRequest) ChatMessages() []OllamaChatMessage {
+	messages := append([]OllamaChatMessage(nil), r.Messages...)
+	if strings.TrimSpace(r.Prompt) != "" {
+		messages = append(messages, OllamaChatMessage{Role: "user", Content: r.Prompt})
+	}
+	return messages
+}
+
+// LastUserInput is what gets logged as the interaction's prompt: the prompt, or the
+// content of the last message when the request only continues a conversation.
+func (r *GenerateRequest) LastUserInput() string {
+	if strings.TrimSpace(r.Prompt) != "" || len(r.Messages) == 0 {
+		return r.Prompt
+	}
+	return r.Messages[len(r.Messages)-1].Content
+}
+
+// InputText is the client-written text input policies check: the prompt and every
+// message except the model's own assistant turns.
+func (r *GenerateRequest) InputText() string {
+	var parts []string
+	for _, m := range r.Messages {
+		if m.Role != "assistant" {
+			parts = append(parts, m.Content)
+		}
+	}
+	if r.Prompt != "" {
+		parts = append(parts, r.Prompt)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// WantsJSON reports whether the request asked for free-form JSON output (format "json").
+func (r *GenerateRequest) WantsJSON() bool {
+	var s string
+	return json.Unmarshal(r.Format, &s) == nil && s == "json"
+}
+
+// Schema returns the JSON Schema the output must satisfy, or nil if none was given.
+func (r *GenerateRequest) Schema() json.RawMessage {
+	trimmed := bytes.TrimSpace(r.Format)
+	if len(trimmed) == 0 || trimmed[0] != '{' || !json.Valid(trimmed) {
+		return nil
+	}
+	return trimmed
+}
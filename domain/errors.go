@@ -1,5 +1,107 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"strconv"
+)
 
 var ErrEmptyPrompt = errors.New("prompt must not be empty")
+
+var (
+	ErrInvalidMessageRole = errors.New(`message role must be "system", "user", "assistant" or "tool"`)
+	ErrInvalidTool        = errors.New(`tools must have type "function" and a function name`)
+	ErrUnknownTool        = errors.New("unknown or disabled server tool")
+	ErrToolRoundsExceeded = errors.New("model kept calling tools without producing an answer")
+)
+
+var (
+	ErrInvalidFormat           = errors.New(`format must be "json" or a JSON Schema object`)
+	ErrInvalidStructuredOutput = errors.New("model output did not satisfy the requested format")
+)
+
+var (
+	ErrInvalidEmbeddingInput  = errors.New("input must be a string or an array of strings")
+	ErrEmptyEmbeddingInput    = errors.New("input must contain at least one non-empty text")
+	ErrTooManyEmbeddingInputs = errors.New("too many inputs in one request")
+	ErrEmbeddingInputTooLong  = errors.New("input text is too long")
+)
+
+var (
+	ErrInvalidCollection      = errors.New("collection must be 1-64 letters, digits, '-' or '_'")
+	ErrInvalidDocumentID      = errors.New("document id must be at most 128 characters on one line")
+	ErrUnsupportedContentType = errors.New("content type must be text/plain or text/markdown")
+	ErrEmptyDocument          = errors.New("document content must not be empty")
+	ErrInvalidTopK            = errors.New("top_k must be between 1 and 20")
+	ErrCollectionNotFound     = errors.New("collection not found")
+	ErrEmbeddingDimensions    = errors.New("embedding dimensions do not match the collection")
+)
+
+var ErrStreamWithCallback = errors.New("stream cannot be combined with callback_url")
+
+var (
+	ErrCallbacksDisabled      = errors.New("callbacks are not enabled on this server")
+	ErrInvalidCallbackURL     = errors.New("callback_url must be an absolute http or https URL")
+	ErrCallbackHostNotAllowed = errors.New("callback_url host is not in the allowlist")
+	ErrCallbackSecretRequired = errors.New("CALLBACK_SECRET is required when CALLBACK_ALLOWED_HOSTS is set")
+)
+
+var (
+	ErrUnknownVaultKey = errors.New("record is encrypted with an unknown vault key")
+	ErrVaultDecrypt    = errors.New("failed to decrypt vault record")
+)
+
+var (
+	ErrUnauthorized        = errors.New("missing or invalid API key")
+	ErrInteractionNotFound = errors.New("interaction not found")
+	ErrInvalidCursor       = errors.New("invalid pagination cursor")
+	ErrHistoryNeedsAuth    = errors.New("interaction history is only served with API keys configured")
+)
+
+var (
+	ErrForbidden        = errors.New("this endpoint requires an admin API key")
+	ErrInvalidModelName = errors.New("invalid model name")
+	ErrModelNotFound    = errors.New("model not found")
+)
+
+var (
+	ErrUpstreamTimeout     = errors.New("model backend timed out")
+	ErrUpstreamUnavailable = errors.New("model backend is unavailable")
+	ErrUpstreamRejected    = errors.New("model backend rejected the request")
+)
+
+var ErrInputBlocked = errors.New("request blocked by input policy")
+
+var ErrOutputBlocked = errors.New("response blocked by output filter")
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+var (
+	ErrGenerationCancelled = errors.New("generation cancelled by an administrator")
+	ErrServerUnavailable   = errors.New("server is in maintenance mode")
+	ErrServerDraining      = errors.New("server is draining and takes no new generations")
+	ErrDrainTimeout        = errors.New("shutdown grace period expired with work in flight")
+)
+
+var (
+	ErrRateLimited     = errors.New("tenant rate limit exceeded")
+	ErrModelNotAllowed = errors.New("model is not allowed for this tenant")
+)
+
+// WebSocket chat errors
+var (
+	ErrTurnInProgress   = errors.New("a turn is already in progress on this connection")
+	ErrTurnCancelled    = errors.New("turn cancelled by the client")
+	ErrUnknownChatFrame = errors.New("unknown frame type")
+)
+
+var ErrConversationTooLarge = errors.New("the prompt and system prompt alone exceed the request size limit")
+
+// RemoteError is a failure reported by a remote MiniVault server.
+type RemoteError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *RemoteError) Error() string {
+	return "server answered " + strconv.Itoa(e.StatusCode) + ": " + e.Message
+}
@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Eval assertion types.
+const (
+	AssertContains    = "contains"
+	AssertNotContains = "not_contains"
+	AssertRegex       = "regex"
+	AssertValidJSON   = "valid_json"
+	AssertJSONSchema  = "json_schema"
+	AssertMaxLength   = "max_length"
+	AssertMaxLatency  = "max_latency"
+)
+
+// EvalSuite is a golden set of prompts with expectations about their answers.
+type EvalSuite struct {
+	Name  string     `json:"name"`
+	Cases []EvalCase `json:"cases"`
+}
+
+// EvalCase is one prompt and the assertions its response must satisfy.
+type EvalCase struct {
+	Name       string          `json:"name"`
+	Prompt     string          `json:"prompt"`
+	Options    map[string]any  `json:"options,omitempty"`
+	Format     json.RawMessage `json:"format,omitempty"`
+	Assertions []EvalAssertion `json:"assertions"`
+}
+
+// EvalAssertion checks one property of a response. Value is used by contains,
+// not_contains and regex; Schema by json_schema; Max by max_length (characters)
+// and max_latency (milliseconds).
+type EvalAssertion struct {
+	Type   string          `json:"type"`
+	Value  string          `json:"value,omitempty"`
+	Schema json.RawMessage `json:"schema,omitempty"`
+	Max    int64           `json:"max,omitempty"`
+}
+
+// EvalAssertionResult is the outcome of one assertion.
+type EvalAssertionResult struct {
+	Type    string `json:"type"`
+	Passed  bool   `json:"passed"`
+	Message string `json:"message,omitempty"`
+}
+
+// EvalCaseResult is the outcome of one case. A case passes when generation
+// succeeded and every assertion passed.
+type EvalCaseResult struct {
+	Name       string                `json:"name"`
+	Passed     bool                  `json:"passed"`
+	Response   string                `json:"response"`
+	LatencyMS  int64                 `json:"latency_ms"`
+	Error      string                `json:"error,omitempty"`
+	Assertions []EvalAssertionResult `json:"assertions"`
+}
+
+// EvalReport aggregates a suite run. Score is the fraction of cases passed and
+// AssertionScore the fraction of individual assertions passed.
+type EvalReport struct {
+	Suite          string           `json:"suite"`
+	StartedAt      time.Time        `json:"started_at"`
+	DurationMS     int64            `json:"duration_ms"`
+	Total          int              `json:"total"`
+	Passed         int              `json:"passed"`
+	Failed         int              `json:"failed"`
+	Score          float64          `json:"score"`
+	AssertionScore float64          `json:"assertion_score"`
+	Cases          []EvalCaseResult `json:"cases"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// Failure classes a fallback policy can act on (FALLBACK_ON).
+const (
+	FailureTimeout     = "timeout"     // the step ran out of time
+	FailureMissing     = "missing"     // the model is not installed on the backend
+	FailureUnavailable = "unavailable" // the backend could not be reached or answered 5xx
+	FailureRejected    = "rejected"    // the backend refused the request, e.g. unsupported options
+)
+
+// FallbackStep is one model in a fallback chain, tried when the steps before it fail.
+type FallbackStep struct {
+	Model   string // empty uses the backend's default model
+	Ollama  OllamaPort
+	Timeout time.Duration // zero leaves the call bounded by the request context only
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Actions a policy can take when it matches.
+const (
+	PolicyActionBlock = "block" // reject the request with 422
+	PolicyActionWarn  = "warn"  // generate, and log a warning
+	PolicyActionTag   = "tag"   // generate, and only record the match
+)
+
+// PolicyResult is one policy that matched, as returned to clients and recorded in
+// the interaction log.
+type PolicyResult struct {
+	Policy string `json:"policy"`
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PolicyErrorResponse is the 422 body for a request blocked by input policies.
+type PolicyErrorResponse struct {
+	Error     string         `json:"error"`
+	RequestID string         `json:"request_id"`
+	Policies  []PolicyResult `json:"policies"`
+}
+
+// PolicyError reports a request rejected by at least one blocking input policy.
+// Results holds every policy that matched, blocking or not.
+type PolicyError struct {
+	Results []PolicyResult
+}
+
+func (e *PolicyError) Error() string {
+	var blocked []string
+	for _, r := range e.Results {
+		if r.Action == PolicyActionBlock {
+			blocked = append(blocked, r.Policy)
+		}
+	}
+	return fmt.Sprintf("%s: %s", ErrInputBlocked, strings.Join(blocked, ", "))
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrInputBlocked
+}
@@ -0,0 +1,54 @@
+package domain
+
+import "time"
+
+// Interaction is one prompt/response exchange as recorded in the interaction log.
+type Interaction struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"` // InteractionKindGenerate or InteractionKindEmbed
+	RequestID string    `json:"request_id,omitempty"`
+	Time      time.Time `json:"time"`
+	Model     string    `json:"model,omitempty"`
+	APIKeyID  string    `json:"api_key_id,omitempty"`
+	Tenant    string    `json:"tenant,omitempty"`
+	Prompt    string    `json:"prompt"`
+	Response  string    `json:"response"`
+	LatencyMS int64     `json:"latency_ms,omitempty"`
+	Usage     *Usage    `json:"usage,omitempty"`
+	// Policies lists the input policies the prompt matched; a blocked request has no response
+	Policies []PolicyResult `json:"policies,omitempty"`
+	// Filters lists the output filters that acted on the response
+	Filters []FilterResult `json:"filters,omitempty"`
+}
+
+// Interaction kinds. Records written before kinds were introduced are generations.
+const (
+	InteractionKindGenerate = "generate"
+	InteractionKindEmbed    = "embed"
+)
+
+// Sort orders for interaction queries.
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// InteractionQuery filters and paginates interaction history. Zero values mean "no filter".
+type InteractionQuery struct {
+	Since     time.Time
+	Until     time.Time
+	Kind      string
+	Model     string
+	RequestID string
+	APIKeyID  string
+	Search    string // case-insensitive substring of prompt or response
+	Cursor    string // opaque cursor from a previous page
+	Limit     int
+	Order     string // OrderAsc or OrderDesc (default)
+}
+
+// InteractionPage is one page of query results.
+type InteractionPage struct {
+	Interactions []Interaction `json:"interactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+}
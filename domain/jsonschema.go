@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// jsonSchemaTypes are the names the "type" keyword accepts.
+var jsonSchemaTypes = map[string]bool{
+	"null": true, "boolean": true, "object": true, "array": true, "number": true, "integer": true, "string": true,
+}
+
+// CheckJSONSchema compiles a JSON Schema in the subset MiniVault validates against,
+// reporting the first keyword whose value is malformed, such as a "type" that names
+// no JSON type or a "pattern" that is not a regular expression. Unknown keywords
+// are ignored.
+func CheckJSONSchema(schema json.RawMessage) error {
+	var s any
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return checkSchema(s, "$")
+}
+
+func checkSchema(schema any, path string) error {
+	switch s := schema.(type) {
+	case bool:
+		return nil
+	case map[string]any:
+		return checkObjectSchema(s, path)
+	default:
+		return fmt.Errorf("invalid JSON schema at %s: expected object or boolean", path)
+	}
+}
+
+func checkObjectSchema(s map[string]any, path string) error {
+	invalid := func(keyword, want string) error {
+		return fmt.Errorf("invalid JSON schema at %s: %q must be %s", path, keyword, want)
+	}
+
+	if t, ok := s["type"]; ok {
+		names, isList := t.([]any)
+		if !isList {
+			names = []any{t}
+		}
+		if len(names) == 0 {
+			return invalid("type", "a JSON type name or a list of them")
+		}
+		for _, n := range names {
+			if name, ok := n.(string); !ok || !jsonSchemaTypes[name] {
+				return invalid("type", "a JSON type name or a list of them")
+			}
+		}
+	}
+	if enum, ok := s["enum"]; ok {
+		if _, ok := enum.([]any); !ok {
+			return invalid("enum", "an array")
+		}
+	}
+	for _, kw := range []string{"minLength", "maxLength", "minItems", "maxItems"} {
+		if v, ok := s[kw]; ok {
+			if n, ok := v.(float64); !ok || n < 0 {
+				return invalid(kw, "a non-negative number")
+			}
+		}
+	}
+	for _, kw := range []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum"} {
+		if v, ok := s[kw]; ok {
+			if _, ok := v.(float64); !ok {
+				return invalid(kw, "a number")
+			}
+		}
+	}
+	if p, ok := s["pattern"]; ok {
+		pattern, ok := p.(string)
+		if !ok {
+			return invalid("pattern", "a string")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid pattern at %s: %w", path, err)
+		}
+	}
+	if r, ok := s["required"]; ok {
+		required, ok := r.([]any)
+		if !ok {
+			return invalid("required", "an array of property names")
+		}
+		for _, name := range required {
+			if _, ok := name.(string); !ok {
+				return invalid("required", "an array of property names")
+			}
+		}
+	}
+	if p, ok := s["properties"]; ok {
+		props, ok := p.(map[string]any)
+		if !ok {
+			return invalid("properties", "an object of schemas")
+		}
+		for name, sub := range props {
+			if err := checkSchema(sub, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	for _, kw := range []string{"additionalProperties", "items", "not"} {
+		if sub, ok := s[kw]; ok {
+			if err := checkSchema(sub, path+"/"+kw); err != nil {
+				return err
+			}
+		}
+	}
+	for _, kw := range []string{"allOf", "anyOf", "oneOf"} {
+		v, ok := s[kw]
+		if !ok {
+			continue
+		}
+		subs, ok := v.([]any)
+		if !ok || len(subs) == 0 {
+			return invalid(kw, "a non-empty array of schemas")
+		}
+		for i, sub := range subs {
+			if err := checkSchema(sub, fmt.Sprintf("%s/%s[%d]", path, kw, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
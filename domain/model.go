@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ModelDetails describes a model's format, family, size and quantization.
+type ModelDetails struct {
+	Format            string   `json:"format,omitempty"`
+	Family            string   `json:"family,omitempty"`
+	Families          []string `json:"families,omitempty"`
+	ParameterSize     string   `json:"parameter_size,omitempty"`
+	QuantizationLevel string   `json:"quantization_level,omitempty"`
+}
+
+// ModelSummary is an installed model as listed by Ollama's /api/tags.
+type ModelSummary struct {
+	Name       string       `json:"name"`
+	Model      string       `json:"model"`
+	ModifiedAt time.Time    `json:"modified_at"`
+	Size       int64        `json:"size"`
+	Digest     string       `json:"digest"`
+	Details    ModelDetails `json:"details"`
+}
+
+// RunningModel is a model loaded in memory as listed by Ollama's /api/ps.
+type RunningModel struct {
+	Name      string       `json:"name"`
+	Model     string       `json:"model"`
+	Size      int64        `json:"size"`
+	SizeVRAM  int64        `json:"size_vram"`
+	Digest    string       `json:"digest"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	Details   ModelDetails `json:"details"`
+}
+
+// ModelInfo is Ollama's /api/show output, passed through unchanged.
+type ModelInfo = json.RawMessage
+
+// PullProgress is one progress update while a model is pulled.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+}
+
+// PullRequest names a model to pull.
+type PullRequest struct {
+	Model string `json:"model"`
+}
+
+// ValidModelName reports whether name looks like an Ollama model reference,
+// e.g. "gemma:2b", "library/llama3:8b" or "hf.co/org/repo:Q4_K_M".
+func ValidModelName(name string) bool {
+	if name == "" || len(name) > 256 || strings.Contains(name, "..") {
+		return false
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("._-:/", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
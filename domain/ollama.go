@@ -1,22 +1,73 @@
 package domain
 
+import (
+	"encoding/json"
+	"strconv"
+)
+
 // OllamaChatMessage represents a message in Ollama chat format.
+// Assistant messages may carry tool calls; "tool" messages carry a tool's result.
 type OllamaChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role      string     `json:"role"`
+	Content   string     `json:"content"`
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	ToolName  string     `json:"tool_name,omitempty"`
 }
 
 // OllamaChatRequest represents a request to the Ollama chat API.
 type OllamaChatRequest struct {
-	Model    string              `json:"model"`
-	Messages []OllamaChatMessage `json:"messages"`
-	Stream   bool                `json:"stream"`
+	Model     string              `json:"model"`
+	Messages  []OllamaChatMessage `json:"messages"`
+	Stream    bool                `json:"stream"`
+	Options   map[string]any      `json:"options,omitempty"`
+	Format    json.RawMessage     `json:"format,omitempty"`
+	Tools     []Tool              `json:"tools,omitempty"`
+	KeepAlive KeepAlive           `json:"keep_alive,omitempty"`
 }
 
-// OllamaChatResponse represents a response from the Ollama chat API.
+// OllamaChatResponse represents a response from the Ollama chat API. Durations
+// are in nanoseconds.
 type OllamaChatResponse struct {
-	Message struct {
-		Role    string `json:"role"`
-		Content string `json:"content"`
-	} `json:"message"`
+	Model              string            `json:"model"`
+	Message            OllamaChatMessage `json:"message"`
+	DoneReason         string            `json:"done_reason,omitempty"`
+	TotalDuration      int64             `json:"total_duration,omitempty"`
+	LoadDuration       int64             `json:"load_duration,omitempty"`
+	PromptEvalCount    int               `json:"prompt_eval_count,omitempty"`
+	PromptEvalDuration int64             `json:"prompt_eval_duration,omitempty"`
+	EvalCount          int               `json:"eval_count,omitempty"`
+	EvalDuration       int64             `json:"eval_duration,omitempty"`
+}
+
+// OllamaEmbedRequest represents a request to the Ollama embed API.
+type OllamaEmbedRequest struct {
+	Model     string    `json:"model"`
+	Input     []string  `json:"input"`
+	KeepAlive KeepAlive `json:"keep_alive,omitempty"`
+}
+
+// OllamaEmbedResponse represents a response from the Ollama embed API.
+type OllamaEmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// OllamaLoadRequest is an Ollama /api/generate request without a prompt, which
+// loads the model and returns.
+type OllamaLoadRequest struct {
+	Model     string    `json:"model"`
+	Stream    bool      `json:"stream"`
+	KeepAlive KeepAlive `json:"keep_alive,omitempty"`
+}
+
+// KeepAlive is how long Ollama keeps a model loaded after a request: a duration such
+// as "10m", or a number of seconds where a negative number means forever. Ollama only
+// accepts the bare number form as a JSON number, so that is how it is sent.
+type KeepAlive string
+
+func (k KeepAlive) MarshalJSON() ([]byte, error) {
+	if n, err := strconv.ParseFloat(string(k), 64); err == nil {
+		return json.Marshal(n)
+	}
+	return json.Marshal(string(k))
 }
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Actions output filters report when they change or stop a response.
+const (
+	FilterActionStripped   = "stripped"   // reasoning blocks removed
+	FilterActionRedacted   = "redacted"   // secrets replaced with placeholders
+	FilterActionBlocked    = "blocked"    // the response matched a deny rule
+	FilterActionTruncated  = "truncated"  // the response was cut at the length limit
+	FilterActionNormalized = "normalized" // whitespace was tidied
+)
+
+// FilterResult is one output filter that acted on a response, as returned to
+// clients and recorded in the interaction log.
+type FilterResult struct {
+	Filter string `json:"filter"`
+	Action string `json:"action"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// FilterErrorResponse is the 422 body for a response blocked by an output filter.
+type FilterErrorResponse struct {
+	Error     string         `json:"error"`
+	RequestID string         `json:"request_id"`
+	Filters   []FilterResult `json:"filters"`
+}
+
+// FilterError reports a response stopped by a deny rule. Results holds every filter
+// that acted before the response was stopped, the blocking one last.
+type FilterError struct {
+	Results []FilterResult
+}
+
+func (e *FilterError) Error() string {
+	var blocked []string
+	for _, r := range e.Results {
+		if r.Action == FilterActionBlocked {
+			blocked = append(blocked, r.Detail)
+		}
+	}
+	return fmt.Sprintf("%s: %s", ErrOutputBlocked, strings.Join(blocked, ", "))
+}
+
+func (e *FilterError) Unwrap() error {
+	return ErrOutputBlocked
+}
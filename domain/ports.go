@@ -1,6 +1,10 @@
 package domain
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+	"time"
+)
 
 // LoggerPort is the logging port/interface for testable logging
 // (If you use mockgen for tests; otherwise, implement manually)
@@ -8,24 +12,239 @@ import "net/http"
 //go:generate mockgen -destination=../mocks/mock_logger.go -package=mocks minivault/domain LoggerPort
 
 type LoggerPort interface {
-	LogInteraction(prompt, response string)
+	LogInteraction(interaction Interaction)
 	LogError(message string, err error)
 	LogWarn(message string)
 	LogInfo(message string)
+	Close() error
+}
+
+// RedactorPort is the port/interface for scrubbing PII and secrets from text before it is logged
+type RedactorPort interface {
+	// Redact returns text with sensitive values replaced by typed placeholders,
+	// plus the number of replacements per type.
+	Redact(text string) (string, map[string]int)
+}
+
+// VaultPort is the port/interface for encrypting interaction fields at rest
+type VaultPort interface {
+	// Seal encrypts plaintext for the named field and returns the ciphertext and the key ID used.
+	Seal(field, plaintext string) (sealed, keyID string, err error)
+	// Open decrypts a sealed field value with the given key ID.
+	Open(keyID, field, sealed string) (string, error)
+	// ActiveKeyID is the key new records are sealed with.
+	ActiveKeyID() string
+}
+
+// InteractionStorePort is the read-side port over the interaction log
+type InteractionStorePort interface {
+	Query(q InteractionQuery) (*InteractionPage, error)
+	Get(id string) (*Interaction, error)
+	// Stats aggregates token usage per model over the generations matching q's
+	// time, model and API key filters.
+	Stats(q InteractionQuery) ([]ModelStats, error)
 }
 
 // OllamaPort is the port/interface for LLM calls
 //
 //go:generate mockgen -destination=../mocks/mock_ollama.go -package=mocks minivault/infrastructure OllamaPort
 type OllamaPort interface {
-	CallOllama(prompt string) (string, error)
+	CallOllama(ctx context.Context, req OllamaChatRequest) (*OllamaChatResponse, error)
+}
+
+// ModelManagerPort is the port/interface for managing the models installed in Ollama
+type ModelManagerPort interface {
+	ListModels(ctx context.Context) ([]ModelSummary, error)
+	// ShowModel returns a model's details, or ErrModelNotFound.
+	ShowModel(ctx context.Context, name string) (ModelInfo, error)
+	// PullModel downloads a model, calling progress for each update until it completes.
+	PullModel(ctx context.Context, name string, progress func(PullProgress)) error
+	// DeleteModel removes a model, or returns ErrModelNotFound.
+	DeleteModel(ctx context.Context, name string) error
+	RunningModels(ctx context.Context) ([]RunningModel, error)
+	// LoadModel loads a model into memory and keeps it there for keepAlive
+	// (empty uses Ollama's default).
+	LoadModel(ctx context.Context, name string, keepAlive KeepAlive) error
+}
+
+// UpstreamPoolPort is the port/interface for spreading Ollama calls over several hosts.
+// Model management applies to every host.
+type UpstreamPoolPort interface {
+	OllamaPort
+	EmbeddingPort
+	ModelManagerPort
+	// MonitorHealth probes every upstream and refreshes its model inventory until ctx is done.
+	MonitorHealth(ctx context.Context)
+	// Status reports each upstream's health, in configuration order.
+	Status() []UpstreamStatus
+}
+
+// GuardrailPort is the port/interface for the input policies checked before generation
+type GuardrailPort interface {
+	// Check returns the policies text matched, in chain order.
+	Check(text string) []PolicyResult
+}
+
+// OutputFilterPort is the port/interface for the filters applied to model answers before they reach users
+type OutputFilterPort interface {
+	// Filter runs the chain over a complete response. A deny match returns a *FilterError.
+	Filter(text string) (string, []FilterResult, error)
+	// Stream starts filtering a response that arrives in chunks.
+	Stream() OutputStreamPort
+}
+
+// OutputStreamPort filters one streamed response incrementally
+type OutputStreamPort interface {
+	// Write takes the next chunk and returns the text that is safe to send so far;
+	// text that later chunks could still change is held back.
+	Write(chunk string) (string, error)
+	// Close ends the response, returning the held-back text and what each filter did.
+	Close() (string, []FilterResult, error)
+}
+
+// QuotaPort is the port/interface for per-API-key request and token quotas
+type QuotaPort interface {
+	// Check returns a *QuotaError if keyID has used up any of its quotas, and
+	// otherwise counts one request against them.
+	Check(keyID string) error
+	// Debit records the tokens a request passed by Check used against keyID.
+	Debit(keyID string, tokens int) error
+	// Usage returns keyID's usage in the current periods.
+	Usage(keyID string) QuotaUsage
+	// List returns the usage of every key that has any, ordered by key ID.
+	List() []QuotaUsage
+	// Reset clears keyID's counters for the current periods.
+	Reset(keyID string) error
+}
+
+// TenantsPort is the port/interface for the tenants sharing the server
+type TenantsPort interface {
+	// TenantFor returns the tenant keyID is bound to; ok is false for unbound keys.
+	TenantFor(keyID string) (tenant Tenant, ok bool)
+	// Tenant returns a tenant by ID.
+	Tenant(id string) (tenant Tenant, ok bool)
+	// List returns every tenant, ordered by ID.
+	List() []Tenant
+	// Allow takes one request from the tenant's rate limit. When the request is
+	// refused, retryAfter is how long until the next one would be allowed.
+	Allow(id string) (ok bool, retryAfter time.Duration)
+}
+
+// InFlightPort is the port/interface for tracking the generations in progress so
+// they can be listed and cancelled
+type InFlightPort interface {
+	// Start registers a generation, taking its request ID and caller from ctx. The
+	// returned context is cancelled by Cancel; done must be called once the
+	// generation, and the delivery of its callback if async, has finished.
+	Start(ctx context.Context, req GenerateRequest, async bool) (genCtx context.Context, done func())
+	// List returns the generations in progress, oldest first.
+	List() []InFlightRequest
+	// Cancel stops the generation with the given request ID, reporting whether one was running.
+	Cancel(reqID string) bool
+	// CancelAll stops every generation in progress with the given cause and returns how many there were.
+	CancelAll(cause error) int
+	// Wait blocks until no generation is in progress or ctx ends.
+	Wait(ctx context.Context) error
+}
+
+// RuntimePort is the port/interface for inspecting and controlling the running server
+type RuntimePort interface {
+	Status(ctx context.Context) RuntimeStatus
+	// Config returns the effective configuration with secrets redacted.
+	Config() map[string]any
+	InFlight() []InFlightRequest
+	Cancel(reqID string) bool
+	// SetMaintenance turns maintenance mode on or off; while on, every
+	// non-admin request is answered with 503.
+	SetMaintenance(on bool)
+	// Drain stops taking new generations and shuts the server down once the
+	// ones in flight have finished.
+	Drain()
+}
+
+// WarmerPort is the port/interface for keeping models loaded so requests do not wait on a cold start
+type WarmerPort interface {
+	// Warmup loads the startup models, returning once all have loaded or failed.
+	Warmup(ctx context.Context) error
+	// KeepWarm pings the keep-warm models on a schedule until ctx is done.
+	KeepWarm(ctx context.Context)
+}
+
+// EmbeddingPort is the port/interface for embedding model calls
+type EmbeddingPort interface {
+	Embed(ctx context.Context, req OllamaEmbedRequest) (*OllamaEmbedResponse, error)
+}
+
+// VectorStorePort is the port/interface for the persistent vector index of document chunks
+type VectorStorePort interface {
+	// Upsert replaces all chunks of a document in a collection, creating the collection if needed.
+	Upsert(collection, documentID string, chunks []Chunk) error
+	// Search returns up to k chunks most similar to vector by cosine similarity, best first.
+	Search(collection string, vector []float64, k int) ([]ScoredChunk, error)
+}
+
+// ToolboxPort is the port/interface for the server-side tool registry
+type ToolboxPort interface {
+	// Tools returns the definitions of the named tools, or ErrUnknownTool if one is not registered.
+	Tools(names []string) ([]Tool, error)
+	// Call runs a tool and returns its result as text for the model.
+	Call(ctx context.Context, call ToolCall) (string, error)
 }
 
 // GeneratorPort is the use-case port for generation
 //
 //go:generate mockgen -destination=../mocks/mock_generator.go -package=mocks minivault/usecases Generator
 type GeneratorPort interface {
-	Generate(prompt string) (string, error)
+	Generate(ctx context.Context, req GenerateRequest) (*GenerateResponse, error)
+}
+
+// ChatSessionPort is the use-case port for one conversation: its turns so far, a
+// system prompt and a model, sent with every new turn
+type ChatSessionPort interface {
+	// Send generates the answer to prompt given the history, streaming it to a
+	// TokenSink in ctx, and records both turns once it succeeds.
+	Send(ctx context.Context, prompt string) (*GenerateResponse, error)
+	Model() string
+	SetModel(model string) // empty uses the server's model
+	System() string
+	SetSystem(prompt string) // empty removes the system prompt
+	// History returns the turns so far, without the system prompt.
+	History() []OllamaChatMessage
+	// Load replaces the history, taking a leading system message as the system prompt.
+	Load(messages []OllamaChatMessage)
+	Reset()
+}
+
+// EmbedderPort is the use-case port for embeddings
+type EmbedderPort interface {
+	Embed(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error)
+}
+
+// KnowledgeBasePort is the use-case port for document ingestion and retrieval
+type KnowledgeBasePort interface {
+	Ingest(ctx context.Context, req DocumentRequest) (*Document, error)
+	// Retrieve returns the chunks of a collection most relevant to query.
+	Retrieve(ctx context.Context, opts RetrievalOptions, query string) ([]ScoredChunk, error)
+}
+
+// ReplayerPort is the use-case port for replaying logged prompts against another model
+type ReplayerPort interface {
+	// Run replays every interaction and calls emit once per result, in input order.
+	Run(ctx context.Context, interactions []Interaction, emit func(ReplayResult)) ReplaySummary
+}
+
+// EvaluatorPort is the use-case port for running golden-set evaluation suites
+type EvaluatorPort interface {
+	Run(ctx context.Context, suite EvalSuite) EvalReport
+}
+
+// CallbackPort is the port/interface for delivering generation results to callback URLs
+type CallbackPort interface {
+	// Validate reports whether rawURL may be used as a callback target.
+	Validate(rawURL string) error
+	// Deliver POSTs the payload to rawURL, retrying until it succeeds, gives up or
+	// ctx ends; undelivered payloads are dead-lettered.
+	Deliver(ctx context.Context, rawURL string, payload CallbackPayload) error
 }
 
 // HttpHandlerPort is the port/interface for HTTP handlers
@@ -34,3 +253,54 @@ type GeneratorPort interface {
 type HttpHandlerPort interface {
 	Generate(w http.ResponseWriter, r *http.Request)
 }
+
+// ChatSocketHandlerPort is the port/interface for the WebSocket chat handler
+type ChatSocketHandlerPort interface {
+	Chat(w http.ResponseWriter, r *http.Request)
+	// Shutdown closes every connection once its turn in progress, if any, has
+	// finished. New connections are closed as soon as they open.
+	Shutdown()
+}
+
+// EmbeddingsHandlerPort is the port/interface for the embeddings HTTP handler
+type EmbeddingsHandlerPort interface {
+	Embed(w http.ResponseWriter, r *http.Request)
+}
+
+// DocumentsHandlerPort is the port/interface for the document ingestion HTTP handler
+type DocumentsHandlerPort interface {
+	Ingest(w http.ResponseWriter, r *http.Request)
+}
+
+// ModelsHandlerPort is the port/interface for the admin model management HTTP handlers
+type ModelsHandlerPort interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Show(w http.ResponseWriter, r *http.Request)
+	Pull(w http.ResponseWriter, r *http.Request)
+	Delete(w http.ResponseWriter, r *http.Request)
+	Running(w http.ResponseWriter, r *http.Request)
+}
+
+// QuotasHandlerPort is the port/interface for the admin quota HTTP handlers
+type QuotasHandlerPort interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Get(w http.ResponseWriter, r *http.Request)
+	Reset(w http.ResponseWriter, r *http.Request)
+}
+
+// AdminHandlerPort is the port/interface for the admin runtime HTTP handlers
+type AdminHandlerPort interface {
+	Status(w http.ResponseWriter, r *http.Request)
+	Config(w http.ResponseWriter, r *http.Request)
+	Requests(w http.ResponseWriter, r *http.Request)
+	Cancel(w http.ResponseWriter, r *http.Request)
+	Drain(w http.ResponseWriter, r *http.Request)
+	Maintenance(w http.ResponseWriter, r *http.Request)
+}
+
+// InteractionsHandlerPort is the port/interface for the interaction history HTTP handlers
+type InteractionsHandlerPort interface {
+	List(w http.ResponseWriter, r *http.Request)
+	Get(w http.ResponseWriter, r *http.Request)
+	Stats(w http.ResponseWriter, r *http.Request)
+}
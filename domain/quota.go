@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Quotas that can be set per API key. Periods are UTC calendar days and months.
+const (
+	QuotaRequestsPerDay   = "requests_per_day"
+	QuotaRequestsPerMonth = "requests_per_month"
+	QuotaTokensPerDay     = "tokens_per_day"
+	QuotaTokensPerMonth   = "tokens_per_month"
+)
+
+// QuotaNames lists the quotas in the order they are checked.
+var QuotaNames = []string{QuotaRequestsPerDay, QuotaRequestsPerMonth, QuotaTokensPerDay, QuotaTokensPerMonth}
+
+// ErrorCodeQuotaExceeded tells a 429 caused by a quota from other throttling.
+const ErrorCodeQuotaExceeded = "quota_exceeded"
+
+// QuotaCounter is an API key's usage in one period. Limits of 0 are unlimited.
+type QuotaCounter struct {
+	Period       string    `json:"period"` // "2006-01-02" or "2006-01"
+	Requests     int       `json:"requests"`
+	Tokens       int       `json:"tokens"`
+	RequestLimit int       `json:"request_limit,omitempty"`
+	TokenLimit   int       `json:"token_limit,omitempty"`
+	ResetsAt     time.Time `json:"resets_at"`
+}
+
+// QuotaUsage is an API key's usage in the current day and month.
+type QuotaUsage struct {
+	KeyID string       `json:"api_key_id"`
+	Day   QuotaCounter `json:"day"`
+	Month QuotaCounter `json:"month"`
+}
+
+// QuotaError reports a request refused because a quota is used up.
+type QuotaError struct {
+	Quota    string
+	Limit    int
+	Used     int
+	ResetsAt time.Time
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: %s is %d of %d until %s", ErrQuotaExceeded, e.Quota, e.Used, e.Limit, e.ResetsAt.Format(time.RFC3339))
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// QuotaErrorResponse is the 429 body for a request refused by a quota.
+type QuotaErrorResponse struct {
+	Error     string    `json:"error"`
+	Code      string    `json:"code"`
+	RequestID string    `json:"request_id"`
+	Quota     string    `json:"quota"`
+	Limit     int       `json:"limit"`
+	Used      int       `json:"used"`
+	ResetsAt  time.Time `json:"resets_at"`
+}
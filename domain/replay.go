@@ -0,0 +1,28 @@
+package domain
+
+// ReplayResult pairs a logged interaction with the answer a different model gave to the same prompt.
+type ReplayResult struct {
+	InteractionID  string  `json:"interaction_id"`
+	Prompt         string  `json:"prompt"`
+	OldModel       string  `json:"old_model,omitempty"`
+	NewModel       string  `json:"new_model"`
+	OldResponse    string  `json:"old_response"`
+	NewResponse    string  `json:"new_response,omitempty"`
+	Similarity     float64 `json:"similarity"`
+	LengthDelta    int     `json:"length_delta"`
+	OldLatencyMS   int64   `json:"old_latency_ms,omitempty"`
+	NewLatencyMS   int64   `json:"new_latency_ms"`
+	LatencyDeltaMS int64   `json:"latency_delta_ms,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// ReplaySummary aggregates a replay run.
+type ReplaySummary struct {
+	Total              int            `json:"total"`
+	Failed             int            `json:"failed"`
+	MeanSimilarity     float64        `json:"mean_similarity"`
+	MedianSimilarity   float64        `json:"median_similarity"`
+	MeanLengthDelta    float64        `json:"mean_length_delta"`
+	MeanLatencyDeltaMS float64        `json:"mean_latency_delta_ms"`
+	LeastSimilar       []ReplayResult `json:"least_similar"`
+}
@@ -0,0 +1,45 @@
+package domain
+
+import "time"
+
+// InFlightRequest is a generation in progress.
+type InFlightRequest struct {
+	RequestID string    `json:"request_id"`
+	APIKeyID  string    `json:"api_key_id,omitempty"`
+	Tenant    string    `json:"tenant,omitempty"`
+	Model     string    `json:"model,omitempty"` // as requested; empty for the default model
+	Async     bool      `json:"async,omitempty"` // accepted with a callback_url
+	StartedAt time.Time `json:"started_at"`
+	AgeMS     int64     `json:"age_ms"`
+}
+
+// UpstreamStatus is the health of one Ollama host as last seen by the server.
+type UpstreamStatus struct {
+	URL          string     `json:"url"`
+	Healthy      bool       `json:"healthy"`
+	EjectedUntil *time.Time `json:"ejected_until,omitempty"`
+	Outstanding  int64      `json:"outstanding"` // calls in progress on this host
+	Error        string     `json:"error,omitempty"`
+}
+
+// BuildInfo identifies the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	GoVersion string `json:"go_version"`
+}
+
+// RuntimeStatus is what the server is doing right now.
+type RuntimeStatus struct {
+	Build       BuildInfo         `json:"build"`
+	StartedAt   time.Time         `json:"started_at"`
+	Uptime      string            `json:"uptime"`
+	Ready       bool              `json:"ready"`
+	Maintenance bool              `json:"maintenance"`
+	Draining    bool              `json:"draining"`
+	InFlight    []InFlightRequest `json:"in_flight"`
+	QueueDepth  int               `json:"queue_depth"` // callback jobs accepted and not yet delivered
+	Upstreams   []UpstreamStatus  `json:"upstreams"`
+}
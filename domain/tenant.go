@@ -0,0 +1,20 @@
+package domain
+
+// Tenant is a team sharing the server. Its API keys only see its own interactions,
+// which are logged to a directory of their own.
+type Tenant struct {
+	ID        string   `json:"id"`
+	APIKeyIDs []string `json:"api_key_ids"`
+	// Models the tenant may generate with. A request that names none goes through
+	// the steps of the fallback chain whose model is listed, or to the first model
+	// if none is. Empty allows any model.
+	Models []string `json:"models,omitempty"`
+	// SystemPrompt is sent before every conversation of the tenant's.
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	// RateLimit is requests per minute across the tenant's keys; 0 is unlimited.
+	RateLimit int `json:"rate_limit,omitempty"`
+	// RateBurst is how many requests may arrive at once; defaults to RateLimit.
+	RateBurst int `json:"rate_burst,omitempty"`
+	// LogDir holds the tenant's interaction log; defaults to LOG_DIR/tenants/<id>.
+	LogDir string `json:"log_dir,omitempty"`
+}
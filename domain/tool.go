@@ -0,0 +1,38 @@
+package domain
+
+import "encoding/json"
+
+// ToolTypeFunction is the only tool type Ollama supports.
+const ToolTypeFunction = "function"
+
+// Tool describes a function the model may call, in Ollama's chat API format.
+type Tool struct {
+	Type     string       `json:"type"`
+	Function ToolFunction `json:"function"`
+}
+
+// ToolFunction is a tool's name, description and JSON Schema for its arguments.
+type ToolFunction struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToolCall is a model's request to call a tool.
+type ToolCall struct {
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction names the tool to call and its arguments.
+type ToolCallFunction struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// ToolInvocation records a server-side tool call made while answering a request.
+type ToolInvocation struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Result    string         `json:"result,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
@@ -0,0 +1,85 @@
+package domain
+
+import "math"
+
+// Usage is the token counts and timings Ollama reported for one generation, summed
+// over every model call it took: server tool rounds and format repairs each add one.
+type Usage struct {
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	ModelCalls       int     `json:"model_calls"`
+	TotalMS          float64 `json:"total_ms"`
+	LoadMS           float64 `json:"load_ms"`
+	PromptEvalMS     float64 `json:"prompt_eval_ms"`
+	EvalMS           float64 `json:"eval_ms"`
+	TokensPerSecond  float64 `json:"tokens_per_second"`     // completion tokens per second of eval time
+	DoneReason       string  `json:"done_reason,omitempty"` // of the last call, e.g. "stop" or "length"
+}
+
+// Add accumulates the metadata of one chat response.
+func (u *Usage) Add(resp *OllamaChatResponse) {
+	u.ModelCalls++
+	u.PromptTokens += resp.PromptEvalCount
+	u.CompletionTokens += resp.EvalCount
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	u.TotalMS = roundMS(u.TotalMS + nsToMS(resp.TotalDuration))
+	u.LoadMS = roundMS(u.LoadMS + nsToMS(resp.LoadDuration))
+	u.PromptEvalMS = roundMS(u.PromptEvalMS + nsToMS(resp.PromptEvalDuration))
+	u.EvalMS = roundMS(u.EvalMS + nsToMS(resp.EvalDuration))
+	u.TokensPerSecond = TokensPerSecond(u.CompletionTokens, u.EvalMS)
+	u.DoneReason = resp.DoneReason
+}
+
+// AddStreamed counts the tokens of a streamed call that ended before Ollama reported
+// its counts, one completion token per chunk received.
+func (u *Usage) AddStreamed(chunks int) {
+	if chunks == 0 {
+		return
+	}
+	u.ModelCalls++
+	u.CompletionTokens += chunks
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+}
+
+// UsageError is a failed generation that had already used model tokens, such as one
+// whose structured output never validated or whose answer a filter blocked.
+type UsageError struct {
+	Err   error
+	Usage Usage
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ModelStats is the token usage of one model over a set of interactions.
+type ModelStats struct {
+	Model            string  `json:"model"`
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	EvalMS           float64 `json:"eval_ms"`
+	TokensPerSecond  float64 `json:"tokens_per_second"`
+}
+
+// TokensPerSecond is the generation speed for tokens produced in evalMS milliseconds.
+func TokensPerSecond(tokens int, evalMS float64) float64 {
+	if evalMS <= 0 {
+		return 0
+	}
+	return math.Round(float64(tokens)/evalMS*1000*10) / 10
+}
+
+func nsToMS(ns int64) float64 {
+	return float64(ns) / 1e6
+}
+
+// roundMS keeps a tenth of a millisecond, enough for timing headers and logs.
+func roundMS(ms float64) float64 {
+	return math.Round(ms*10) / 10
+}
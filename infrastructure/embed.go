@@ -0,0 +1,80 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"minivault/config"
+	"minivault/domain"
+	"net/http"
+	"time"
+)
+
+type ollamaEmbedder struct {
+	httpClient *http.Client
+	embedURL   string
+	model      string
+	keepAlive  domain.KeepAlive
+}
+
+func NewOllamaEmbedder(cfg *config.Config) domain.EmbeddingPort {
+	return newOllamaEmbedder(cfg.OllamaEmbedURL, cfg.EmbedModel, cfg.OllamaKeepAlive, cfg.OllamaTimeout)
+}
+
+func newOllamaEmbedder(url, model, keepAlive string, timeout time.Duration) *ollamaEmbedder {
+	return &ollamaEmbedder{
+		httpClient: &http.Client{
+			Timeout: timeout,
+		},
+		embedURL:  url,
+		model:     model,
+		keepAlive: domain.KeepAlive(keepAlive),
+	}
+}
+
+// Embed calls Ollama's /api/embed (implements domain.EmbeddingPort).
+// An empty req.Model uses the configured embedding model, and an empty KeepAlive the
+// configured keep-alive. Failures are classified like CallOllama's.
+func (c *ollamaEmbedder) Embed(ctx context.Context, embedReq domain.OllamaEmbedRequest) (*domain.OllamaEmbedResponse, error) {
+	if embedReq.Model == "" {
+		embedReq.Model = c.model
+	}
+	if embedReq.KeepAlive == "" {
+		embedReq.KeepAlive = c.keepAlive
+	}
+	data, err := json.Marshal(embedReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal embed request: %w", err)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, "POST", c.embedURL, bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new HTTP request: %w", err)
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to perform HTTP request: %w", transportFailure(err), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("%w: ollama API returned status %d: %s", statusFailure(resp.StatusCode), resp.StatusCode, string(body))
+	}
+
+	var embedResp domain.OllamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal embed response: %w", err)
+	}
+	if len(embedResp.Embeddings) != len(embedReq.Input) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d inputs", len(embedResp.Embeddings), len(embedReq.Input))
+	}
+	if embedResp.Model == "" {
+		embedResp.Model = embedReq.Model
+	}
+	return &embedResp, nil
+}
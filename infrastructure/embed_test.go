@@ -0,0 +1,49 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"minivault/domain"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOllamaEmbedder_Embed(t *testing.T) {
+	var sent domain.OllamaEmbedRequest
+	c := &ollamaEmbedder{model: "nomic-embed-text", httpClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		body := `{"model":"nomic-embed-text","embeddings":[[0.1,0.2],[0.3,0.4]]}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})}}
+	resp, err := c.Embed(context.Background(), domain.OllamaEmbedRequest{Input: []string{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.Model != "nomic-embed-text" || len(sent.Input) != 2 {
+		t.Errorf("unexpected request: %+v", sent)
+	}
+	if len(resp.Embeddings) != 2 || resp.Embeddings[1][0] != 0.3 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
+
+func TestOllamaEmbedder_CountMismatch(t *testing.T) {
+	c := &ollamaEmbedder{httpClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"embeddings":[[1]]}`))}, nil
+	})}}
+	if _, err := c.Embed(context.Background(), domain.OllamaEmbedRequest{Input: []string{"a", "b"}}); err == nil {
+		t.Error("expected error when Ollama returns fewer embeddings than inputs")
+	}
+}
+
+func TestOllamaEmbedder_Non2xxStatus(t *testing.T) {
+	c := &ollamaEmbedder{httpClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader(`model not found`))}, nil
+	})}}
+	_, err := c.Embed(context.Background(), domain.OllamaEmbedRequest{Input: []string{"a"}})
+	if err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("expected status error, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package infrastructure
+
+import (
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"strings"
+	"time"
+)
+
+// NewFallbackSteps parses FALLBACK_CHAIN entries of the form
+// "model[;timeout=30s][;url=http://host:11434/api/chat]". Steps without a url use
+// primary, the client for OLLAMA_URL; the others get a client of their own.
+func NewFallbackSteps(cfg *config.Config, primary domain.OllamaPort) ([]domain.FallbackStep, error) {
+	steps := make([]domain.FallbackStep, 0, len(cfg.FallbackChain))
+	for _, entry := range cfg.FallbackChain {
+		fields := strings.Split(entry, ";")
+		step := domain.FallbackStep{Model: strings.TrimSpace(fields[0]), Ollama: primary, Timeout: cfg.OllamaTimeout}
+		if !domain.ValidModelName(step.Model) {
+			return nil, fmt.Errorf("invalid FALLBACK_CHAIN entry %q: %w", entry, domain.ErrInvalidModelName)
+		}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			switch key {
+			case "timeout":
+				d, err := time.ParseDuration(value)
+				if err != nil || d <= 0 {
+					return nil, fmt.Errorf("invalid FALLBACK_CHAIN timeout in %q", entry)
+				}
+				step.Timeout = d
+			case "url":
+				if !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+					return nil, fmt.Errorf("invalid FALLBACK_CHAIN url in %q", entry)
+				}
+				step.Ollama = newOllamaClient(value, step.Model, cfg.OllamaKeepAlive, step.Timeout)
+			default:
+				return nil, fmt.Errorf("unknown FALLBACK_CHAIN option %q in %q", key, entry)
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
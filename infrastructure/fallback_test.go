@@ -0,0 +1,37 @@
+package infrastructure
+
+import (
+	"minivault/config"
+	"minivault/mocks"
+	"testing"
+	"time"
+)
+
+func TestNewFallbackSteps(t *testing.T) {
+	primary := &mocks.MockOllama{}
+	cfg := &config.Config{
+		OllamaTimeout: 30 * time.Second,
+		FallbackChain: []string{"gemma:2b", "phi3:mini;timeout=10s;url=http://backup:11434/api/chat"},
+	}
+	steps, err := NewFallbackSteps(cfg, primary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(steps))
+	}
+	if steps[0].Model != "gemma:2b" || steps[0].Ollama != primary || steps[0].Timeout != 30*time.Second {
+		t.Errorf("unexpected first step: %+v", steps[0])
+	}
+	backup, ok := steps[1].Ollama.(*ollamaClient)
+	if !ok || backup.ollamaURL != "http://backup:11434/api/chat" || steps[1].Timeout != 10*time.Second {
+		t.Errorf("unexpected second step: %+v", steps[1])
+	}
+
+	for _, bad := range []string{"gemma:2b;timeout=soon", "gemma:2b;url=backup", "gemma:2b;retries=2", "bad model"} {
+		cfg.FallbackChain = []string{bad}
+		if _, err := NewFallbackSteps(cfg, primary); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package infrastructure
+
+import (
+	"bufio"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// inputPolicy is one link of the guardrail chain. check returns why text matched.
+type inputPolicy struct {
+	id     string
+	action string
+	check  func(text string) (reason string, matched bool)
+}
+
+// guardrails implements domain.GuardrailPort by running every policy in order.
+type guardrails struct {
+	policies []inputPolicy
+}
+
+// injectionPatterns are heuristics for common prompt-injection phrasings. They favour
+// precision: a user asking about prompt injection in general should not match.
+var injectionPatterns = []struct {
+	name string
+	re   *regexp.Regexp
+}{
+	{"ignore_instructions", regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\b[\w\s,]{0,30}\b(previous|prior|above|earlier|preceding|all|your|system)\b[\w\s]{0,20}\b(instructions?|prompts?|rules|directions|guidelines)\b`)},
+	{"reveal_prompt", regexp.MustCompile(`(?i)\b(reveal|show|print|repeat|output|tell me)\b[\w\s]{0,30}\b(system|hidden|initial|original)\s+(prompt|instructions|message)\b`)},
+	{"role_override", regexp.MustCompile(`(?i)\b(you are now|from now on,? you are|act as|pretend (to be|you are))\b[\w\s]{0,40}\b(unrestricted|unfiltered|no (rules|restrictions|limits)|jailbroken|DAN)\b`)},
+	{"jailbreak", regexp.MustCompile(`(?i)\b(jailbreak|developer mode|do anything now)\b`)},
+	{"fake_turn", regexp.MustCompile(`(?im)^\s*(system|assistant)\s*:|<\|?(system|im_start)\|?>|\[/?INST\]`)},
+}
+
+// secretRuleNames are the built-in redaction rules that detect credentials, as
+// opposed to personal data.
+var secretRuleNames = []string{"PRIVATE_KEY", "TOKEN", "API_KEY"}
+
+func secretRules() []redactRule {
+	var rules []redactRule
+	for _, r := range builtinRedactRules() {
+		if slices.Contains(secretRuleNames, r.name) {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// NewGuardrails builds the policy chain from GUARDRAILS, a list of "policy:action"
+// entries in evaluation order. Returns nil when no policies are configured.
+func NewGuardrails(cfg *config.Config) (domain.GuardrailPort, error) {
+	if len(cfg.Guardrails) == 0 {
+		return nil, nil
+	}
+	g := &guardrails{}
+	for _, entry := range cfg.Guardrails {
+		id, action, _ := strings.Cut(entry, ":")
+		switch action {
+		case domain.PolicyActionBlock, domain.PolicyActionWarn, domain.PolicyActionTag:
+		default:
+			return nil, fmt.Errorf("invalid GUARDRAILS entry %q: action must be block, warn or tag", entry)
+		}
+		check, err := newPolicyCheck(id, cfg)
+		if err != nil {
+			return nil, err
+		}
+		g.policies = append(g.policies, inputPolicy{id: id, action: action, check: check})
+	}
+	return g, nil
+}
+
+func newPolicyCheck(id string, cfg *config.Config) (func(string) (string, bool), error) {
+	switch id {
+	case "max_length":
+		limit := cfg.GuardrailMaxChars
+		return func(text string) (string, bool) {
+			if n := utf8.RuneCountInString(text); n > limit {
+				return fmt.Sprintf("input is %d characters, max %d", n, limit), true
+			}
+			return "", false
+		}, nil
+
+	case "blocklist":
+		if cfg.GuardrailBlocklistFile == "" {
+			return nil, fmt.Errorf("the blocklist guardrail needs GUARDRAIL_BLOCKLIST_FILE")
+		}
+		entries, err := loadBlocklist(cfg.GuardrailBlocklistFile, "guardrail blocklist")
+		if err != nil {
+			return nil, err
+		}
+		return func(text string) (string, bool) {
+			for _, e := range entries {
+				if e.re.MatchString(text) {
+					// name the line, not the term, so the response does not echo the list
+					return fmt.Sprintf("matched blocklist entry on line %d", e.line), true
+				}
+			}
+			return "", false
+		}, nil
+
+	case "language":
+		if len(cfg.GuardrailLanguages) == 0 {
+			return nil, fmt.Errorf("the language guardrail needs GUARDRAIL_LANGUAGES")
+		}
+		allowed := cfg.GuardrailLanguages
+		return func(text string) (string, bool) {
+			lang := detectLanguage(text)
+			if lang != "" && !slices.Contains(allowed, lang) {
+				return fmt.Sprintf("detected language %s, allowed: %s", lang, strings.Join(allowed, ", ")), true
+			}
+			return "", false
+		}, nil
+
+	case "injection":
+		return func(text string) (string, bool) {
+			for _, p := range injectionPatterns {
+				if p.re.MatchString(text) {
+					return "matched prompt-injection heuristic " + p.name, true
+				}
+			}
+			return "", false
+		}, nil
+
+	case "secrets":
+		rules := secretRules()
+		return func(text string) (string, bool) {
+			for _, r := range rules {
+				if r.re.MatchString(text) {
+					return "contains a credential (" + r.name + ")", true
+				}
+			}
+			return "", false
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown guardrail policy %q", id)
+}
+
+// Check implements GuardrailPort
+func (g *guardrails) Check(text string) []domain.PolicyResult {
+	var results []domain.PolicyResult
+	for _, p := range g.policies {
+		if reason, matched := p.check(text); matched {
+			results = append(results, domain.PolicyResult{Policy: p.id, Action: p.action, Reason: reason})
+		}
+	}
+	return results
+}
+
+type blocklistEntry struct {
+	line int
+	re   *regexp.Regexp
+}
+
+// loadBlocklist reads one entry per line: a word or phrase matched case-insensitively
+// on word boundaries with any whitespace between words, or "re:" followed by a regular expression. Blank lines and #
+// comments are ignored. what names the list in errors.
+func loadBlocklist(path, what string) ([]blocklistEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", what, err)
+	}
+	defer f.Close()
+
+	var entries []blocklistEntry
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		pattern, isRegex := strings.CutPrefix(line, "re:")
+		if !isRegex {
+			words := strings.Fields(line)
+			for i, w := range words {
+				words[i] = regexp.QuoteMeta(w)
+			}
+			pattern = `(?i)\b` + strings.Join(words, `\s+`) + `\b`
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", what, lineNo, err)
+		}
+		entries = append(entries, blocklistEntry{line: lineNo, re: re})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", what, err)
+	}
+	return entries, nil
+}
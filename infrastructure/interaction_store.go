@@ -0,0 +1,434 @@
+package infrastructure
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"minivault/config"
+	"minivault/domain"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultQueryLimit = 50
+	maxQueryLimit     = 500
+)
+
+// indexEntry holds the filterable metadata of one interaction and where its record lives.
+type indexEntry struct {
+	id        string
+	kind      string
+	requestID string
+	model     string
+	apiKeyID  string
+	tenant    string
+	time      time.Time
+	usage     *domain.Usage
+	file      string
+	offset    int64
+	length    int
+}
+
+// indexedFile tracks how much of a log file has been indexed.
+type indexedFile struct {
+	path   string
+	info   os.FileInfo
+	offset int64
+}
+
+// interactionStore implements domain.InteractionStorePort over the JSONL files in the
+// log directory. It keeps an in-memory index of record metadata and file offsets that
+// is extended with newly appended lines on each call; full rescans only happen when
+// files are rotated, compressed, pruned or rewritten.
+type interactionStore struct {
+	dir   string
+	vault domain.VaultPort
+
+	mu      sync.Mutex
+	entries []indexEntry
+	byID    map[string]int
+	files   []*indexedFile
+}
+
+func NewInteractionStore(cfg *config.Config, vault domain.VaultPort) domain.InteractionStorePort {
+	return &interactionStore{dir: cfg.LogDir, vault: vault, byID: make(map[string]int)}
+}
+
+// Query returns one page of interactions matching q.
+func (s *interactionStore) Query(q domain.InteractionQuery) (*domain.InteractionPage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultQueryLimit
+	}
+	if limit > maxQueryLimit {
+		limit = maxQueryLimit
+	}
+	step, pos := -1, len(s.entries)-1
+	if q.Order == domain.OrderAsc {
+		step, pos = 1, 0
+	}
+	if q.Cursor != "" {
+		id, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+		i, ok := s.byID[string(id)]
+		if err != nil || !ok {
+			return nil, domain.ErrInvalidCursor
+		}
+		pos = i + step
+	}
+
+	reader := &recordReader{}
+	defer reader.Close()
+	search := strings.ToLower(q.Search)
+	page := &domain.InteractionPage{Interactions: []domain.Interaction{}}
+	for ; pos >= 0 && pos < len(s.entries); pos += step {
+		e := s.entries[pos]
+		if !matchesMetadata(e, q) {
+			continue
+		}
+		var interaction *domain.Interaction
+		if search != "" {
+			var err error
+			if interaction, err = s.load(reader, e); err != nil {
+				return nil, err
+			}
+			if !strings.Contains(strings.ToLower(interaction.Prompt), search) &&
+				!strings.Contains(strings.ToLower(interaction.Response), search) {
+				continue
+			}
+		}
+		if len(page.Interactions) == limit {
+			// one more match exists, so there is a next page
+			last := page.Interactions[limit-1]
+			page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(last.ID))
+			break
+		}
+		if interaction == nil {
+			var err error
+			if interaction, err = s.load(reader, e); err != nil {
+				return nil, err
+			}
+		}
+		page.Interactions = append(page.Interactions, *interaction)
+	}
+	return page, nil
+}
+
+// Get returns a single interaction by ID.
+func (s *interactionStore) Get(id string) (*domain.Interaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	i, ok := s.byID[id]
+	if !ok {
+		return nil, domain.ErrInteractionNotFound
+	}
+	reader := &recordReader{}
+	defer reader.Close()
+	return s.load(reader, s.entries[i])
+}
+
+// Stats sums the usage recorded on generations per model. Speed is computed from
+// the summed eval time, so long generations weigh more than short ones.
+func (s *interactionStore) Stats(q domain.InteractionQuery) ([]domain.ModelStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	q.Kind = domain.InteractionKindGenerate
+	byModel := make(map[string]*domain.ModelStats)
+	for _, e := range s.entries {
+		if e.usage == nil || !matchesMetadata(e, q) {
+			continue
+		}
+		m, ok := byModel[e.model]
+		if !ok {
+			m = &domain.ModelStats{Model: e.model}
+			byModel[e.model] = m
+		}
+		m.Requests++
+		m.PromptTokens += e.usage.PromptTokens
+		m.CompletionTokens += e.usage.CompletionTokens
+		m.EvalMS += e.usage.EvalMS
+	}
+	stats := make([]domain.ModelStats, 0, len(byModel))
+	for _, m := range byModel {
+		m.EvalMS = math.Round(m.EvalMS*10) / 10
+		m.TokensPerSecond = domain.TokensPerSecond(m.CompletionTokens, m.EvalMS)
+		stats = append(stats, *m)
+	}
+	slices.SortFunc(stats, func(a, b domain.ModelStats) int { return strings.Compare(a.Model, b.Model) })
+	return stats, nil
+}
+
+func matchesMetadata(e indexEntry, q domain.InteractionQuery) bool {
+	switch {
+	case !q.Since.IsZero() && e.time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !e.time.Before(q.Until):
+		return false
+	case q.Kind != "" && e.kind != q.Kind:
+		return false
+	case q.Model != "" && e.model != q.Model:
+		return false
+	case q.RequestID != "" && e.requestID != q.RequestID:
+		return false
+	case q.APIKeyID != "" && e.apiKeyID != q.APIKeyID:
+		return false
+	}
+	return true
+}
+
+// refresh brings the index up to date. If the set of files is unchanged and every
+// file has only grown, just the new bytes are indexed; otherwise the index is rebuilt.
+func (s *interactionStore) refresh() error {
+	paths := LogFiles(s.dir)
+	if s.appendOnly(paths) {
+		for _, f := range s.files {
+			if err := s.indexFile(f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	s.entries = nil
+	s.byID = make(map[string]int)
+	s.files = nil
+	for _, path := range paths {
+		f := &indexedFile{path: path}
+		s.files = append(s.files, f)
+		if err := s.indexFile(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *interactionStore) appendOnly(paths []string) bool {
+	if len(paths) != len(s.files) {
+		return false
+	}
+	for i, path := range paths {
+		f := s.files[i]
+		if f.path != path || f.info == nil {
+			return false
+		}
+		info, err := os.Stat(path)
+		if err != nil || !os.SameFile(info, f.info) || info.Size() < f.info.Size() {
+			return false
+		}
+	}
+	return true
+}
+
+// indexFile indexes complete lines after f.offset. A trailing partial line (a write
+// in progress) is left for the next refresh. Compressed segments are indexed once.
+func (s *interactionStore) indexFile(f *indexedFile) error {
+	compressed := strings.HasSuffix(f.path, ".gz")
+	if compressed && f.info != nil {
+		return nil
+	}
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if !compressed && info.Size() == f.offset {
+		f.info = info
+		return nil
+	}
+
+	r, err := openLogFile(f.path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if f.offset > 0 {
+		if _, err := io.CopyN(io.Discard, r, f.offset); err != nil {
+			return err
+		}
+	}
+
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		s.indexLine(f.path, f.offset, line)
+		f.offset += int64(len(line))
+	}
+	f.info = info
+	return nil
+}
+
+// legacyInteractionMessage is the message of interaction records, which identifies
+// those written before records carried an ID.
+const legacyInteractionMessage = "generation interaction"
+
+// indexLine records an interaction line's metadata. Interactions written before
+// records carried an ID get one derived from their content and offset, which do not
+// change when the file is rotated or compressed. Other lines are not indexed.
+func (s *interactionStore) indexLine(path string, offset int64, line []byte) {
+	var meta struct {
+		ID        string        `json:"id"`
+		Kind      string        `json:"kind"`
+		RequestID string        `json:"request_id"`
+		Model     string        `json:"model"`
+		APIKeyID  string        `json:"api_key_id"`
+		Tenant    string        `json:"tenant"`
+		Time      time.Time     `json:"time"`
+		Usage     *domain.Usage `json:"usage"`
+		Message   string        `json:"message"`
+	}
+	if err := json.Unmarshal(line, &meta); err != nil {
+		return
+	}
+	if meta.ID == "" {
+		if meta.Message != legacyInteractionMessage {
+			return
+		}
+		sum := sha256.Sum256(line)
+		meta.ID = fmt.Sprintf("legacy-%x-%d", sum[:8], offset)
+	}
+	if meta.Kind == "" {
+		meta.Kind = domain.InteractionKindGenerate
+	}
+	entry := indexEntry{
+		id:        meta.ID,
+		kind:      meta.Kind,
+		requestID: meta.RequestID,
+		model:     meta.Model,
+		apiKeyID:  meta.APIKeyID,
+		tenant:    meta.Tenant,
+		time:      meta.Time,
+		usage:     meta.Usage,
+		file:      path,
+		offset:    offset,
+		length:    len(line),
+	}
+	if i, ok := s.byID[meta.ID]; ok {
+		s.entries[i] = entry
+		return
+	}
+	s.byID[meta.ID] = len(s.entries)
+	s.entries = append(s.entries, entry)
+}
+
+// load reads, decrypts and decodes the record an index entry points to.
+func (s *interactionStore) load(reader *recordReader, e indexEntry) (*domain.Interaction, error) {
+	line, err := reader.ReadAt(e.file, e.offset, e.length)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read interaction %s: %w", e.id, err)
+	}
+	var rec map[string]any
+	if err := json.Unmarshal(line, &rec); err != nil {
+		return nil, fmt.Errorf("failed to decode interaction %s: %w", e.id, err)
+	}
+	if err := DecryptRecord(s.vault, rec); err != nil {
+		return nil, fmt.Errorf("failed to decrypt interaction %s: %w", e.id, err)
+	}
+	str := func(key string) string {
+		v, _ := rec[key].(string)
+		return v
+	}
+	latency, _ := rec["latency_ms"].(float64)
+	var policies []domain.PolicyResult
+	decodeField(rec, "policies", &policies)
+	var filters []domain.FilterResult
+	decodeField(rec, "filters", &filters)
+	return &domain.Interaction{
+		ID:        e.id,
+		Kind:      e.kind,
+		RequestID: e.requestID,
+		Time:      e.time,
+		Model:     e.model,
+		APIKeyID:  e.apiKeyID,
+		Tenant:    e.tenant,
+		Prompt:    str("prompt"),
+		Response:  str("response"),
+		LatencyMS: int64(latency),
+		Usage:     e.usage,
+		Policies:  policies,
+		Filters:   filters,
+	}, nil
+}
+
+// decodeField decodes a structured record field into out, leaving out unchanged if
+// the field is missing or malformed.
+func decodeField(rec map[string]any, key string, out any) {
+	if v, ok := rec[key]; ok {
+		if data, err := json.Marshal(v); err == nil {
+			json.Unmarshal(data, out)
+		}
+	}
+}
+
+// recordReader reads byte ranges from log files during one query, keeping the
+// current plain file open and the current compressed segment decompressed.
+type recordReader struct {
+	path  string
+	file  *os.File
+	unzip []byte
+}
+
+func (r *recordReader) ReadAt(path string, offset int64, length int) ([]byte, error) {
+	if path != r.path {
+		r.Close()
+		r.path = path
+		if strings.HasSuffix(path, ".gz") {
+			rc, err := openLogFile(path)
+			if err != nil {
+				return nil, err
+			}
+			r.unzip, err = io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			f, err := os.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			r.file = f
+		}
+	}
+	if r.file == nil {
+		if offset+int64(length) > int64(len(r.unzip)) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return r.unzip[offset : offset+int64(length)], nil
+	}
+	buf := make([]byte, length)
+	if _, err := r.file.ReadAt(buf, offset); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+func (r *recordReader) Close() {
+	if r.file != nil {
+		r.file.Close()
+	}
+	r.path, r.file, r.unzip = "", nil, nil
+}
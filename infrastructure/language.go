@@ -0,0 +1,97 @@
+package infrastructure
+
+import (
+	"strings"
+	"unicode"
+)
+
+// scriptLanguages maps scripts used by essentially one language to its ISO 639-1
+// code. Han is checked after kana so Japanese text with kanji is not taken for Chinese.
+var scriptLanguages = []struct {
+	script *unicode.RangeTable
+	lang   string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Greek, "el"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Thai, "th"},
+}
+
+// stopwords are frequent function words that tell Latin-script languages apart.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "are", "to", "of", "in", "that", "it", "you", "what", "how", "for", "with", "this", "be", "not", "can", "do", "my", "i", "a", "was", "have", "why", "where", "please", "your", "from", "on"},
+	"fr": {"le", "la", "les", "et", "est", "de", "des", "un", "une", "du", "que", "qui", "pour", "dans", "pas", "vous", "je", "ce", "sur", "avec", "au", "mon", "ne", "il", "nous", "pourquoi", "comment", "mais", "très"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "ich", "zu", "mit", "sie", "den", "von", "es", "auf", "wie", "was", "für", "warum", "bitte", "haben", "auch", "oder", "aber", "dem", "des"},
+	"es": {"el", "la", "los", "las", "y", "es", "de", "que", "en", "un", "una", "por", "para", "con", "no", "se", "qué", "cómo", "del", "lo", "mi", "al", "cuál", "pero", "muy", "tengo", "puedo", "está"},
+	"it": {"il", "lo", "la", "gli", "e", "è", "di", "che", "un", "una", "per", "con", "non", "sono", "come", "del", "della", "cosa", "mi", "ho", "questo", "perché", "ma", "anche", "nel", "mio"},
+	"pt": {"o", "a", "os", "as", "e", "é", "de", "que", "um", "uma", "para", "com", "não", "em", "do", "da", "se", "como", "você", "meu", "isso", "ao", "por", "mas", "muito", "tenho", "está", "porque"},
+	"nl": {"de", "het", "een", "en", "is", "van", "dat", "niet", "ik", "je", "op", "te", "met", "voor", "zijn", "wat", "hoe", "mijn", "ook", "waarom", "maar", "heb", "er", "om"},
+}
+
+// detectLanguage guesses the language of text, returning "" when it is too short or
+// too ambiguous to tell. Non-Latin scripts are identified by script; Latin text by
+// counting stopwords, which needs a few words to be reliable.
+func detectLanguage(text string) string {
+	letters := 0
+	counts := make(map[string]int)
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for _, s := range scriptLanguages {
+			if unicode.Is(s.script, r) {
+				counts[s.lang]++
+				break
+			}
+		}
+	}
+	if letters == 0 {
+		return ""
+	}
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"] // kanji
+		counts["zh"] = 0
+	}
+	for _, s := range scriptLanguages {
+		if counts[s.lang]*2 > letters {
+			return s.lang
+		}
+	}
+
+	scores := make(map[string]int)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+	for _, w := range words {
+		for lang, list := range stopwords {
+			for _, s := range list {
+				if w == s {
+					scores[lang]++
+					break
+				}
+			}
+		}
+	}
+	best, second := "", 0
+	for lang, n := range scores {
+		switch {
+		case best == "" || n > scores[best]:
+			if best != "" {
+				second = scores[best]
+			}
+			best = lang
+		case n > second:
+			second = n
+		}
+	}
+	// require a few hits and a clear winner; function words overlap between languages
+	if best == "" || scores[best] < 3 || scores[best]*2 < second*3 || scores[best] == second {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,114 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LogFiles returns the interaction log files in dir, oldest first: rotated
+// segments followed by the active log.jsonl.
+func LogFiles(dir string) []string {
+	files := listSegments(dir, InteractionLogName)
+	active := filepath.Join(dir, InteractionLogName)
+	if _, err := os.Stat(active); err == nil {
+		files = append(files, active)
+	}
+	return files
+}
+
+// openLogFile opens a plain or gzip-compressed JSONL log file for reading.
+func openLogFile(path string) (io.ReadCloser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(path, ".gz") {
+		return f, nil
+	}
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{gz, f}, nil
+}
+
+// ReadLogRecords decodes each line of a log file and passes it to fn. Blank
+// lines are skipped; lines that are not JSON objects are an error.
+func ReadLogRecords(path string, fn func(rec map[string]any) error) error {
+	r, err := openLogFile(path)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	reader := bufio.NewReader(r)
+	lineNo := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			lineNo++
+			var rec map[string]any
+			dec := json.NewDecoder(bytes.NewReader(line))
+			dec.UseNumber()
+			if jsonErr := dec.Decode(&rec); jsonErr != nil {
+				return fmt.Errorf("%s:%d: %w", path, lineNo, jsonErr)
+			}
+			if fnErr := fn(rec); fnErr != nil {
+				return fmt.Errorf("%s:%d: %w", path, lineNo, fnErr)
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// RewriteLogFile applies fn to every record of a log file and atomically replaces
+// the file with the result, keeping gzip compression if the original had it.
+func RewriteLogFile(path string, fn func(rec map[string]any) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	var w io.Writer = tmp
+	var gz *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gz = gzip.NewWriter(tmp)
+		w = gz
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	err = ReadLogRecords(path, func(rec map[string]any) error {
+		if err := fn(rec); err != nil {
+			return err
+		}
+		return enc.Encode(rec)
+	})
+	if err == nil && gz != nil {
+		err = gz.Close()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -1,62 +1,220 @@
 package infrastructure
 
 import (
+	"errors"
+	"io"
+	"minivault/config"
 	"minivault/domain"
 	"os"
+	"time"
 
 	"github.com/rs/zerolog"
 )
 
+// InteractionLogName is the active interaction log file inside the log directory.
+const InteractionLogName = "log.jsonl"
+
 // logger implements Logger using zerolog
 // It logs interactions to file, and other logs to console
 // (You can inject a custom Logger for testing)
 type logger struct {
 	fileLogger    zerolog.Logger
 	consoleLogger zerolog.Logger
+	redactor      domain.RedactorPort
+	vault         domain.VaultPort
+	file          *rotatingFile
+	tenantFiles   map[string]*tenantLog // tenant ID -> that tenant's interaction log
+	stopSignals   func()
 }
 
-func NewLogger() domain.LoggerPort {
-	err := os.MkdirAll("logs", 0755)
-	if err != nil {
-		panic(err)
-	}
+// tenantLog is the interaction log of one tenant.
+type tenantLog struct {
+	fileLogger zerolog.Logger
+	file       *rotatingFile
+}
 
-	var fileLogger, consoleLogger zerolog.Logger
-	logFile, err := os.OpenFile("logs/log.jsonl", os.O_APPEND|os.O_WRONLY, 0644)
-	if os.IsNotExist(err) {
-		logFile, err = os.Create("logs/log.jsonl")
+// NewLogger opens the interaction log. Every message passes through redactor before it is written;
+// if vault is non-nil, interaction prompts and responses are also encrypted. When tenants is
+// non-nil, each tenant's interactions go to a log in the tenant's own directory.
+func NewLogger(cfg *config.Config, redactor domain.RedactorPort, vault domain.VaultPort, tenants domain.TenantsPort) domain.LoggerPort {
+	consoleLogger := zerolog.New(consoleWriter(cfg.LogConsole)).With().Timestamp().Logger()
+	l := &logger{consoleLogger: consoleLogger, redactor: redactor, vault: vault}
+
+	var files []*rotatingFile
+	l.file, l.fileLogger = openInteractionLog(cfg, cfg.LogDir, consoleLogger)
+	if l.file != nil {
+		files = append(files, l.file)
+	}
+	if tenants != nil {
+		l.tenantFiles = make(map[string]*tenantLog)
+		for _, t := range tenants.List() {
+			file, fileLogger := openInteractionLog(cfg, t.LogDir, consoleLogger)
+			l.tenantFiles[t.ID] = &tenantLog{fileLogger: fileLogger, file: file}
+			if file != nil {
+				files = append(files, file)
+			}
+		}
 	}
+	if len(files) == 0 {
+		return l
+	}
+	l.stopSignals = notifyReopen(func() {
+		reopened := true
+		for _, file := range files {
+			if err := file.Reopen(); err != nil {
+				l.LogError("failed to reopen log file", err)
+				reopened = false
+			}
+		}
+		if reopened {
+			l.LogInfo("log file reopened")
+		}
+	})
+	return l
+}
+
+// openInteractionLog opens the rotating interaction log in dir. If that fails, file
+// is nil and records go to stdout instead.
+func openInteractionLog(cfg *config.Config, dir string, consoleLogger zerolog.Logger) (*rotatingFile, zerolog.Logger) {
+	file, err := newRotatingFile(dir, InteractionLogName, RotateOptions{
+		MaxSize:    cfg.LogMaxSizeMB * 1024 * 1024,
+		Interval:   cfg.LogRotateInterval,
+		Compress:   cfg.LogCompress,
+		MaxAge:     cfg.LogMaxAge,
+		MaxBackups: cfg.LogMaxBackups,
+	})
 	if err != nil {
-		consoleLogger = zerolog.New(os.Stdout).With().Timestamp().Logger()
-		fileLogger = zerolog.New(os.Stdout).With().Timestamp().Logger() // fallback: file logs to stdout too
-		consoleLogger.Error().Err(err).Msg("Failed to open log file, file logs redirected to stdout")
-	} else {
-		fileLogger = zerolog.New(logFile).With().Timestamp().Logger()
-		consoleLogger = zerolog.New(os.Stdout).With().Timestamp().Logger()
+		consoleLogger.Error().Err(err).Str("dir", dir).Msg("Failed to open log file, file logs redirected to stdout")
+		return nil, zerolog.New(os.Stdout) // fallback: file logs to stdout too
+	}
+	file.onError = func(message string, err error) {
+		consoleLogger.Error().Err(err).Str("dir", dir).Msg(message)
 	}
-	return &logger{fileLogger: fileLogger, consoleLogger: consoleLogger}
+	return file, zerolog.New(io.Writer(file))
 }
 
-func (l *logger) LogInteraction(prompt, response string) {
-	l.fileLogger.Info().
-		Str("prompt", prompt).
-		Str("response", response).
-		Msg("generation interaction")
+// LogInteraction writes one interaction record. The file record carries everything the
+// interaction store needs to index it; its "time" has nanosecond precision.
+func (l *logger) LogInteraction(interaction domain.Interaction) {
+	prompt, promptCounts := l.redactor.Redact(interaction.Prompt)
+	response, responseCounts := l.redactor.Redact(interaction.Response)
+	redactions := mergeCounts(promptCounts, responseCounts)
+
+	var keyID string
+	if l.vault != nil {
+		var err error
+		if prompt, keyID, err = l.vault.Seal("prompt", prompt); err == nil {
+			response, _, err = l.vault.Seal("response", response)
+		}
+		if err != nil {
+			// never fall back to plaintext when encryption is configured
+			l.LogError("failed to encrypt interaction, record dropped", err)
+			return
+		}
+	}
 
-	l.consoleLogger.Info().
-		Str("prompt", prompt).
-		Str("response", response).
-		Msg("generation interaction")
+	fileLogger := l.fileLogger
+	if t, ok := l.tenantFiles[interaction.Tenant]; ok {
+		fileLogger = t.fileLogger
+	}
+	for _, event := range []*zerolog.Event{
+		fileLogger.Info().Str(zerolog.TimestampFieldName, interaction.Time.UTC().Format(time.RFC3339Nano)),
+		l.consoleLogger.Info(),
+	} {
+		event = event.
+			Str("id", interaction.ID).
+			Str("kind", interaction.Kind).
+			Str("request_id", interaction.RequestID).
+			Str("model", interaction.Model).
+			Str("api_key_id", interaction.APIKeyID).
+			Str("prompt", prompt).
+			Str("response", response).
+			Int64("latency_ms", interaction.LatencyMS)
+		if interaction.Tenant != "" {
+			event = event.Str("tenant", interaction.Tenant)
+		}
+		if keyID != "" {
+			event = event.Str("enc", VaultAlgorithm).Str("key_id", keyID)
+		}
+		if len(redactions) > 0 {
+			event = event.Interface("redactions", redactions)
+		}
+		if interaction.Usage != nil {
+			event = event.Interface("usage", interaction.Usage)
+		}
+		if len(interaction.Policies) > 0 {
+			event = event.Interface("policies", interaction.Policies)
+		}
+		if len(interaction.Filters) > 0 {
+			event = event.Interface("filters", interaction.Filters)
+		}
+		if interaction.Kind == domain.InteractionKindEmbed {
+			event.Msg("embedding interaction")
+		} else {
+			event.Msg("generation interaction")
+		}
+	}
+}
+
+// consoleWriter maps the LOG_CONSOLE setting to a writer; anything unrecognised means stdout.
+func consoleWriter(target string) io.Writer {
+	switch target {
+	case "stderr":
+		return os.Stderr
+	case "none":
+		return io.Discard
+	default:
+		return os.Stdout
+	}
 }
 
 func (l *logger) LogError(message string, err error) {
-	l.consoleLogger.Error().Err(err).Msg(message)
+	event := l.consoleLogger.Error()
+	if err != nil {
+		event = event.Str(zerolog.ErrorFieldName, l.redact(err.Error()))
+	}
+	event.Msg(l.redact(message))
 }
 
 func (l *logger) LogWarn(message string) {
-	l.consoleLogger.Warn().Msg(message)
+	l.consoleLogger.Warn().Msg(l.redact(message))
 }
 
 func (l *logger) LogInfo(message string) {
-	l.consoleLogger.Info().Msg(message)
+	l.consoleLogger.Info().Msg(l.redact(message))
+}
+
+func (l *logger) redact(s string) string {
+	s, _ = l.redactor.Redact(s)
+	return s
+}
+
+func mergeCounts(a, b map[string]int) map[string]int {
+	if len(b) == 0 {
+		return a
+	}
+	if a == nil {
+		return b
+	}
+	for k, v := range b {
+		a[k] += v
+	}
+	return a
+}
+
+// Close stops listening for reopen signals and flushes and closes the interaction logs.
+func (l *logger) Close() error {
+	if l.stopSignals != nil {
+		l.stopSignals()
+	}
+	var errs []error
+	if l.file != nil {
+		errs = append(errs, l.file.Close())
+	}
+	for _, t := range l.tenantFiles {
+		if t.file != nil {
+			errs = append(errs, t.file.Close())
+		}
+	}
+	return errors.Join(errs...)
 }
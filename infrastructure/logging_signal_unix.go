@@ -0,0 +1,32 @@
+//go:build !windows
+
+package infrastructure
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// notifyReopen calls reopen whenever the process receives SIGUSR1, so external
+// logrotate can move the log aside and ask MiniVault to start a new file.
+// The returned function stops listening.
+func notifyReopen(reopen func()) func() {
+	sigs := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sigs, syscall.SIGUSR1)
+	go func() {
+		for {
+			select {
+			case <-sigs:
+				reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package infrastructure
+
+// notifyReopen is a no-op on Windows, which has no SIGUSR1.
+func notifyReopen(reopen func()) func() {
+	return func() {}
+}
@@ -1,13 +1,18 @@
 package infrastructure
 
 import (
-	"testing"
+	"minivault/config"
+	"minivault/domain"
 	"minivault/mocks"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
 )
 
 func TestLogger_LogInteraction(t *testing.T) {
 	mockLog := &mocks.MockLogger{}
-	mockLog.LogInteraction("prompt", "resp")
+	mockLog.LogInteraction(domain.Interaction{Prompt: "prompt", Response: "resp"})
 	if len(mockLog.Interactions) != 1 {
 		t.Error("Interaction not logged")
 	}
@@ -36,8 +41,8 @@ func TestLogger_LogWarnAndInfo(t *testing.T) {
 
 func TestLogger_MultipleLogs(t *testing.T) {
 	mockLog := &mocks.MockLogger{}
-	mockLog.LogInteraction("p1", "r1")
-	mockLog.LogInteraction("p2", "r2")
+	mockLog.LogInteraction(domain.Interaction{Prompt: "p1", Response: "r1"})
+	mockLog.LogInteraction(domain.Interaction{Prompt: "p2", Response: "r2"})
 	mockLog.LogError("err1", nil)
 	mockLog.LogWarn("warn")
 	mockLog.LogInfo("info")
@@ -45,3 +50,24 @@ func TestLogger_MultipleLogs(t *testing.T) {
 		t.Error("Multiple logs not handled correctly")
 	}
 }
+
+func TestLogger_RedactsInteractionFile(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLogger(&config.Config{LogDir: dir}, &mocks.MockRedactor{Secret: "hunter2"}, nil, nil)
+	l.LogInteraction(domain.Interaction{ID: "i1", Prompt: "my password is hunter2", Response: "noted: hunter2"})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, InteractionLogName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	line := string(data)
+	if strings.Contains(line, "hunter2") {
+		t.Errorf("secret leaked into log: %s", line)
+	}
+	if !strings.Contains(line, `"redactions":{"SECRET":2}`) {
+		t.Errorf("expected redaction counts in record: %s", line)
+	}
+}
@@ -0,0 +1,104 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"minivault/domain"
+	"net/http"
+	"strings"
+)
+
+// minivaultClient implements domain.GeneratorPort against the /generate endpoint
+// of a remote MiniVault server, for the command-line clients.
+type minivaultClient struct {
+	httpClient *http.Client
+	url        string
+	apiKey     string
+}
+
+// NewMiniVaultClient returns a generator that calls the server at baseURL, sending
+// apiKey if it is not empty.
+func NewMiniVaultClient(baseURL, apiKey string) domain.GeneratorPort {
+	return &minivaultClient{
+		httpClient: &http.Client{},
+		url:        strings.TrimSuffix(baseURL, "/") + "/generate",
+		apiKey:     apiKey,
+	}
+}
+
+// Generate implements GeneratorPort. With a domain.TokenSink in ctx the request is
+// streamed and tokens are passed to the sink as they arrive. Failures the server
+// reports are returned as *domain.RemoteError; a server that cannot be reached
+// wraps ErrUpstreamUnavailable or ErrUpstreamTimeout.
+func (c *minivaultClient) Generate(ctx context.Context, req domain.GenerateRequest) (*domain.GenerateResponse, error) {
+	sink := domain.TokenSinkFromContext(ctx)
+	req.Stream = sink != nil
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal generate request: %w", err)
+	}
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new HTTP request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		request.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	resp, err := c.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", transportFailure(err), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, remoteError(resp)
+	}
+	if sink == nil {
+		var out domain.GenerateResponse
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, fmt.Errorf("failed to decode generate response: %w", err)
+		}
+		return &out, nil
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var event domain.GenerateStreamEvent
+		if err := dec.Decode(&event); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, fmt.Errorf("generate stream interrupted: %w", err)
+		}
+		switch event.Type {
+		case domain.StreamEventToken:
+			sink(event.Token)
+		case domain.StreamEventDone:
+			if event.Response == nil {
+				return nil, errors.New("generate stream ended without a response")
+			}
+			return event.Response, nil
+		case domain.StreamEventError:
+			return nil, &domain.RemoteError{StatusCode: event.Status, Message: event.Error}
+		}
+	}
+}
+
+// remoteError reads the server's error message, which is either plain text or a
+// JSON object with an "error" field.
+func remoteError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	msg := strings.TrimSpace(string(body))
+	var structured struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &structured) == nil && structured.Error != "" {
+		msg = structured.Error
+	}
+	return &domain.RemoteError{StatusCode: resp.StatusCode, Message: msg}
+}
@@ -0,0 +1,61 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"minivault/domain"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiniVaultClient(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key-a" {
+			http.Error(w, "Unauthorized: missing or invalid API key", http.StatusUnauthorized)
+			return
+		}
+		var req domain.GenerateRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		enc := json.NewEncoder(w)
+		switch {
+		case req.Prompt == "quota":
+			w.WriteHeader(http.StatusTooManyRequests)
+			enc.Encode(domain.QuotaErrorResponse{Error: "Quota exceeded"})
+		case req.Stream:
+			enc.Encode(domain.GenerateStreamEvent{Type: domain.StreamEventToken, Token: "Hel"})
+			enc.Encode(domain.GenerateStreamEvent{Type: domain.StreamEventToken, Token: "lo"})
+			enc.Encode(domain.GenerateStreamEvent{Type: domain.StreamEventDone, Response: &domain.GenerateResponse{Response: "Hello", Model: req.Model}})
+		default:
+			enc.Encode(domain.GenerateResponse{Response: "Hello"})
+		}
+	}))
+	defer srv.Close()
+
+	client := NewMiniVaultClient(srv.URL+"/", "key-a")
+	resp, err := client.Generate(context.Background(), domain.GenerateRequest{Prompt: "hi"})
+	if err != nil || resp.Response != "Hello" {
+		t.Fatalf("unexpected response: %+v %v", resp, err)
+	}
+	var tokens []string
+	ctx := domain.WithTokenSink(context.Background(), func(token string) { tokens = append(tokens, token) })
+	resp, err = client.Generate(ctx, domain.GenerateRequest{Prompt: "hi", Model: "gemma:2b"})
+	if err != nil || len(tokens) != 2 || resp.Model != "gemma:2b" {
+		t.Errorf("unexpected stream: %q %+v %v", tokens, resp, err)
+	}
+
+	var remote *domain.RemoteError
+	if _, err := client.Generate(context.Background(), domain.GenerateRequest{Prompt: "quota"}); !errors.As(err, &remote) ||
+		remote.StatusCode != http.StatusTooManyRequests || remote.Message != "Quota exceeded" {
+		t.Errorf("expected the server's JSON error, got %v", err)
+	}
+	if _, err := NewMiniVaultClient(srv.URL, "wrong").Generate(context.Background(), domain.GenerateRequest{Prompt: "hi"}); !errors.As(err, &remote) ||
+		remote.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401, got %v", err)
+	}
+	srv.Close()
+	if _, err := client.Generate(context.Background(), domain.GenerateRequest{Prompt: "hi"}); !errors.Is(err, domain.ErrUpstreamUnavailable) {
+		t.Errorf("expected an unreachable server to be unavailable, got %v", err)
+	}
+}
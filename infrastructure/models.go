@@ -0,0 +1,160 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"minivault/config"
+	"minivault/domain"
+	"net/http"
+	"time"
+)
+
+// modelRequestTimeout bounds the quick management calls; pulls are bounded only by ctx.
+const modelRequestTimeout = 30 * time.Second
+
+type modelManager struct {
+	httpClient *http.Client
+	apiURL     string
+}
+
+func NewModelManager(cfg *config.Config) domain.ModelManagerPort {
+	// no client timeout: a pull can take as long as the download does
+	return &modelManager{httpClient: &http.Client{}, apiURL: cfg.OllamaAPIURL}
+}
+
+// ListModels calls /api/tags (implements domain.ModelManagerPort).
+func (m *modelManager) ListModels(ctx context.Context) ([]domain.ModelSummary, error) {
+	var out struct {
+		Models []domain.ModelSummary `json:"models"`
+	}
+	if err := m.call(ctx, http.MethodGet, "/tags", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Models, nil
+}
+
+// ShowModel calls /api/show (implements domain.ModelManagerPort).
+func (m *modelManager) ShowModel(ctx context.Context, name string) (domain.ModelInfo, error) {
+	var out json.RawMessage
+	if err := m.call(ctx, http.MethodPost, "/show", map[string]string{"model": name}, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// DeleteModel calls /api/delete (implements domain.ModelManagerPort).
+func (m *modelManager) DeleteModel(ctx context.Context, name string) error {
+	return m.call(ctx, http.MethodDelete, "/delete", map[string]string{"model": name}, nil)
+}
+
+// RunningModels calls /api/ps (implements domain.ModelManagerPort).
+func (m *modelManager) RunningModels(ctx context.Context) ([]domain.RunningModel, error) {
+	var out struct {
+		Models []domain.RunningModel `json:"models"`
+	}
+	if err := m.call(ctx, http.MethodGet, "/ps", nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Models, nil
+}
+
+// LoadModel sends /api/generate with no prompt, which only loads the model
+// (implements domain.ModelManagerPort). Loading is bounded by ctx alone, since a
+// large model can take longer than the management timeout.
+func (m *modelManager) LoadModel(ctx context.Context, name string, keepAlive domain.KeepAlive) error {
+	resp, err := m.do(ctx, http.MethodPost, "/generate", domain.OllamaLoadRequest{Model: name, KeepAlive: keepAlive})
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PullModel calls /api/pull with streaming enabled and reports each NDJSON progress
+// line (implements domain.ModelManagerPort). Ollama reports failures mid-stream as
+// {"error": "..."} lines, which become the returned error.
+func (m *modelManager) PullModel(ctx context.Context, name string, progress func(domain.PullProgress)) error {
+	resp, err := m.do(ctx, http.MethodPost, "/pull", map[string]any{"model": name, "stream": true})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var line struct {
+			domain.PullProgress
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("failed to decode pull progress: %w", err)
+		}
+		if line.Error != "" {
+			return fmt.Errorf("pull failed: %s", line.Error)
+		}
+		progress(line.PullProgress)
+		if line.Status == "success" {
+			return nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read pull progress: %w", err)
+	}
+	return errors.New("pull ended without success")
+}
+
+// call performs a short request and decodes the JSON response into out (if not nil).
+func (m *modelManager) call(ctx context.Context, method, path string, body any, out any) error {
+	ctx, cancel := context.WithTimeout(ctx, modelRequestTimeout)
+	defer cancel()
+	resp, err := m.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to unmarshal %s response: %w", path, err)
+	}
+	return nil
+}
+
+// do sends a request and checks the status; the caller closes the body on success.
+func (m *modelManager) do(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %s request: %w", path, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	request, err := http.NewRequestWithContext(ctx, method, m.apiURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new HTTP request: %w", err)
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := m.httpClient.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to perform HTTP request: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, domain.ErrModelNotFound
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(msg))
+	}
+	return resp, nil
+}
@@ -0,0 +1,92 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"minivault/domain"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func newTestModelManager(rt http.RoundTripper) *modelManager {
+	return &modelManager{httpClient: &http.Client{Transport: rt}, apiURL: "http://ollama/api"}
+}
+
+func TestModelManager_ListModels(t *testing.T) {
+	m := newTestModelManager(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/api/tags" || r.Method != http.MethodGet {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		body := `{"models":[{"name":"gemma:2b","size":1678447520,"details":{"family":"gemma","parameter_size":"3B"}}]}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+	models, err := m.ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 1 || models[0].Name != "gemma:2b" || models[0].Details.ParameterSize != "3B" {
+		t.Errorf("unexpected models: %+v", models)
+	}
+}
+
+func TestModelManager_NotFound(t *testing.T) {
+	m := newTestModelManager(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader(`{"error":"model not found"}`))}, nil
+	}))
+	if err := m.DeleteModel(context.Background(), "missing"); !errors.Is(err, domain.ErrModelNotFound) {
+		t.Errorf("expected ErrModelNotFound, got %v", err)
+	}
+}
+
+func TestModelManager_PullProgress(t *testing.T) {
+	m := newTestModelManager(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"status":"pulling manifest"}
+{"status":"downloading","digest":"sha256:abc","total":10,"completed":5}
+{"status":"success"}
+`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+	var got []domain.PullProgress
+	if err := m.PullModel(context.Background(), "gemma:2b", func(p domain.PullProgress) { got = append(got, p) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[1].Completed != 5 || got[2].Status != "success" {
+		t.Errorf("unexpected progress: %+v", got)
+	}
+}
+
+func TestModelManager_PullStreamError(t *testing.T) {
+	m := newTestModelManager(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"status":"pulling manifest"}
+{"error":"pull model manifest: file does not exist"}
+`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+	err := m.PullModel(context.Background(), "nope", func(domain.PullProgress) {})
+	if err == nil || !strings.Contains(err.Error(), "file does not exist") {
+		t.Errorf("expected stream error, got %v", err)
+	}
+}
+
+func TestModelManager_LoadModel(t *testing.T) {
+	var sent map[string]any
+	m := newTestModelManager(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/api/generate" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"done":true,"done_reason":"load"}`))}, nil
+	}))
+	if err := m.LoadModel(context.Background(), "gemma:2b", "-1"); err != nil {
+		t.Fatal(err)
+	}
+	if sent["model"] != "gemma:2b" || sent["keep_alive"] != float64(-1) || sent["stream"] != false {
+		t.Errorf("unexpected load request: %v", sent)
+	}
+	if _, ok := sent["prompt"]; ok {
+		t.Error("a load request must not carry a prompt")
+	}
+}
@@ -2,12 +2,16 @@ package infrastructure
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"minivault/config"
 	"minivault/domain"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -15,60 +19,143 @@ type ollamaClient struct {
 	httpClient  *http.Client
 	ollamaURL   string
 	ollamaModel string
+	keepAlive   domain.KeepAlive
+	timeout     time.Duration // applies when the caller's context has no deadline
 }
 
 func NewOllamaClient(cfg *config.Config) domain.OllamaPort {
+	return newOllamaClient(cfg.OllamaURL, cfg.OllamaModel, cfg.OllamaKeepAlive, cfg.OllamaTimeout)
+}
+
+func newOllamaClient(url, model, keepAlive string, timeout time.Duration) *ollamaClient {
 	return &ollamaClient{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		ollamaURL:   cfg.OllamaURL,
-		ollamaModel: cfg.OllamaModel,
+		httpClient:  &http.Client{},
+		ollamaURL:   url,
+		ollamaModel: model,
+		keepAlive:   domain.KeepAlive(keepAlive),
+		timeout:     timeout,
 	}
 }
 
-// CallOllama performs a non-streaming chat request (implements domain.OllamaPort)
-func (c *ollamaClient) CallOllama(prompt string) (string, error) {
-	chatReq := domain.OllamaChatRequest{
-		Model: c.ollamaModel,
-		Messages: []domain.OllamaChatMessage{{
-			Role:    "user",
-			Content: prompt,
-		}},
-		Stream: false,
+// CallOllama performs a chat request (implements domain.OllamaPort). When ctx has a
+// domain.TokenSink, the reply is streamed to it and still returned whole. An empty req.Model uses the configured default model, and an empty KeepAlive the
+// configured keep-alive. A deadline already on ctx replaces the configured timeout,
+// which lets fallback steps have their own. Failures wrap ErrUpstreamTimeout,
+// ErrUpstreamUnavailable, ErrUpstreamRejected or ErrModelNotFound so callers can
+// decide whether to fall back.
+func (c *ollamaClient) CallOllama(ctx context.Context, chatReq domain.OllamaChatRequest) (*domain.OllamaChatResponse, error) {
+	if chatReq.Model == "" {
+		chatReq.Model = c.ollamaModel
+	}
+	if chatReq.KeepAlive == "" {
+		chatReq.KeepAlive = c.keepAlive
+	}
+	if _, ok := ctx.Deadline(); !ok && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
 	}
+	sink := domain.TokenSinkFromContext(ctx)
+	chatReq.Stream = sink != nil
 	chatData, err := json.Marshal(chatReq)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal chat request: %w", err)
+		return nil, fmt.Errorf("failed to marshal chat request: %w", err)
 	}
 
-	request, err := http.NewRequest("POST", c.ollamaURL, bytes.NewReader(chatData))
+	request, err := http.NewRequestWithContext(ctx, "POST", c.ollamaURL, bytes.NewReader(chatData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create new HTTP request: %w", err)
+		return nil, fmt.Errorf("failed to create new HTTP request: %w", err)
 	}
 
 	request.Header.Set("Content-Type", "application/json")
 	resp, err := c.httpClient.Do(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to perform HTTP request: %w", err)
+		return nil, fmt.Errorf("%w: failed to perform HTTP request: %w", transportFailure(err), err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return "", fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("%w: ollama API returned status %d: %s", statusFailure(resp.StatusCode), resp.StatusCode, string(body))
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read HTTP response body: %w", err)
+	var chatResp domain.OllamaChatResponse
+	if sink != nil {
+		if err := readChatStream(resp.Body, sink, &chatResp); err != nil {
+			return nil, err
+		}
+	} else {
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read HTTP response body: %w", err)
+		}
+		if err := json.Unmarshal(body, &chatResp); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal chat response: %w", err)
+		}
+	}
+	if chatResp.Model == "" {
+		chatResp.Model = chatReq.Model
 	}
 
-	var chatResp domain.OllamaChatResponse
-	err = json.Unmarshal(body, &chatResp)
-	if err != nil {
-		return "", fmt.Errorf("failed to unmarshal chat response: %w", err)
+	return &chatResp, nil
+}
+
+// readChatStream reads Ollama's NDJSON chat stream, passing each piece of content
+// to sink and assembling the whole reply in chatResp. The last chunk carries the
+// counts and timings. Failures once text has been sent are not classified, so the
+// request is not retried elsewhere with the text repeated; timeouts still are.
+func readChatStream(body io.Reader, sink domain.TokenSink, chatResp *domain.OllamaChatResponse) error {
+	dec := json.NewDecoder(body)
+	var content strings.Builder
+	var toolCalls []domain.ToolCall
+	for {
+		var chunk struct {
+			domain.OllamaChatResponse
+			Done  bool   `json:"done"`
+			Error string `json:"error"`
+		}
+		if err := dec.Decode(&chunk); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return fmt.Errorf("%w: chat stream interrupted: %w", domain.ErrUpstreamTimeout, err)
+			}
+			return fmt.Errorf("chat stream interrupted: %w", err)
+		}
+		if chunk.Error != "" {
+			return fmt.Errorf("ollama reported an error mid-stream: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			sink(chunk.Message.Content)
+		}
+		toolCalls = append(toolCalls, chunk.Message.ToolCalls...)
+		if chunk.Done {
+			*chatResp = chunk.OllamaChatResponse
+			chatResp.Message.Role = "assistant"
+			chatResp.Message.Content = content.String()
+			chatResp.Message.ToolCalls = toolCalls
+			return nil
+		}
+	}
+}
+
+// transportFailure classifies an error from http.Client.Do.
+func transportFailure(err error) error {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return domain.ErrUpstreamTimeout
 	}
+	return domain.ErrUpstreamUnavailable
+}
 
-	return chatResp.Message.Content, nil
+// statusFailure classifies a non-2xx Ollama status. Ollama answers 404 for models
+// that are not installed.
+func statusFailure(code int) error {
+	switch {
+	case code == http.StatusNotFound:
+		return domain.ErrModelNotFound
+	case code >= 500:
+		return domain.ErrUpstreamUnavailable
+	default:
+		return domain.ErrUpstreamRejected
+	}
 }
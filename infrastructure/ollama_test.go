@@ -1,12 +1,16 @@
 package infrastructure
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"io"
+	"minivault/domain"
 	"minivault/mocks"
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 )
 
 // --- Real ollamaClient error handling tests ---
@@ -21,11 +25,15 @@ func newTestOllamaClient(rt http.RoundTripper) *ollamaClient {
 	return &ollamaClient{httpClient: &http.Client{Transport: rt}}
 }
 
+func chatRequest(prompt string) domain.OllamaChatRequest {
+	return domain.OllamaChatRequest{Messages: []domain.OllamaChatMessage{{Role: "user", Content: prompt}}}
+}
+
 func TestOllamaClient_HTTPError(t *testing.T) {
 	c := newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
 		return nil, errors.New("network fail")
 	}))
-	_, err := c.CallOllama("foo")
+	_, err := c.CallOllama(context.Background(), chatRequest("foo"))
 	if err == nil || !strings.Contains(err.Error(), "network fail") {
 		t.Error("expected network error")
 	}
@@ -36,7 +44,7 @@ func TestOllamaClient_Non2xxStatus(t *testing.T) {
 	c := newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{StatusCode: 500, Body: respBody}, nil
 	}))
-	_, err := c.CallOllama("foo")
+	_, err := c.CallOllama(context.Background(), chatRequest("foo"))
 	if err == nil || !strings.Contains(err.Error(), "ollama API returned status 500") {
 		t.Error("expected API status error")
 	}
@@ -47,7 +55,7 @@ func TestOllamaClient_InvalidJSON(t *testing.T) {
 	c := newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{StatusCode: 200, Body: respBody}, nil
 	}))
-	_, err := c.CallOllama("foo")
+	_, err := c.CallOllama(context.Background(), chatRequest("foo"))
 	if err == nil || !strings.Contains(err.Error(), "unmarshal") {
 		t.Error("expected unmarshal error")
 	}
@@ -58,7 +66,7 @@ func TestOllamaClient_ReadBodyError(t *testing.T) {
 	c := newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
 		return &http.Response{StatusCode: 200, Body: badBody}, nil
 	}))
-	_, err := c.CallOllama("foo")
+	_, err := c.CallOllama(context.Background(), chatRequest("foo"))
 	if err == nil || !strings.Contains(err.Error(), "failed to read HTTP response body") {
 		t.Error("expected read body error")
 	}
@@ -69,18 +77,38 @@ type badReader struct{}
 func (badReader) Read([]byte) (int, error) { return 0, io.ErrUnexpectedEOF }
 func (badReader) Close() error             { return nil }
 
+func TestOllamaClient_DecodesUsage(t *testing.T) {
+	c := newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"model":"gemma:2b","message":{"role":"assistant","content":"hi"},"done":true,"done_reason":"length",
+			"total_duration":1500000000,"load_duration":250000000,"prompt_eval_count":12,"prompt_eval_duration":100000000,
+			"eval_count":64,"eval_duration":800000000}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+	resp, err := c.CallOllama(context.Background(), chatRequest("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var u domain.Usage
+	u.Add(resp)
+	want := domain.Usage{PromptTokens: 12, CompletionTokens: 64, TotalTokens: 76, ModelCalls: 1, TotalMS: 1500, LoadMS: 250,
+		PromptEvalMS: 100, EvalMS: 800, TokensPerSecond: 80, DoneReason: "length"}
+	if u != want {
+		t.Errorf("usage = %+v, want %+v", u, want)
+	}
+}
+
 func TestOllamaClient_CallOllama(t *testing.T) {
 	// This test uses the mock, not the real HTTP call
 	mock := &mocks.MockOllama{Response: "hi", Error: nil}
-	resp, err := mock.CallOllama("hello")
-	if err != nil || resp != "hi" {
+	resp, err := mock.CallOllama(context.Background(), chatRequest("hello"))
+	if err != nil || resp.Message.Content != "hi" {
 		t.Errorf("unexpected: %v %v", resp, err)
 	}
 }
 
 func TestOllamaClient_LastPrompt(t *testing.T) {
 	mock := &mocks.MockOllama{Response: "foo"}
-	mock.CallOllama("abc")
+	mock.CallOllama(context.Background(), chatRequest("abc"))
 	if mock.LastPrompt != "abc" {
 		t.Errorf("LastPrompt not recorded")
 	}
@@ -89,8 +117,8 @@ func TestOllamaClient_LastPrompt(t *testing.T) {
 func TestOllamaClient_MultipleCalls(t *testing.T) {
 	mock := &mocks.MockOllama{Response: "bar"}
 	for i := 0; i < 3; i++ {
-		resp, err := mock.CallOllama("x")
-		if err != nil || resp != "bar" {
+		resp, err := mock.CallOllama(context.Background(), chatRequest("x"))
+		if err != nil || resp.Message.Content != "bar" {
 			t.Errorf("unexpected: %v %v", resp, err)
 		}
 	}
@@ -98,8 +126,105 @@ func TestOllamaClient_MultipleCalls(t *testing.T) {
 
 func TestOllamaClient_CallOllama_Error(t *testing.T) {
 	mock := &mocks.MockOllama{Response: "", Error: errors.New("fail")}
-	resp, err := mock.CallOllama("fail")
-	if err == nil || resp != "" {
+	resp, err := mock.CallOllama(context.Background(), chatRequest("fail"))
+	if err == nil || resp != nil {
 		t.Errorf("expected error, got %v %v", resp, err)
 	}
 }
+
+func TestOllamaClient_ToolCallsRoundTrip(t *testing.T) {
+	var sent domain.OllamaChatRequest
+	c := newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		body := `{"model":"m","message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"clock","arguments":{"timezone":"UTC"}}}]}}`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+	req := chatRequest("time?")
+	req.Tools = []domain.Tool{clockTool}
+	resp, err := c.CallOllama(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sent.Tools) != 1 || sent.Tools[0].Function.Name != "clock" {
+		t.Errorf("tools not sent: %+v", sent.Tools)
+	}
+	calls := resp.Message.ToolCalls
+	if len(calls) != 1 || calls[0].Function.Name != "clock" || calls[0].Function.Arguments["timezone"] != "UTC" {
+		t.Errorf("tool calls not decoded: %+v", calls)
+	}
+}
+
+func TestOllamaClient_KeepAlive(t *testing.T) {
+	var sent []map[string]any
+	c := newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = append(sent, body)
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`{"message":{"content":"ok"}}`))}, nil
+	}))
+	c.CallOllama(context.Background(), chatRequest("no keep-alive configured"))
+	c.keepAlive = "30m"
+	c.CallOllama(context.Background(), chatRequest("configured"))
+	c.keepAlive = "-1"
+	c.CallOllama(context.Background(), chatRequest("forever"))
+
+	if _, ok := sent[0]["keep_alive"]; ok {
+		t.Errorf("keep_alive should be omitted when unset: %v", sent[0])
+	}
+	if sent[1]["keep_alive"] != "30m" || sent[2]["keep_alive"] != float64(-1) {
+		t.Errorf("unexpected keep_alive values: %v, %v", sent[1]["keep_alive"], sent[2]["keep_alive"])
+	}
+}
+
+func TestOllamaClient_ClassifiesFailures(t *testing.T) {
+	for status, want := range map[int]error{
+		404: domain.ErrModelNotFound,
+		503: domain.ErrUpstreamUnavailable,
+		400: domain.ErrUpstreamRejected,
+	} {
+		c := newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader("nope"))}, nil
+		}))
+		if _, err := c.CallOllama(context.Background(), chatRequest("foo")); !errors.Is(err, want) {
+			t.Errorf("status %d: expected %v, got %v", status, want, err)
+		}
+	}
+
+	c := newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	}))
+	c.timeout = 10 * time.Millisecond
+	if _, err := c.CallOllama(context.Background(), chatRequest("foo")); !errors.Is(err, domain.ErrUpstreamTimeout) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
+
+func TestOllamaClient_Stream(t *testing.T) {
+	var sent domain.OllamaChatRequest
+	c := newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		body := `{"model":"gemma:2b","message":{"role":"assistant","content":"Hel"},"done":false}
+{"model":"gemma:2b","message":{"role":"assistant","content":"lo"},"done":false}
+{"model":"gemma:2b","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","eval_count":2}
+`
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+	var tokens []string
+	ctx := domain.WithTokenSink(context.Background(), func(token string) { tokens = append(tokens, token) })
+	resp, err := c.CallOllama(ctx, chatRequest("hi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sent.Stream || len(tokens) != 2 || resp.Message.Content != "Hello" || resp.EvalCount != 2 || resp.DoneReason != "stop" {
+		t.Errorf("unexpected stream result: %v %q %+v", sent.Stream, tokens, resp)
+	}
+
+	c = newTestOllamaClient(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		body := `{"message":{"role":"assistant","content":"Hel"},"done":false}` + "\n"
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+	if _, err := c.CallOllama(ctx, chatRequest("hi")); err == nil || errors.Is(err, domain.ErrUpstreamUnavailable) {
+		t.Errorf("a stream cut short should fail without inviting a retry, got %v", err)
+	}
+}
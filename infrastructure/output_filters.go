@@ -0,0 +1,381 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// outputStage is one filter's state for one response. process receives the
+// response's text in order and returns what may pass to the next filter; final
+// marks the last call, after which nothing may be held back.
+type outputStage interface {
+	process(text string, final bool) (string, error)
+	// result reports what the filter did, or nil if it left the response alone.
+	result() *domain.FilterResult
+}
+
+// outputFilters implements domain.OutputFilterPort. Each filter is a stage that
+// works incrementally, so a whole response is filtered as a stream of one chunk.
+type outputFilters struct {
+	stages []func() outputStage
+}
+
+// errDenied is returned by the deny stage; the chain turns it into a *domain.FilterError.
+var errDenied = errors.New("deny rule matched")
+
+// NewOutputFilters builds the chain from OUTPUT_FILTERS, in order. Returns nil when
+// no filters are configured.
+func NewOutputFilters(cfg *config.Config) (domain.OutputFilterPort, error) {
+	if len(cfg.OutputFilters) == 0 {
+		return nil, nil
+	}
+	f := &outputFilters{}
+	for _, id := range cfg.OutputFilters {
+		switch id {
+		case "think":
+			f.stages = append(f.stages, func() outputStage { return &thinkStage{} })
+		case "secrets":
+			rules := secretRules()
+			f.stages = append(f.stages, func() outputStage { return &secretsStage{redactor: redactor{rules: rules}} })
+		case "deny":
+			if cfg.OutputDenyFile == "" {
+				return nil, fmt.Errorf("the deny output filter needs OUTPUT_DENY_FILE")
+			}
+			entries, err := loadBlocklist(cfg.OutputDenyFile, "output deny list")
+			if err != nil {
+				return nil, err
+			}
+			f.stages = append(f.stages, func() outputStage { return &denyStage{entries: entries} })
+		case "max_length":
+			limit := cfg.OutputMaxChars
+			f.stages = append(f.stages, func() outputStage { return &lengthStage{limit: limit} })
+		case "whitespace":
+			f.stages = append(f.stages, func() outputStage { return &whitespaceStage{} })
+		default:
+			return nil, fmt.Errorf("unknown output filter %q", id)
+		}
+	}
+	return f, nil
+}
+
+// Filter implements OutputFilterPort
+func (f *outputFilters) Filter(text string) (string, []domain.FilterResult, error) {
+	s := f.Stream()
+	head, err := s.Write(text)
+	if err != nil {
+		return "", nil, err
+	}
+	tail, results, err := s.Close()
+	if err != nil {
+		return "", nil, err
+	}
+	return head + tail, results, nil
+}
+
+// Stream implements OutputFilterPort
+func (f *outputFilters) Stream() domain.OutputStreamPort {
+	s := &outputStream{}
+	for _, newStage := range f.stages {
+		s.stages = append(s.stages, newStage())
+	}
+	return s
+}
+
+type outputStream struct {
+	stages []outputStage
+}
+
+// Write implements OutputStreamPort
+func (s *outputStream) Write(chunk string) (string, error) {
+	for _, stage := range s.stages {
+		if chunk == "" {
+			break
+		}
+		var err error
+		if chunk, err = stage.process(chunk, false); err != nil {
+			return "", &domain.FilterError{Results: s.results()}
+		}
+	}
+	return chunk, nil
+}
+
+// Close implements OutputStreamPort
+func (s *outputStream) Close() (string, []domain.FilterResult, error) {
+	text := ""
+	for _, stage := range s.stages {
+		var err error
+		if text, err = stage.process(text, true); err != nil {
+			return "", nil, &domain.FilterError{Results: s.results()}
+		}
+	}
+	return text, s.results(), nil
+}
+
+func (s *outputStream) results() []domain.FilterResult {
+	var results []domain.FilterResult
+	for _, stage := range s.stages {
+		if r := stage.result(); r != nil {
+			results = append(results, *r)
+		}
+	}
+	return results
+}
+
+const (
+	thinkOpen  = "<think>"
+	thinkClose = "</think>"
+)
+
+// thinkStage removes <think>...</think> reasoning blocks and the whitespace after
+// them. An unterminated block runs to the end of the response.
+type thinkStage struct {
+	pending   string
+	inside    bool
+	trimSpace bool
+	blocks    int
+}
+
+func (s *thinkStage) process(text string, final bool) (string, error) {
+	s.pending += text
+	var out strings.Builder
+	for {
+		if s.inside {
+			i := strings.Index(s.pending, thinkClose)
+			if i < 0 {
+				break
+			}
+			s.pending = s.pending[i+len(thinkClose):]
+			s.inside, s.trimSpace = false, true
+		}
+		if s.trimSpace {
+			s.pending = strings.TrimLeftFunc(s.pending, unicode.IsSpace)
+			if s.pending == "" {
+				break // more whitespace may follow
+			}
+			s.trimSpace = false
+		}
+		i := strings.Index(s.pending, thinkOpen)
+		if i < 0 {
+			break
+		}
+		out.WriteString(s.pending[:i])
+		s.pending = s.pending[i+len(thinkOpen):]
+		s.inside = true
+		s.blocks++
+	}
+	switch {
+	case final:
+		if !s.inside {
+			out.WriteString(s.pending)
+		}
+		s.pending = ""
+	case s.inside:
+		// drop the reasoning, keeping only what may be the start of the closing tag
+		s.pending = s.pending[len(s.pending)-partialSuffix(s.pending, thinkClose):]
+	default:
+		keep := partialSuffix(s.pending, thinkOpen)
+		out.WriteString(s.pending[:len(s.pending)-keep])
+		s.pending = s.pending[len(s.pending)-keep:]
+	}
+	return out.String(), nil
+}
+
+func (s *thinkStage) result() *domain.FilterResult {
+	if s.blocks == 0 {
+		return nil
+	}
+	return &domain.FilterResult{Filter: "think", Action: domain.FilterActionStripped, Detail: fmt.Sprintf("%d block(s)", s.blocks)}
+}
+
+// partialSuffix returns the length of the longest proper prefix of tag that text ends with.
+func partialSuffix(text, tag string) int {
+	for n := min(len(tag)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// secretsStage redacts credentials with the same rules as the secrets guardrail.
+// While streaming, the last word is held back since a secret may continue in the
+// next chunk, as is a "Bearer" before it and an unfinished private key block.
+type secretsStage struct {
+	redactor redactor
+	pending  string
+	counts   map[string]int
+}
+
+var privateKeyEnd = regexp.MustCompile(`-----END [A-Z ]*PRIVATE KEY-----`)
+
+func (s *secretsStage) process(text string, final bool) (string, error) {
+	s.pending += text
+	cut := len(s.pending)
+	if !final {
+		cut = secretSafeCut(s.pending)
+	}
+	redacted, counts := s.redactor.Redact(s.pending[:cut])
+	s.pending = s.pending[cut:]
+	for name, n := range counts {
+		if s.counts == nil {
+			s.counts = make(map[string]int)
+		}
+		s.counts[name] += n
+	}
+	return redacted, nil
+}
+
+func secretSafeCut(text string) int {
+	cut := strings.LastIndexFunc(text, unicode.IsSpace)
+	if cut < 0 {
+		return 0
+	}
+	prev := strings.TrimRightFunc(text[:cut], unicode.IsSpace)
+	if i := strings.LastIndexFunc(prev, unicode.IsSpace); strings.EqualFold(prev[i+1:], "bearer") {
+		cut = i + 1
+	}
+	if begin := strings.LastIndex(text[:cut], "-----BEGIN"); begin >= 0 && !privateKeyEnd.MatchString(text[begin:cut]) {
+		cut = begin
+	}
+	return cut
+}
+
+func (s *secretsStage) result() *domain.FilterResult {
+	if len(s.counts) == 0 {
+		return nil
+	}
+	var parts []string
+	for _, name := range secretRuleNames {
+		if n := s.counts[name]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", name, n))
+		}
+	}
+	return &domain.FilterResult{Filter: "secrets", Action: domain.FilterActionRedacted, Detail: strings.Join(parts, ", ")}
+}
+
+// denyWindow is how much already released text is searched again with each new
+// chunk, so a phrase split across chunks still matches.
+const denyWindow = 512
+
+// denyStage stops the response when it matches an entry of the deny list. While
+// streaming, text is released a word at a time; what was sent before the match
+// cannot be recalled.
+type denyStage struct {
+	entries []blocklistEntry
+	pending string
+	window  string // the end of the released text
+	line    int    // deny list line that matched
+}
+
+func (s *denyStage) process(text string, final bool) (string, error) {
+	s.pending += text
+	cut := len(s.pending)
+	if !final {
+		cut = max(strings.LastIndexFunc(s.pending, unicode.IsSpace), 0)
+	}
+	ready := s.pending[:cut]
+	if ready == "" {
+		return "", nil
+	}
+	search := s.window + ready
+	for _, e := range s.entries {
+		if e.re.MatchString(search) {
+			s.line = e.line
+			return "", errDenied
+		}
+	}
+	s.pending = s.pending[cut:]
+	s.window = search[max(len(search)-denyWindow, 0):]
+	return ready, nil
+}
+
+func (s *denyStage) result() *domain.FilterResult {
+	if s.line == 0 {
+		return nil
+	}
+	// name the line, not the term, so the response does not echo the list
+	return &domain.FilterResult{Filter: "deny", Action: domain.FilterActionBlocked, Detail: fmt.Sprintf("deny list line %d", s.line)}
+}
+
+// lengthStage cuts the response after limit characters.
+type lengthStage struct {
+	limit, seen int
+	cut         bool
+}
+
+func (s *lengthStage) process(text string, final bool) (string, error) {
+	if s.cut {
+		return "", nil
+	}
+	n := utf8.RuneCountInString(text)
+	if s.seen+n <= s.limit {
+		s.seen += n
+		return text, nil
+	}
+	keep, i := s.limit-s.seen, 0
+	for keep > 0 {
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+		keep--
+	}
+	s.seen, s.cut = s.limit, true
+	return text[:i], nil
+}
+
+func (s *lengthStage) result() *domain.FilterResult {
+	if !s.cut {
+		return nil
+	}
+	return &domain.FilterResult{Filter: "max_length", Action: domain.FilterActionTruncated, Detail: fmt.Sprintf("cut at %d characters", s.limit)}
+}
+
+var (
+	trailingSpace = regexp.MustCompile(`[ \t]+\n`)
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+)
+
+// whitespaceStage trims the response, strips trailing spaces from lines, uses \n
+// line endings and collapses runs of blank lines into one. Trailing whitespace is
+// held back while streaming until it is known not to end the response.
+type whitespaceStage struct {
+	pending string
+	started bool
+	changed bool
+}
+
+func (s *whitespaceStage) process(text string, final bool) (string, error) {
+	s.pending += text
+	end := len(strings.TrimRightFunc(s.pending, unicode.IsSpace))
+	ready := s.pending[:end]
+	s.pending = s.pending[end:]
+	if final && s.pending != "" {
+		s.pending, s.changed = "", true
+	}
+	if ready == "" {
+		return "", nil
+	}
+	out := strings.ReplaceAll(ready, "\r\n", "\n")
+	out = trailingSpace.ReplaceAllString(out, "\n")
+	out = blankLines.ReplaceAllString(out, "\n\n")
+	if !s.started {
+		out = strings.TrimLeftFunc(out, unicode.IsSpace)
+		s.started = true
+	}
+	if out != ready {
+		s.changed = true
+	}
+	return out, nil
+}
+
+func (s *whitespaceStage) result() *domain.FilterResult {
+	if !s.changed {
+		return nil
+	}
+	return &domain.FilterResult{Filter: "whitespace", Action: domain.FilterActionNormalized}
+}
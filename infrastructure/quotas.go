@@ -0,0 +1,248 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// quotaCounters is one key's persisted usage. A counter whose period has passed
+// counts as zero.
+type quotaCounters struct {
+	Day   periodCount `json:"day"`
+	Month periodCount `json:"month"`
+}
+
+type periodCount struct {
+	Period   string `json:"period"`
+	Requests int    `json:"requests"`
+	Tokens   int    `json:"tokens"`
+}
+
+// quotaStore implements domain.QuotaPort with counters kept in memory and written
+// to a JSON file after every change, so usage survives restarts.
+type quotaStore struct {
+	path     string
+	defaults map[string]int            // quota name -> limit for every key
+	perKey   map[string]map[string]int // key ID -> overrides
+	now      func() time.Time
+
+	mu       sync.Mutex
+	counters map[string]*quotaCounters
+}
+
+// NewQuotaStore parses QUOTA_LIMITS and loads the counters persisted at
+// QUOTA_STATE_PATH. Returns nil when no quotas are configured.
+func NewQuotaStore(cfg *config.Config) (domain.QuotaPort, error) {
+	if len(cfg.QuotaLimits) == 0 {
+		return nil, nil
+	}
+	q := &quotaStore{
+		path:     cfg.QuotaStatePath,
+		defaults: make(map[string]int),
+		perKey:   make(map[string]map[string]int),
+		now:      time.Now,
+		counters: make(map[string]*quotaCounters),
+	}
+	for _, entry := range cfg.QuotaLimits {
+		keyID, spec, ok := strings.Cut(entry, ":")
+		keyID = strings.TrimSpace(keyID)
+		if !ok || keyID == "" {
+			return nil, fmt.Errorf("invalid QUOTA_LIMITS entry %q: want keyID:quota=N;...", entry)
+		}
+		limits := q.defaults
+		if keyID != "*" {
+			limits = make(map[string]int)
+			q.perKey[keyID] = limits
+		}
+		for _, field := range strings.Split(spec, ";") {
+			name, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			if !slices.Contains(domain.QuotaNames, name) {
+				return nil, fmt.Errorf("unknown quota %q in QUOTA_LIMITS entry %q", name, entry)
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("invalid %s in QUOTA_LIMITS entry %q", name, entry)
+			}
+			limits[name] = n
+		}
+	}
+	data, err := os.ReadFile(q.path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("failed to read quota state: %w", err)
+	default:
+		if err := json.Unmarshal(data, &q.counters); err != nil {
+			return nil, fmt.Errorf("failed to decode quota state %s: %w", q.path, err)
+		}
+	}
+	return q, nil
+}
+
+// Check implements QuotaPort. The request is counted under the same lock that
+// checks the limits, so concurrent requests cannot all pass on the last one left;
+// the counters are saved by the Debit that follows.
+func (q *quotaStore) Check(keyID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	usage := q.usage(keyID)
+	for _, name := range domain.QuotaNames {
+		var c domain.QuotaCounter
+		var used, limit int
+		switch name {
+		case domain.QuotaRequestsPerDay:
+			c = usage.Day
+			used, limit = c.Requests, c.RequestLimit
+		case domain.QuotaRequestsPerMonth:
+			c = usage.Month
+			used, limit = c.Requests, c.RequestLimit
+		case domain.QuotaTokensPerDay:
+			c = usage.Day
+			used, limit = c.Tokens, c.TokenLimit
+		case domain.QuotaTokensPerMonth:
+			c = usage.Month
+			used, limit = c.Tokens, c.TokenLimit
+		}
+		if limit > 0 && used >= limit {
+			return &domain.QuotaError{Quota: name, Limit: limit, Used: used, ResetsAt: c.ResetsAt}
+		}
+	}
+	c := q.current(keyID)
+	c.Day.Requests++
+	c.Month.Requests++
+	return nil
+}
+
+// Debit implements QuotaPort
+func (q *quotaStore) Debit(keyID string, tokens int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	c := q.current(keyID)
+	c.Day.Tokens += tokens
+	c.Month.Tokens += tokens
+	return q.save()
+}
+
+// Usage implements QuotaPort
+func (q *quotaStore) Usage(keyID string) domain.QuotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.usage(keyID)
+}
+
+// List implements QuotaPort
+func (q *quotaStore) List() []domain.QuotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	keys := make([]string, 0, len(q.counters))
+	for keyID := range q.counters {
+		keys = append(keys, keyID)
+	}
+	slices.Sort(keys)
+	list := make([]domain.QuotaUsage, 0, len(keys))
+	for _, keyID := range keys {
+		list = append(list, q.usage(keyID))
+	}
+	return list
+}
+
+// Reset implements QuotaPort
+func (q *quotaStore) Reset(keyID string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.counters, keyID)
+	return q.save()
+}
+
+// current returns keyID's counters, starting new periods as days and months pass.
+// Callers hold q.mu.
+func (q *quotaStore) current(keyID string) *quotaCounters {
+	now := q.now().UTC()
+	day, month := now.Format(time.DateOnly), now.Format("2006-01")
+	c, ok := q.counters[keyID]
+	if !ok {
+		c = &quotaCounters{}
+		q.counters[keyID] = c
+	}
+	if c.Day.Period != day {
+		c.Day = periodCount{Period: day}
+	}
+	if c.Month.Period != month {
+		c.Month = periodCount{Period: month}
+	}
+	return c
+}
+
+// usage reports keyID's counters with its limits. Callers hold q.mu.
+func (q *quotaStore) usage(keyID string) domain.QuotaUsage {
+	now := q.now().UTC()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	c := quotaCounters{Day: periodCount{Period: now.Format(time.DateOnly)}, Month: periodCount{Period: now.Format("2006-01")}}
+	if stored, ok := q.counters[keyID]; ok {
+		if stored.Day.Period == c.Day.Period {
+			c.Day = stored.Day
+		}
+		if stored.Month.Period == c.Month.Period {
+			c.Month = stored.Month
+		}
+	}
+	return domain.QuotaUsage{
+		KeyID: keyID,
+		Day: domain.QuotaCounter{
+			Period:       c.Day.Period,
+			Requests:     c.Day.Requests,
+			Tokens:       c.Day.Tokens,
+			RequestLimit: q.limit(keyID, domain.QuotaRequestsPerDay),
+			TokenLimit:   q.limit(keyID, domain.QuotaTokensPerDay),
+			ResetsAt:     dayStart.AddDate(0, 0, 1),
+		},
+		Month: domain.QuotaCounter{
+			Period:       c.Month.Period,
+			Requests:     c.Month.Requests,
+			Tokens:       c.Month.Tokens,
+			RequestLimit: q.limit(keyID, domain.QuotaRequestsPerMonth),
+			TokenLimit:   q.limit(keyID, domain.QuotaTokensPerMonth),
+			ResetsAt:     monthStart.AddDate(0, 1, 0),
+		},
+	}
+}
+
+// limit returns keyID's limit for a quota: its own if set, otherwise the default.
+func (q *quotaStore) limit(keyID, name string) int {
+	if n, ok := q.perKey[keyID][name]; ok {
+		return n
+	}
+	return q.defaults[name]
+}
+
+// save atomically replaces the state file. Callers hold q.mu.
+func (q *quotaStore) save() error {
+	dir := filepath.Dir(q.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(q.path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	err = json.NewEncoder(tmp).Encode(q.counters)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write quota state: %w", err)
+	}
+	return os.Rename(tmp.Name(), q.path)
+}
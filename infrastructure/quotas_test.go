@@ -0,0 +1,111 @@
+package infrastructure
+
+import (
+	"errors"
+	"minivault/config"
+	"minivault/domain"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestQuotaStore_LimitsAndPeriods(t *testing.T) {
+	cfg := &config.Config{
+		QuotaLimits:    []string{"*:requests_per_day=2;tokens_per_month=1000", "alice:requests_per_day=5"},
+		QuotaStatePath: filepath.Join(t.TempDir(), "quotas.json"),
+	}
+	port, err := NewQuotaStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := port.(*quotaStore)
+	now := time.Date(2025, 1, 31, 23, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	for range 2 {
+		if err := q.Check("bob"); err != nil {
+			t.Fatalf("unexpected refusal: %v", err)
+		}
+		q.Debit("bob", 100)
+	}
+	var quotaErr *domain.QuotaError
+	if err := q.Check("bob"); !errors.As(err, &quotaErr) || quotaErr.Quota != domain.QuotaRequestsPerDay || quotaErr.Used != 2 || quotaErr.Limit != 2 {
+		t.Fatalf("expected the daily request quota to be hit, got %v", err)
+	}
+	if !quotaErr.ResetsAt.Equal(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected reset time %v", quotaErr.ResetsAt)
+	}
+	if err := q.Check("alice"); err != nil {
+		t.Errorf("alice's own limit should apply: %v", err)
+	}
+
+	// a new day and month start from zero; persisted counters survive a restart
+	now = now.Add(2 * time.Hour)
+	if err := q.Check("bob"); err != nil {
+		t.Errorf("quota should reset with the period: %v", err)
+	}
+	q.Debit("bob", 1000)
+	reloaded, err := NewQuotaStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded.(*quotaStore).now = q.now
+	if err := reloaded.Check("bob"); !errors.As(err, &quotaErr) || quotaErr.Quota != domain.QuotaTokensPerMonth {
+		t.Errorf("expected the monthly token quota after reload, got %v", err)
+	}
+	u := reloaded.Usage("bob")
+	if u.Day.Period != "2025-02-01" || u.Day.Requests != 1 || u.Month.Tokens != 1000 || u.Month.TokenLimit != 1000 || u.Day.RequestLimit != 2 {
+		t.Errorf("unexpected usage %+v", u)
+	}
+
+	if err := reloaded.Reset("bob"); err != nil {
+		t.Fatal(err)
+	}
+	if list := reloaded.List(); len(list) != 1 || list[0].KeyID != "alice" {
+		t.Errorf("expected only alice's checked request after bob's reset, got %+v", list)
+	}
+	if err := reloaded.Check("bob"); err != nil {
+		t.Errorf("reset should clear usage: %v", err)
+	}
+}
+
+func TestQuotaStore_CheckReservesRequests(t *testing.T) {
+	port, err := NewQuotaStore(&config.Config{
+		QuotaLimits:    []string{"*:requests_per_day=5"},
+		QuotaStatePath: filepath.Join(t.TempDir(), "quotas.json"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var passed atomic.Int32
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if port.Check("bob") == nil {
+				passed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if passed.Load() != 5 {
+		t.Errorf("expected exactly 5 concurrent requests to pass, got %d", passed.Load())
+	}
+	if u := port.Usage("bob"); u.Day.Requests != 5 {
+		t.Errorf("expected the passed requests to be counted, got %+v", u.Day)
+	}
+}
+
+func TestNewQuotaStore_Config(t *testing.T) {
+	if q, err := NewQuotaStore(&config.Config{}); q != nil || err != nil {
+		t.Errorf("expected no quotas when unconfigured, got %v %v", q, err)
+	}
+	for _, limits := range []string{"alice", "*:requests_per_week=5", "*:tokens_per_day=-1"} {
+		if _, err := NewQuotaStore(&config.Config{QuotaLimits: []string{limits}, QuotaStatePath: filepath.Join(t.TempDir(), "q.json")}); err == nil {
+			t.Errorf("expected an error for %q", limits)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package infrastructure
+
+import (
+	"bufio"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// redactRule replaces matches of re with a typed placeholder. If valid is set,
+// matches it rejects (e.g. failing a Luhn check) are left untouched.
+type redactRule struct {
+	name  string
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+// redactor implements domain.RedactorPort by applying its rules in order.
+type redactor struct {
+	rules []redactRule
+}
+
+// builtinRedactRules are applied most specific first so that, for example, a
+// token is not partially consumed by the phone number detector.
+func builtinRedactRules() []redactRule {
+	return []redactRule{
+		{name: "PRIVATE_KEY", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+		{name: "TOKEN", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}`)},
+		{name: "TOKEN", re: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]{20,}`)},
+		{name: "API_KEY", re: regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_-]{20,}`)},
+		{name: "API_KEY", re: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+		{name: "API_KEY", re: regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+		{name: "API_KEY", re: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+		{name: "API_KEY", re: regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+		{name: "EMAIL", re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+		{name: "CREDIT_CARD", re: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), valid: luhnValid},
+		{name: "IP_ADDRESS", re: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), valid: ipv4Valid},
+		{name: "IP_ADDRESS", re: regexp.MustCompile(`\b[0-9A-Fa-f]{1,4}(?::[0-9A-Fa-f]{0,4}){2,7}\b`), valid: ipv6Valid},
+		{name: "PHONE", re: regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?(?:\(\d{2,4}\)[\s.-]?|\b\d{2,4}[\s.-])\d{3,4}[\s.-]?\d{3,4}\b`)},
+	}
+}
+
+// NewRedactor builds the redaction pipeline from config. Built-in detectors run
+// first, followed by user rules from REDACT_RULES_FILE. Returns a no-op redactor
+// when redaction is disabled.
+func NewRedactor(cfg *config.Config) (domain.RedactorPort, error) {
+	if !cfg.RedactEnabled {
+		return &redactor{}, nil
+	}
+	rules := builtinRedactRules()
+	if cfg.RedactRulesFile != "" {
+		custom, err := loadRedactRules(cfg.RedactRulesFile)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, custom...)
+	}
+	return &redactor{rules: rules}, nil
+}
+
+// loadRedactRules reads "NAME=regex" lines; blank lines and # comments are ignored.
+func loadRedactRules(path string) ([]redactRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open redaction rules: %w", err)
+	}
+	defer f.Close()
+
+	var rules []redactRule
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, pattern, ok := strings.Cut(line, "=")
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if !ok || name == "" || pattern == "" {
+			return nil, fmt.Errorf("redaction rules %s:%d: expected NAME=regex", path, lineNo)
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("redaction rules %s:%d: %w", path, lineNo, err)
+		}
+		rules = append(rules, redactRule{name: name, re: re})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read redaction rules: %w", err)
+	}
+	return rules, nil
+}
+
+// Redact replaces every detected value with [REDACTED:<TYPE>] and returns the
+// number of replacements per type (nil if nothing was redacted).
+func (r *redactor) Redact(text string) (string, map[string]int) {
+	var counts map[string]int
+	for _, rule := range r.rules {
+		text = rule.re.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.valid != nil && !rule.valid(match) {
+				return match
+			}
+			if counts == nil {
+				counts = make(map[string]int)
+			}
+			counts[rule.name]++
+			return "[REDACTED:" + rule.name + "]"
+		})
+	}
+	return text, counts
+}
+
+// luhnValid checks the digits of a candidate card number against the Luhn checksum.
+func luhnValid(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+func ipv4Valid(s string) bool {
+	ip := net.ParseIP(s)
+	return ip != nil && ip.To4() != nil
+}
+
+// ipv6Valid accepts real IPv6 addresses with at least three non-empty groups,
+// which keeps things like C++ "std::string" from being treated as addresses.
+func ipv6Valid(s string) bool {
+	ip := net.ParseIP(s)
+	if ip == nil || ip.To4() != nil {
+		return false
+	}
+	groups := 0
+	for _, g := range strings.Split(s, ":") {
+		if g != "" {
+			groups++
+		}
+	}
+	return groups >= 3
+}
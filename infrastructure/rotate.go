@@ -0,0 +1,295 @@
+package infrastructure
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const segmentTimeFormat = "20060102T150405.000"
+
+// RotateOptions controls when the active log file is rotated and which segments are kept.
+// Zero values disable the corresponding behaviour.
+type RotateOptions struct {
+	MaxSize    int64         // rotate once the active file would exceed this many bytes
+	Interval   time.Duration // rotate once the active file is older than this
+	Compress   bool          // gzip rotated segments
+	MaxAge     time.Duration // delete segments older than this
+	MaxBackups int           // keep at most this many rotated segments
+}
+
+// rotatingFile is an io.WriteCloser over dir/name that rotates into timestamped
+// segments (e.g. log-20250101T120000.000-000.jsonl[.gz]) and prunes old ones.
+// The three-digit sequence keeps segments rotated within the same millisecond apart.
+type rotatingFile struct {
+	dir  string
+	name string
+	opts RotateOptions
+	// onError reports failures that do not stop writes: a failed rotation, which
+	// leaves records going to the current file, and failed compression.
+	onError func(message string, err error)
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	bg       sync.WaitGroup  // pending compression/pruning
+	bgMu     sync.Mutex      // runs one compression or prune at a time
+	pending  map[string]bool // rotated segments not yet compressed, guarded by mu
+	now      func() time.Time
+	rename   func(oldpath, newpath string) error
+}
+
+func newRotatingFile(dir, name string, opts RotateOptions) (*rotatingFile, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	r := &rotatingFile{dir: dir, name: name, opts: opts, onError: func(string, error) {}, now: time.Now, rename: os.Rename}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) path() string {
+	return filepath.Join(r.dir, r.name)
+}
+
+// open opens (or creates) the active file, taking its age from the modification time
+// so that time-based rotation survives restarts.
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	r.file = f
+	r.size = info.Size()
+	r.openedAt = r.now()
+	if r.size > 0 {
+		r.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return 0, os.ErrClosed
+	}
+	if r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			r.onError("failed to rotate log file, still writing to "+r.name, err)
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+func (r *rotatingFile) shouldRotate(incoming int64) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.opts.MaxSize > 0 && r.size+incoming > r.opts.MaxSize {
+		return true
+	}
+	return r.opts.Interval > 0 && r.now().Sub(r.openedAt) >= r.opts.Interval
+}
+
+// rotate renames the active file to a timestamped segment and opens a fresh one.
+// If either step fails, the current file stays open under its own name so no
+// record is lost. Must be called with r.mu held.
+func (r *rotatingFile) rotate() error {
+	segment := r.nextSegment()
+	if err := r.rename(r.path(), segment); err != nil {
+		return err
+	}
+	old := r.file
+	if err := r.open(); err != nil {
+		if undoErr := r.rename(segment, r.path()); undoErr != nil {
+			err = fmt.Errorf("%w (records go to %s until the next rotation)", err, segment)
+		}
+		return err
+	}
+	if err := old.Close(); err != nil {
+		r.onError("failed to close rotated log segment", err)
+	}
+
+	if r.opts.Compress {
+		if r.pending == nil {
+			r.pending = map[string]bool{}
+		}
+		r.pending[segment] = true
+	}
+	r.bg.Add(1)
+	go r.compressAndPrune(segment)
+	return nil
+}
+
+// compressAndPrune compresses a rotated segment and then applies retention. Runs
+// are serialized so a prune never removes a segment, or its partial .gz, while it
+// is being compressed.
+func (r *rotatingFile) compressAndPrune(segment string) {
+	defer r.bg.Done()
+	r.bgMu.Lock()
+	defer r.bgMu.Unlock()
+	if r.opts.Compress {
+		if err := compressSegment(segment); err != nil {
+			r.onError("failed to compress log segment "+filepath.Base(segment), err)
+		}
+	}
+	r.mu.Lock()
+	delete(r.pending, segment)
+	pending := make(map[string]bool, len(r.pending))
+	for s := range r.pending {
+		pending[s] = true
+	}
+	r.mu.Unlock()
+	r.prune(pending)
+}
+
+// nextSegment names the segment the active file rotates into: the current time
+// and the first sequence number not yet taken, compressed or not.
+func (r *rotatingFile) nextSegment() string {
+	base := strings.TrimSuffix(r.name, filepath.Ext(r.name))
+	stamp := r.now().UTC().Format(segmentTimeFormat)
+	for seq := 0; ; seq++ {
+		segment := filepath.Join(r.dir, fmt.Sprintf("%s-%s-%03d%s", base, stamp, seq, filepath.Ext(r.name)))
+		if !fileExists(segment) && !fileExists(segment+".gz") {
+			return segment
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Reopen closes and reopens the active file, for use after an external tool such
+// as logrotate has moved it away.
+func (r *rotatingFile) Reopen() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+	return r.open()
+}
+
+// Close flushes the active file to disk and waits for background compression.
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	var err error
+	if r.file != nil {
+		if syncErr := r.file.Sync(); syncErr != nil {
+			err = syncErr
+		}
+		if closeErr := r.file.Close(); closeErr != nil {
+			err = closeErr
+		}
+		r.file = nil
+	}
+	r.mu.Unlock()
+	r.bg.Wait()
+	return err
+}
+
+// Segments returns rotated segment paths, oldest first.
+func (r *rotatingFile) Segments() []string {
+	return listSegments(r.dir, r.name)
+}
+
+// prune applies the MaxAge and MaxBackups retention rules to rotated segments.
+// Segments still waiting to be compressed count towards MaxBackups but are
+// never removed.
+func (r *rotatingFile) prune(pending map[string]bool) {
+	segments := listSegments(r.dir, r.name)
+	if r.opts.MaxAge > 0 {
+		cutoff := r.now().Add(-r.opts.MaxAge)
+		kept := segments[:0]
+		for _, s := range segments {
+			if info, err := os.Stat(s); err == nil && info.ModTime().Before(cutoff) && !pending[s] {
+				os.Remove(s)
+				continue
+			}
+			kept = append(kept, s)
+		}
+		segments = kept
+	}
+	if r.opts.MaxBackups > 0 {
+		excess := len(segments) - r.opts.MaxBackups
+		for _, s := range segments {
+			if excess <= 0 {
+				break
+			}
+			if pending[s] {
+				continue
+			}
+			os.Remove(s)
+			excess--
+		}
+	}
+}
+
+// listSegments finds rotated segments of name in dir. Timestamped names sort chronologically.
+// A .gz next to its uncompressed segment is a compression still in progress (or
+// interrupted), so only the uncompressed segment is listed.
+func listSegments(dir, name string) []string {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	matches, _ := filepath.Glob(filepath.Join(dir, base+"-*"+filepath.Ext(name)+"*"))
+	var segments []string
+	for _, m := range matches {
+		switch {
+		case strings.HasSuffix(m, filepath.Ext(name)):
+			segments = append(segments, m)
+		case strings.HasSuffix(m, filepath.Ext(name)+".gz") && !fileExists(strings.TrimSuffix(m, ".gz")):
+			segments = append(segments, m)
+		}
+	}
+	sort.Strings(segments)
+	return segments
+}
+
+// compressSegment gzips path to path.gz and removes the original on success.
+func compressSegment(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
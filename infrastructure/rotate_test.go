@@ -0,0 +1,201 @@
+package infrastructure
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFile_RotatesOnSize(t *testing.T) {
+	dir := t.TempDir()
+	r, err := newRotatingFile(dir, "log.jsonl", RotateOptions{MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Write([]byte("0123456789\n"))
+	r.Write([]byte("abc\n"))
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	segments := listSegments(dir, "log.jsonl")
+	if len(segments) != 1 {
+		t.Fatalf("expected 1 rotated segment, got %v", segments)
+	}
+	if !strings.HasPrefix(filepath.Base(segments[0]), "log-") {
+		t.Errorf("unexpected segment name %q", segments[0])
+	}
+	active, _ := os.ReadFile(filepath.Join(dir, "log.jsonl"))
+	if string(active) != "abc\n" {
+		t.Errorf("unexpected active file contents %q", active)
+	}
+}
+
+func TestRotatingFile_RotatesOnIntervalAndCompresses(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r, err := newRotatingFile(dir, "log.jsonl", RotateOptions{Interval: time.Hour, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.now = func() time.Time { return now }
+	r.openedAt = now
+	r.Write([]byte("first\n"))
+	now = now.Add(2 * time.Hour)
+	r.Write([]byte("second\n"))
+	r.Close()
+
+	segments := listSegments(dir, "log.jsonl")
+	if len(segments) != 1 || !strings.HasSuffix(segments[0], ".jsonl.gz") {
+		t.Fatalf("expected 1 compressed segment, got %v", segments)
+	}
+	f, _ := os.Open(segments[0])
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(gz)
+	if string(data) != "first\n" {
+		t.Errorf("unexpected segment contents %q", data)
+	}
+}
+
+func TestRotatingFile_PrunesByCount(t *testing.T) {
+	dir := t.TempDir()
+	r, err := newRotatingFile(dir, "log.jsonl", RotateOptions{MaxSize: 1, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Second)
+		r.now = func() time.Time { return now }
+		r.Write([]byte("x\n"))
+	}
+	r.Close()
+
+	if segments := listSegments(dir, "log.jsonl"); len(segments) != 2 {
+		t.Errorf("expected 2 segments after pruning, got %v", segments)
+	}
+}
+
+func TestRotatingFile_CompressesAndPrunesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	r, err := newRotatingFile(dir, "log.jsonl", RotateOptions{MaxSize: 1, Compress: true, MaxBackups: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	for i := 0; i < 20; i++ {
+		now = now.Add(time.Second)
+		r.now = func() time.Time { return now }
+		r.Write([]byte("x\n"))
+	}
+	r.Close()
+
+	segments := listSegments(dir, "log.jsonl")
+	if len(segments) != 2 {
+		t.Fatalf("expected 2 segments after pruning, got %v", segments)
+	}
+	for _, s := range segments {
+		if !strings.HasSuffix(s, ".jsonl.gz") {
+			t.Errorf("expected %s to be compressed", s)
+		}
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("expected the active file and 2 segments, got %d files", len(entries))
+	}
+}
+
+func TestListSegments_SkipsPartialCompression(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"log-a-000.jsonl.gz", "log-b-000.jsonl", "log-b-000.jsonl.gz"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	segments := listSegments(dir, "log.jsonl")
+	if len(segments) != 2 || filepath.Base(segments[1]) != "log-b-000.jsonl" {
+		t.Errorf("expected the partial .gz to be left out, got %v", segments)
+	}
+}
+
+func TestRotatingFile_Reopen(t *testing.T) {
+	dir := t.TempDir()
+	r, err := newRotatingFile(dir, "log.jsonl", RotateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	r.Write([]byte("before\n"))
+	os.Rename(filepath.Join(dir, "log.jsonl"), filepath.Join(dir, "moved.jsonl"))
+	if err := r.Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	r.Write([]byte("after\n"))
+
+	active, _ := os.ReadFile(filepath.Join(dir, "log.jsonl"))
+	if string(active) != "after\n" {
+		t.Errorf("expected writes to go to the reopened file, got %q", active)
+	}
+}
+
+func TestRotatingFile_SegmentsWithinOneMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	r, err := newRotatingFile(dir, "log.jsonl", RotateOptions{MaxSize: 1, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		r.Write([]byte(line))
+	}
+	r.Close()
+
+	segments := listSegments(dir, "log.jsonl")
+	if len(segments) != 3 {
+		t.Fatalf("expected 3 segments from the same millisecond, got %v", segments)
+	}
+	var got string
+	for _, s := range segments {
+		f, _ := openLogFile(s)
+		data, _ := io.ReadAll(f)
+		f.Close()
+		got += string(data)
+	}
+	if got != "a\nb\nc\n" {
+		t.Errorf("expected every record in order, got %q", got)
+	}
+}
+
+func TestRotatingFile_KeepsWritingWhenRotationFails(t *testing.T) {
+	dir := t.TempDir()
+	r, err := newRotatingFile(dir, "log.jsonl", RotateOptions{MaxSize: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reported []error
+	r.onError = func(_ string, err error) { reported = append(reported, err) }
+	r.rename = func(string, string) error { return os.ErrPermission }
+	r.Write([]byte("first\n"))
+	if _, err := r.Write([]byte("second\n")); err != nil {
+		t.Fatalf("a failed rotation should not fail the write: %v", err)
+	}
+	if len(reported) != 1 {
+		t.Errorf("expected the failed rotation to be reported, got %v", reported)
+	}
+
+	r.rename = os.Rename
+	r.Write([]byte("third\n"))
+	r.Close()
+	active, _ := os.ReadFile(filepath.Join(dir, "log.jsonl"))
+	segments := listSegments(dir, "log.jsonl")
+	if string(active) != "third\n" || len(segments) != 1 {
+		t.Errorf("expected rotation to resume once it works, got %q and %v", active, segments)
+	}
+}
@@ -0,0 +1,153 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// tenantsFile is the layout of TENANTS_FILE.
+type tenantsFile struct {
+	Tenants []domain.Tenant `json:"tenants"`
+}
+
+// tenantDirectory implements domain.TenantsPort over the tenants in TENANTS_FILE,
+// with a token bucket per tenant for its rate limit.
+type tenantDirectory struct {
+	tenants map[string]domain.Tenant
+	byKey   map[string]string // API key ID -> tenant ID
+	now     func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket refills at the tenant's rate up to its burst.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTenants loads and validates TENANTS_FILE. Every API key ID it names must exist
+// in MINIVAULT_API_KEYS and may belong to one tenant only; log directories default
+// to LOG_DIR/tenants/<id> and may not be shared. Returns nil when no file is set.
+func NewTenants(cfg *config.Config) (domain.TenantsPort, error) {
+	if cfg.TenantsFile == "" {
+		return nil, nil
+	}
+	if len(cfg.APIKeys) == 0 {
+		return nil, fmt.Errorf("TENANTS_FILE needs MINIVAULT_API_KEYS to tell tenants apart")
+	}
+	data, err := os.ReadFile(cfg.TenantsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tenants file: %w", err)
+	}
+	var file tenantsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode tenants file %s: %w", cfg.TenantsFile, err)
+	}
+	d := &tenantDirectory{
+		tenants: make(map[string]domain.Tenant),
+		byKey:   make(map[string]string),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+	dirs := map[string]string{filepath.Clean(cfg.LogDir): "LOG_DIR"}
+	for _, t := range file.Tenants {
+		if !tenantIDPattern.MatchString(t.ID) {
+			return nil, fmt.Errorf("invalid tenant id %q: want 1-64 letters, digits, '-' or '_'", t.ID)
+		}
+		if _, ok := d.tenants[t.ID]; ok {
+			return nil, fmt.Errorf("tenant %q is defined twice", t.ID)
+		}
+		for _, keyID := range t.APIKeyIDs {
+			if _, ok := cfg.APIKeys[keyID]; !ok {
+				return nil, fmt.Errorf("tenant %q: API key %q is not in MINIVAULT_API_KEYS", t.ID, keyID)
+			}
+			if other, ok := d.byKey[keyID]; ok {
+				return nil, fmt.Errorf("API key %q is bound to both tenant %q and %q", keyID, other, t.ID)
+			}
+			d.byKey[keyID] = t.ID
+		}
+		for _, model := range t.Models {
+			if !domain.ValidModelName(model) {
+				return nil, fmt.Errorf("tenant %q: %w %q", t.ID, domain.ErrInvalidModelName, model)
+			}
+		}
+		if t.RateLimit < 0 || t.RateBurst < 0 {
+			return nil, fmt.Errorf("tenant %q: rate_limit and rate_burst must not be negative", t.ID)
+		}
+		if t.RateBurst == 0 {
+			t.RateBurst = max(t.RateLimit, 1)
+		}
+		if t.LogDir == "" {
+			t.LogDir = filepath.Join(cfg.LogDir, "tenants", t.ID)
+		}
+		t.LogDir = filepath.Clean(t.LogDir)
+		if owner, ok := dirs[t.LogDir]; ok {
+			return nil, fmt.Errorf("tenant %q: log_dir %s is already used by %s", t.ID, t.LogDir, owner)
+		}
+		dirs[t.LogDir] = "tenant " + t.ID
+		d.tenants[t.ID] = t
+	}
+	return d, nil
+}
+
+// TenantFor implements TenantsPort
+func (d *tenantDirectory) TenantFor(keyID string) (domain.Tenant, bool) {
+	id, ok := d.byKey[keyID]
+	if !ok {
+		return domain.Tenant{}, false
+	}
+	return d.tenants[id], true
+}
+
+// Tenant implements TenantsPort
+func (d *tenantDirectory) Tenant(id string) (domain.Tenant, bool) {
+	t, ok := d.tenants[id]
+	return t, ok
+}
+
+// List implements TenantsPort
+func (d *tenantDirectory) List() []domain.Tenant {
+	list := make([]domain.Tenant, 0, len(d.tenants))
+	for _, t := range d.tenants {
+		list = append(list, t)
+	}
+	slices.SortFunc(list, func(a, b domain.Tenant) int { return strings.Compare(a.ID, b.ID) })
+	return list
+}
+
+// Allow implements TenantsPort
+func (d *tenantDirectory) Allow(id string) (bool, time.Duration) {
+	t, ok := d.tenants[id]
+	if !ok || t.RateLimit == 0 {
+		return true, 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	perSecond := float64(t.RateLimit) / 60
+	b, ok := d.buckets[id]
+	if !ok {
+		b = &tokenBucket{tokens: float64(t.RateBurst), last: now}
+		d.buckets[id] = b
+	}
+	b.tokens = min(float64(t.RateBurst), b.tokens+now.Sub(b.last).Seconds()*perSecond)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+	return false, time.Duration((1 - b.tokens) / perSecond * float64(time.Second))
+}
@@ -0,0 +1,131 @@
+package infrastructure
+
+import (
+	"minivault/config"
+	"minivault/domain"
+	"minivault/mocks"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTenantsFile(t *testing.T, body string) string {
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewTenants(t *testing.T) {
+	logDir := t.TempDir()
+	cfg := &config.Config{
+		LogDir:  logDir,
+		APIKeys: map[string]string{"alice": "a", "bob": "b", "ops": "o"},
+		TenantsFile: writeTenantsFile(t, `{"tenants": [
+			{"id": "red", "api_key_ids": ["alice"], "models": ["gemma:2b"], "rate_limit": 60},
+			{"id": "blue", "api_key_ids": ["bob"], "log_dir": "`+filepath.Join(logDir, "blue")+`"}
+		]}`),
+	}
+	tenants, err := NewTenants(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	red, ok := tenants.TenantFor("alice")
+	if !ok || red.ID != "red" || red.RateBurst != 60 || red.LogDir != filepath.Join(logDir, "tenants", "red") {
+		t.Errorf("unexpected tenant for alice: %+v", red)
+	}
+	if _, ok := tenants.TenantFor("ops"); ok {
+		t.Error("ops is bound to no tenant")
+	}
+	if list := tenants.List(); len(list) != 2 || list[0].ID != "blue" || list[1].ID != "red" {
+		t.Errorf("expected tenants ordered by id, got %+v", list)
+	}
+
+	if tenants, err := NewTenants(&config.Config{}); tenants != nil || err != nil {
+		t.Errorf("expected no tenants when unconfigured, got %v %v", tenants, err)
+	}
+	for name, body := range map[string]string{
+		"unknown key":  `{"tenants": [{"id": "red", "api_key_ids": ["mallory"]}]}`,
+		"shared key":   `{"tenants": [{"id": "red", "api_key_ids": ["alice"]}, {"id": "blue", "api_key_ids": ["alice"]}]}`,
+		"duplicate id": `{"tenants": [{"id": "red"}, {"id": "red"}]}`,
+		"bad id":       `{"tenants": [{"id": "../red"}]}`,
+		"shared dir":   `{"tenants": [{"id": "red", "log_dir": "` + logDir + `"}]}`,
+		"bad model":    `{"tenants": [{"id": "red", "models": ["no spaces"]}]}`,
+		"negative":     `{"tenants": [{"id": "red", "rate_limit": -1}]}`,
+	} {
+		cfg := &config.Config{LogDir: logDir, APIKeys: cfg.APIKeys, TenantsFile: writeTenantsFile(t, body)}
+		if _, err := NewTenants(cfg); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := NewTenants(&config.Config{TenantsFile: cfg.TenantsFile}); err == nil {
+		t.Error("tenants without API keys should be refused")
+	}
+}
+
+func TestTenants_RateLimit(t *testing.T) {
+	cfg := &config.Config{
+		LogDir:      t.TempDir(),
+		APIKeys:     map[string]string{"alice": "a"},
+		TenantsFile: writeTenantsFile(t, `{"tenants": [{"id": "red", "api_key_ids": ["alice"], "rate_limit": 30, "rate_burst": 2}, {"id": "blue"}]}`),
+	}
+	port, err := NewTenants(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := port.(*tenantDirectory)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	for i := range 2 {
+		if ok, _ := d.Allow("red"); !ok {
+			t.Fatalf("request %d should fit in the burst", i+1)
+		}
+	}
+	ok, retryAfter := d.Allow("red")
+	if ok || retryAfter != 2*time.Second {
+		t.Errorf("expected a refusal for 2s at 30/min, got %v %v", ok, retryAfter)
+	}
+	now = now.Add(2 * time.Second)
+	if ok, _ := d.Allow("red"); !ok {
+		t.Error("a token should have refilled")
+	}
+	for range 10 {
+		if ok, _ := d.Allow("blue"); !ok {
+			t.Fatal("a tenant without a rate limit is never refused")
+		}
+	}
+}
+
+func TestLogger_TenantLogs(t *testing.T) {
+	cfg := &config.Config{LogDir: t.TempDir()}
+	tenants := &mocks.MockTenants{Tenants: []domain.Tenant{{ID: "red", LogDir: filepath.Join(cfg.LogDir, "tenants", "red")}}}
+	l := NewLogger(cfg, &mocks.MockRedactor{}, nil, tenants)
+	l.LogInteraction(domain.Interaction{ID: "shared", Prompt: "p"})
+	l.LogInteraction(domain.Interaction{ID: "private", Tenant: "red", Prompt: "p"})
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	shared, _ := os.ReadFile(filepath.Join(cfg.LogDir, InteractionLogName))
+	private, _ := os.ReadFile(filepath.Join(cfg.LogDir, "tenants", "red", InteractionLogName))
+	if !strings.Contains(string(shared), `"id":"shared"`) || strings.Contains(string(shared), "private") {
+		t.Errorf("unexpected shared log: %s", shared)
+	}
+	if !strings.Contains(string(private), `"id":"private"`) || !strings.Contains(string(private), `"tenant":"red"`) {
+		t.Errorf("unexpected tenant log: %s", private)
+	}
+
+	tenantCfg := *cfg
+	tenantCfg.LogDir = tenants.Tenants[0].LogDir
+	got, err := NewInteractionStore(&tenantCfg, nil).Get("private")
+	if err != nil || got.Tenant != "red" {
+		t.Errorf("expected the tenant's store to load its record, got %+v %v", got, err)
+	}
+	if _, err := NewInteractionStore(cfg, nil).Get("private"); err != domain.ErrInteractionNotFound {
+		t.Errorf("the shared store must not see tenant records, got %v", err)
+	}
+}
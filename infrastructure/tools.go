@@ -0,0 +1,308 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"math"
+	"minivault/config"
+	"minivault/domain"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxToolFileBytes caps how much of a file read_file returns to the model.
+const maxToolFileBytes = 32 << 10
+
+type toolFunc func(ctx context.Context, args map[string]any) (string, error)
+
+type registeredTool struct {
+	def domain.Tool
+	run toolFunc
+}
+
+// toolbox implements domain.ToolboxPort over the built-in Go tools enabled in config.
+type toolbox struct {
+	tools   map[string]registeredTool
+	timeout time.Duration
+}
+
+// NewToolbox builds the registry of tools listed in cfg.Tools. It returns nil when
+// no tools are enabled. read_file requires cfg.ToolsFileRoot and can only read
+// files beneath it.
+func NewToolbox(cfg *config.Config) (domain.ToolboxPort, error) {
+	if len(cfg.Tools) == 0 {
+		return nil, nil
+	}
+	tb := &toolbox{tools: make(map[string]registeredTool), timeout: cfg.ToolsTimeout}
+	for _, name := range cfg.Tools {
+		var t registeredTool
+		switch name {
+		case "calculator":
+			t = registeredTool{def: calculatorTool, run: runCalculator}
+		case "clock":
+			t = registeredTool{def: clockTool, run: runClock}
+		case "read_file":
+			if cfg.ToolsFileRoot == "" {
+				return nil, errors.New("tool read_file requires TOOLS_FILE_ROOT")
+			}
+			root, err := os.OpenRoot(cfg.ToolsFileRoot)
+			if err != nil {
+				return nil, fmt.Errorf("failed to open TOOLS_FILE_ROOT: %w", err)
+			}
+			t = registeredTool{def: readFileTool, run: readFileFunc(root)}
+		default:
+			return nil, fmt.Errorf("unknown tool %q in TOOLS", name)
+		}
+		tb.tools[name] = t
+	}
+	return tb, nil
+}
+
+// Tools implements ToolboxPort
+func (tb *toolbox) Tools(names []string) ([]domain.Tool, error) {
+	defs := make([]domain.Tool, 0, len(names))
+	for _, name := range names {
+		t, ok := tb.tools[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", domain.ErrUnknownTool, name)
+		}
+		defs = append(defs, t.def)
+	}
+	return defs, nil
+}
+
+// Call implements ToolboxPort
+func (tb *toolbox) Call(ctx context.Context, call domain.ToolCall) (string, error) {
+	t, ok := tb.tools[call.Function.Name]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", domain.ErrUnknownTool, call.Function.Name)
+	}
+	if tb.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tb.timeout)
+		defer cancel()
+	}
+	return t.run(ctx, call.Function.Arguments)
+}
+
+func stringArg(args map[string]any, name string) (string, error) {
+	v, ok := args[name].(string)
+	if !ok || strings.TrimSpace(v) == "" {
+		return "", fmt.Errorf("argument %q must be a non-empty string", name)
+	}
+	return v, nil
+}
+
+var calculatorTool = domain.Tool{
+	Type: domain.ToolTypeFunction,
+	Function: domain.ToolFunction{
+		Name:        "calculator",
+		Description: "Evaluate an arithmetic expression with + - * / %, parentheses and the functions sqrt, pow, abs, floor, ceil, round.",
+		Parameters:  json.RawMessage(`{"type":"object","required":["expression"],"properties":{"expression":{"type":"string","description":"e.g. (2 + 3) * pow(2, 10)"}}}`),
+	},
+}
+
+func runCalculator(_ context.Context, args map[string]any) (string, error) {
+	expr, err := stringArg(args, "expression")
+	if err != nil {
+		return "", err
+	}
+	node, err := parser.ParseExpr(expr)
+	if err != nil {
+		return "", fmt.Errorf("invalid expression: %w", err)
+	}
+	v, err := evalArithmetic(node)
+	if err != nil {
+		return "", err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "", errors.New("result is not a finite number")
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64), nil
+}
+
+var calculatorFuncs = map[string]func(args []float64) (float64, error){
+	"sqrt":  unary(math.Sqrt),
+	"abs":   unary(math.Abs),
+	"floor": unary(math.Floor),
+	"ceil":  unary(math.Ceil),
+	"round": unary(math.Round),
+	"pow": func(args []float64) (float64, error) {
+		if len(args) != 2 {
+			return 0, errors.New("pow takes 2 arguments")
+		}
+		return math.Pow(args[0], args[1]), nil
+	},
+}
+
+func unary(f func(float64) float64) func([]float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, errors.New("function takes 1 argument")
+		}
+		return f(args[0]), nil
+	}
+}
+
+// evalArithmetic evaluates a parsed Go expression restricted to numeric literals,
+// arithmetic operators and the calculator functions; anything else is rejected.
+func evalArithmetic(node ast.Expr) (float64, error) {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		if n.Kind != token.INT && n.Kind != token.FLOAT {
+			return 0, fmt.Errorf("unsupported literal %s", n.Value)
+		}
+		return strconv.ParseFloat(n.Value, 64)
+	case *ast.ParenExpr:
+		return evalArithmetic(n.X)
+	case *ast.UnaryExpr:
+		x, err := evalArithmetic(n.X)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case token.ADD:
+			return x, nil
+		case token.SUB:
+			return -x, nil
+		}
+		return 0, fmt.Errorf("unsupported operator %s", n.Op)
+	case *ast.BinaryExpr:
+		x, err := evalArithmetic(n.X)
+		if err != nil {
+			return 0, err
+		}
+		y, err := evalArithmetic(n.Y)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case token.ADD:
+			return x + y, nil
+		case token.SUB:
+			return x - y, nil
+		case token.MUL:
+			return x * y, nil
+		case token.QUO:
+			if y == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return x / y, nil
+		case token.REM:
+			if y == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return math.Mod(x, y), nil
+		}
+		return 0, fmt.Errorf("unsupported operator %s", n.Op)
+	case *ast.CallExpr:
+		ident, ok := n.Fun.(*ast.Ident)
+		if !ok {
+			return 0, errors.New("unsupported function call")
+		}
+		fn, ok := calculatorFuncs[ident.Name]
+		if !ok {
+			return 0, fmt.Errorf("unknown function %s", ident.Name)
+		}
+		args := make([]float64, len(n.Args))
+		for i, a := range n.Args {
+			v, err := evalArithmetic(a)
+			if err != nil {
+				return 0, err
+			}
+			args[i] = v
+		}
+		return fn(args)
+	}
+	return 0, fmt.Errorf("unsupported expression %T", node)
+}
+
+var clockTool = domain.Tool{
+	Type: domain.ToolTypeFunction,
+	Function: domain.ToolFunction{
+		Name:        "clock",
+		Description: "Get the current date and time, optionally in an IANA time zone.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"timezone":{"type":"string","description":"e.g. Europe/Paris; defaults to UTC"}}}`),
+	},
+}
+
+func runClock(_ context.Context, args map[string]any) (string, error) {
+	loc := time.UTC
+	if tz, _ := args["timezone"].(string); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return "", fmt.Errorf("unknown time zone %q", tz)
+		}
+	}
+	now := time.Now().In(loc)
+	return now.Format(time.RFC3339) + " (" + now.Weekday().String() + ")", nil
+}
+
+var readFileTool = domain.Tool{
+	Type: domain.ToolTypeFunction,
+	Function: domain.ToolFunction{
+		Name:        "read_file",
+		Description: "Read a text file from the shared document folder, or list a directory in it.",
+		Parameters:  json.RawMessage(`{"type":"object","required":["path"],"properties":{"path":{"type":"string","description":"path relative to the document folder; use . for its top level"}}}`),
+	},
+}
+
+// readFileFunc reads through an os.Root, so paths (including symlinks) cannot
+// escape the configured directory.
+func readFileFunc(root *os.Root) toolFunc {
+	return func(ctx context.Context, args map[string]any) (string, error) {
+		path, err := stringArg(args, "path")
+		if err != nil {
+			return "", err
+		}
+		f, err := root.Open(strings.TrimPrefix(path, "/"))
+		if err != nil {
+			var pathErr *os.PathError
+			if errors.As(err, &pathErr) {
+				err = pathErr.Err // don't echo the server-side path back to the model
+			}
+			return "", fmt.Errorf("cannot open %s: %w", path, err)
+		}
+		defer f.Close()
+		info, err := f.Stat()
+		if err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			entries, err := f.ReadDir(-1)
+			if err != nil {
+				return "", err
+			}
+			names := make([]string, 0, len(entries))
+			for _, e := range entries {
+				name := e.Name()
+				if e.IsDir() {
+					name += "/"
+				}
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return strings.Join(names, "\n"), nil
+		}
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxToolFileBytes+1))
+		if err != nil {
+			return "", err
+		}
+		if len(data) > maxToolFileBytes {
+			return string(data[:maxToolFileBytes]) + "\n[truncated]", nil
+		}
+		return string(data), nil
+	}
+}
@@ -0,0 +1,104 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"minivault/config"
+	"minivault/domain"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func callTool(t *testing.T, tb domain.ToolboxPort, name string, args map[string]any) (string, error) {
+	t.Helper()
+	return tb.Call(context.Background(), domain.ToolCall{Function: domain.ToolCallFunction{Name: name, Arguments: args}})
+}
+
+func TestNewToolbox_Disabled(t *testing.T) {
+	tb, err := NewToolbox(&config.Config{})
+	if err != nil || tb != nil {
+		t.Errorf("expected nil toolbox, got %v %v", tb, err)
+	}
+}
+
+func TestNewToolbox_Errors(t *testing.T) {
+	if _, err := NewToolbox(&config.Config{Tools: []string{"shell"}}); err == nil {
+		t.Error("expected error for unknown tool")
+	}
+	if _, err := NewToolbox(&config.Config{Tools: []string{"read_file"}}); err == nil {
+		t.Error("expected error for read_file without a root")
+	}
+}
+
+func TestToolbox_Tools(t *testing.T) {
+	tb, _ := NewToolbox(&config.Config{Tools: []string{"calculator", "clock"}})
+	defs, err := tb.Tools([]string{"clock"})
+	if err != nil || len(defs) != 1 || defs[0].Function.Name != "clock" || len(defs[0].Function.Parameters) == 0 {
+		t.Errorf("unexpected definitions: %+v %v", defs, err)
+	}
+	if _, err := tb.Tools([]string{"read_file"}); !errors.Is(err, domain.ErrUnknownTool) {
+		t.Errorf("expected ErrUnknownTool for a disabled tool, got %v", err)
+	}
+}
+
+func TestToolbox_Calculator(t *testing.T) {
+	tb, _ := NewToolbox(&config.Config{Tools: []string{"calculator"}})
+	cases := map[string]string{
+		"(2 + 3) * 4":      "20",
+		"-7 % 3":           "-1",
+		"pow(2, 10) / 4":   "256",
+		"sqrt(2.25) + 0.5": "2",
+	}
+	for expr, want := range cases {
+		got, err := callTool(t, tb, "calculator", map[string]any{"expression": expr})
+		if err != nil || got != want {
+			t.Errorf("%s = %q, %v; want %s", expr, got, err, want)
+		}
+	}
+	for _, expr := range []string{"1/0", "os.Exit(1)", `"a" + "b"`, "x * 2", "1 << 3"} {
+		if _, err := callTool(t, tb, "calculator", map[string]any{"expression": expr}); err == nil {
+			t.Errorf("expected error for %s", expr)
+		}
+	}
+}
+
+func TestToolbox_Clock(t *testing.T) {
+	tb, _ := NewToolbox(&config.Config{Tools: []string{"clock"}})
+	got, err := callTool(t, tb, "clock", map[string]any{"timezone": "Asia/Tokyo"})
+	if err != nil || !strings.Contains(got, "+09:00") {
+		t.Errorf("unexpected clock result %q, %v", got, err)
+	}
+	if _, err := callTool(t, tb, "clock", map[string]any{"timezone": "Mars/Olympus"}); err == nil {
+		t.Error("expected error for unknown time zone")
+	}
+}
+
+func TestToolbox_ReadFileSandbox(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "docs")
+	os.MkdirAll(filepath.Join(root, "sub"), 0755)
+	os.WriteFile(filepath.Join(root, "sub", "a.txt"), []byte("hello"), 0644)
+	os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("nope"), 0644)
+	os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "link.txt"))
+
+	tb, err := NewToolbox(&config.Config{Tools: []string{"read_file"}, ToolsFileRoot: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := callTool(t, tb, "read_file", map[string]any{"path": "sub/a.txt"}); err != nil || got != "hello" {
+		t.Errorf("read: %q, %v", got, err)
+	}
+	if got, err := callTool(t, tb, "read_file", map[string]any{"path": "."}); err != nil || got != "link.txt\nsub/" {
+		t.Errorf("list: %q, %v", got, err)
+	}
+	for _, path := range []string{"../secret.txt", "link.txt", "/etc/passwd"} {
+		got, err := callTool(t, tb, "read_file", map[string]any{"path": path})
+		if err == nil {
+			t.Errorf("expected %s to be rejected, got %q", path, got)
+		} else if strings.Contains(err.Error(), dir) {
+			t.Errorf("error leaks server path: %v", err)
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// upstream is one Ollama host in the pool.
+type upstream struct {
+	baseURL string
+	weight  int
+	chat    *ollamaClient
+	embed   *ollamaEmbedder
+	manager *modelManager
+
+	outstanding atomic.Int64
+
+	// guarded by upstreamPool.mu
+	healthy      bool
+	checkErr     string // why the last health check failed
+	failures     int    // consecutive
+	ejectedUntil time.Time
+	models       map[string]bool // installed models; nil until the first health check
+}
+
+// upstreamPool implements domain.UpstreamPoolPort. Each call goes to the eligible
+// upstream with the fewest outstanding requests per unit of weight. An upstream is
+// eligible when its last health check passed, it is not ejected, and its inventory
+// has the requested model. Consecutive connection failures, 5xx answers and
+// timeouts eject an upstream for a while; a call that could not be served is retried
+// on each other eligible upstream.
+type upstreamPool struct {
+	upstreams  []*upstream
+	logger     domain.LoggerPort
+	chatModel  string
+	embedModel string
+
+	interval  time.Duration
+	maxFails  int
+	ejectTime time.Duration
+	checker   *http.Client
+	now       func() time.Time
+
+	mu   sync.Mutex
+	next int // rotates the starting point so ties spread across upstreams
+}
+
+// NewUpstreamPool parses OLLAMA_UPSTREAMS. It returns nil when no upstreams are configured.
+func NewUpstreamPool(cfg *config.Config, logger domain.LoggerPort) (domain.UpstreamPoolPort, error) {
+	if len(cfg.OllamaUpstreams) == 0 {
+		return nil, nil
+	}
+	p := &upstreamPool{
+		logger:     logger,
+		chatModel:  cfg.OllamaModel,
+		embedModel: cfg.EmbedModel,
+		interval:   cfg.UpstreamHealthInterval,
+		maxFails:   max(cfg.UpstreamMaxFails, 1),
+		ejectTime:  cfg.UpstreamEjectTime,
+		checker:    &http.Client{Timeout: 5 * time.Second},
+		now:        time.Now,
+	}
+	for _, entry := range cfg.OllamaUpstreams {
+		fields := strings.Split(entry, ";")
+		base := strings.TrimSuffix(strings.TrimSpace(fields[0]), "/")
+		if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+			return nil, fmt.Errorf("invalid OLLAMA_UPSTREAMS url in %q", entry)
+		}
+		base = strings.TrimSuffix(base, "/api") + "/api"
+		u := &upstream{
+			baseURL: base,
+			weight:  1,
+			chat:    newOllamaClient(base+"/chat", cfg.OllamaModel, cfg.OllamaKeepAlive, cfg.OllamaTimeout),
+			embed:   newOllamaEmbedder(base+"/embed", cfg.EmbedModel, cfg.OllamaKeepAlive, cfg.OllamaTimeout),
+			manager: &modelManager{httpClient: &http.Client{}, apiURL: base},
+			healthy: true, // until a check says otherwise, so commands without monitoring work
+		}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(strings.TrimSpace(field), "=")
+			if key != "weight" {
+				return nil, fmt.Errorf("unknown OLLAMA_UPSTREAMS option %q in %q", key, entry)
+			}
+			w, err := strconv.Atoi(value)
+			if err != nil || w < 1 {
+				return nil, fmt.Errorf("invalid OLLAMA_UPSTREAMS weight in %q", entry)
+			}
+			u.weight = w
+		}
+		p.upstreams = append(p.upstreams, u)
+	}
+	return p, nil
+}
+
+// CallOllama implements OllamaPort
+func (p *upstreamPool) CallOllama(ctx context.Context, req domain.OllamaChatRequest) (*domain.OllamaChatResponse, error) {
+	if req.Model == "" {
+		req.Model = p.chatModel
+	}
+	return dispatch(ctx, p, req.Model, func(u *upstream) (*domain.OllamaChatResponse, error) {
+		return u.chat.CallOllama(ctx, req)
+	})
+}
+
+// Embed implements EmbeddingPort
+func (p *upstreamPool) Embed(ctx context.Context, req domain.OllamaEmbedRequest) (*domain.OllamaEmbedResponse, error) {
+	if req.Model == "" {
+		req.Model = p.embedModel
+	}
+	return dispatch(ctx, p, req.Model, func(u *upstream) (*domain.OllamaEmbedResponse, error) {
+		return u.embed.Embed(ctx, req)
+	})
+}
+
+// ListModels implements ModelManagerPort, merging the models installed on every
+// upstream that answers.
+func (p *upstreamPool) ListModels(ctx context.Context) ([]domain.ModelSummary, error) {
+	var models []domain.ModelSummary
+	seen := make(map[string]bool)
+	var errs []error
+	for _, u := range p.upstreams {
+		list, err := u.manager.ListModels(ctx)
+		if err != nil {
+			errs = append(errs, p.upstreamErr(u, err))
+			continue
+		}
+		for _, m := range list {
+			if !seen[m.Name] {
+				seen[m.Name] = true
+				models = append(models, m)
+			}
+		}
+	}
+	if len(errs) == len(p.upstreams) {
+		return nil, errors.Join(errs...)
+	}
+	return models, nil
+}
+
+// ShowModel implements ModelManagerPort, answering from the first upstream that has the model.
+func (p *upstreamPool) ShowModel(ctx context.Context, name string) (domain.ModelInfo, error) {
+	err := domain.ErrModelNotFound
+	for _, u := range p.upstreams {
+		info, showErr := u.manager.ShowModel(ctx, name)
+		if showErr == nil {
+			return info, nil
+		}
+		if !errors.Is(showErr, domain.ErrModelNotFound) {
+			err = p.upstreamErr(u, showErr)
+		}
+	}
+	return nil, err
+}
+
+// PullModel implements ModelManagerPort, pulling onto every upstream in turn so
+// that any of them can serve the model.
+func (p *upstreamPool) PullModel(ctx context.Context, name string, progress func(domain.PullProgress)) error {
+	return p.each(name, true, func(u *upstream) error {
+		return u.manager.PullModel(ctx, name, progress)
+	})
+}
+
+// DeleteModel implements ModelManagerPort, removing the model from every upstream.
+// It returns ErrModelNotFound only if no upstream had it.
+func (p *upstreamPool) DeleteModel(ctx context.Context, name string) error {
+	return p.each(name, false, func(u *upstream) error {
+		return u.manager.DeleteModel(ctx, name)
+	})
+}
+
+// RunningModels implements ModelManagerPort, listing the models loaded on every
+// upstream that answers.
+func (p *upstreamPool) RunningModels(ctx context.Context) ([]domain.RunningModel, error) {
+	var running []domain.RunningModel
+	var errs []error
+	for _, u := range p.upstreams {
+		list, err := u.manager.RunningModels(ctx)
+		if err != nil {
+			errs = append(errs, p.upstreamErr(u, err))
+			continue
+		}
+		running = append(running, list...)
+	}
+	if len(errs) == len(p.upstreams) {
+		return nil, errors.Join(errs...)
+	}
+	return running, nil
+}
+
+// LoadModel implements ModelManagerPort, loading the model on every upstream that
+// has it, so warm-up and keep-warm cover whichever host a request lands on.
+func (p *upstreamPool) LoadModel(ctx context.Context, name string, keepAlive domain.KeepAlive) error {
+	return p.each(name, true, func(u *upstream) error {
+		return u.manager.LoadModel(ctx, name, keepAlive)
+	})
+}
+
+// each runs a model management call on every upstream, one at a time, and keeps
+// their inventories in step with the outcome. Upstreams without the model are
+// skipped; ErrModelNotFound is returned only when none had it.
+func (p *upstreamPool) each(model string, installs bool, call func(*upstream) error) error {
+	var errs []error
+	found := false
+	for _, u := range p.upstreams {
+		err := call(u)
+		if errors.Is(err, domain.ErrModelNotFound) {
+			continue
+		}
+		found = true
+		if err != nil {
+			errs = append(errs, p.upstreamErr(u, err))
+			continue
+		}
+		p.mu.Lock()
+		if u.models != nil {
+			if installs {
+				u.models[canonicalModel(model)] = true
+			} else {
+				delete(u.models, canonicalModel(model))
+			}
+		}
+		p.mu.Unlock()
+	}
+	if !found {
+		return domain.ErrModelNotFound
+	}
+	return errors.Join(errs...)
+}
+
+func (p *upstreamPool) upstreamErr(u *upstream, err error) error {
+	return fmt.Errorf("upstream %s: %w", config.RedactURL(u.baseURL), err)
+}
+
+// dispatch sends a call to the best upstream for model, moving on to the next best
+// when an upstream is unavailable or turns out not to have the model.
+func dispatch[T any](ctx context.Context, p *upstreamPool, model string, call func(*upstream) (T, error)) (T, error) {
+	tried := make(map[*upstream]bool)
+	var lastErr error
+	for {
+		u, err := p.pick(model, tried)
+		if err != nil {
+			if lastErr != nil {
+				err = lastErr
+			}
+			var zero T
+			return zero, err
+		}
+		tried[u] = true
+		u.outstanding.Add(1)
+		resp, err := call(u)
+		u.outstanding.Add(-1)
+		p.report(ctx, u, model, err)
+		// calls have no side effects, so one an upstream could not serve can be repeated
+		retry := errors.Is(err, domain.ErrUpstreamUnavailable) || errors.Is(err, domain.ErrModelNotFound)
+		if err == nil || ctx.Err() != nil || !retry {
+			return resp, err
+		}
+		lastErr = err
+	}
+}
+
+// pick returns the eligible upstream with the lowest (outstanding+1)/weight.
+func (p *upstreamPool) pick(model string, exclude map[*upstream]bool) (*upstream, error) {
+	model = canonicalModel(model)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	var best *upstream
+	var bestScore float64
+	available := false
+	n := len(p.upstreams)
+	for i := range n {
+		u := p.upstreams[(p.next+i)%n]
+		if exclude[u] || !u.healthy || now.Before(u.ejectedUntil) {
+			continue
+		}
+		available = true
+		if u.models != nil && !u.models[model] {
+			continue
+		}
+		score := float64(u.outstanding.Load()+1) / float64(u.weight)
+		if best == nil || score < bestScore {
+			best, bestScore = u, score
+		}
+	}
+	p.next = (p.next + 1) % n
+	switch {
+	case best != nil:
+		return best, nil
+	case available:
+		return nil, fmt.Errorf("%w: no healthy upstream has %s installed", domain.ErrModelNotFound, model)
+	default:
+		return nil, fmt.Errorf("%w: no healthy upstream", domain.ErrUpstreamUnavailable)
+	}
+}
+
+// Status implements UpstreamPoolPort
+func (p *upstreamPool) Status() []domain.UpstreamStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	status := make([]domain.UpstreamStatus, 0, len(p.upstreams))
+	for _, u := range p.upstreams {
+		s := domain.UpstreamStatus{URL: config.RedactURL(u.baseURL), Healthy: u.healthy, Outstanding: u.outstanding.Load(), Error: u.checkErr}
+		if now.Before(u.ejectedUntil) {
+			until := u.ejectedUntil
+			s.EjectedUntil = &until
+		}
+		status = append(status, s)
+	}
+	return status
+}
+
+// report applies the outcome of a call for passive health tracking.
+func (p *upstreamPool) report(ctx context.Context, u *upstream, model string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case err == nil:
+		u.failures = 0
+	case errors.Is(err, domain.ErrModelNotFound):
+		// the inventory was stale; the next health check restores it if the model returns
+		delete(u.models, canonicalModel(model))
+	case ctx.Err() != nil:
+		// the caller gave up; that says nothing about the upstream
+	case errors.Is(err, domain.ErrUpstreamUnavailable), errors.Is(err, domain.ErrUpstreamTimeout):
+		u.failures++
+		if u.failures >= p.maxFails {
+			u.failures = 0
+			u.ejectedUntil = p.now().Add(p.ejectTime)
+			p.logger.LogWarn(fmt.Sprintf("upstream %s ejected for %s after %d consecutive failures: %v", u.baseURL, p.ejectTime, p.maxFails, err))
+		}
+	}
+}
+
+// MonitorHealth implements UpstreamPoolPort. Each check lists the upstream's models,
+// which both proves it is serving and refreshes its inventory.
+func (p *upstreamPool) MonitorHealth(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *upstreamPool) checkAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, u := range p.upstreams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			models, err := p.inventory(ctx, u)
+			if ctx.Err() != nil {
+				return
+			}
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if err != nil {
+				if u.healthy {
+					p.logger.LogWarn(fmt.Sprintf("upstream %s failed its health check: %v", u.baseURL, err))
+				}
+				u.healthy, u.checkErr = false, err.Error()
+				return
+			}
+			if !u.healthy {
+				p.logger.LogInfo(fmt.Sprintf("upstream %s is healthy again", u.baseURL))
+			}
+			u.healthy, u.checkErr = true, ""
+			u.models = models
+		}()
+	}
+	wg.Wait()
+}
+
+func (p *upstreamPool) inventory(ctx context.Context, u *upstream) (map[string]bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, u.baseURL+"/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := p.checker.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	var tags struct {
+		Models []domain.ModelSummary `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+	models := make(map[string]bool, len(tags.Models))
+	for _, m := range tags.Models {
+		models[canonicalModel(m.Name)] = true
+	}
+	return models, nil
+}
+
+// canonicalModel adds Ollama's implicit ":latest" tag, so "llama3" matches "llama3:latest".
+func canonicalModel(name string) string {
+	if !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		return name + ":latest"
+	}
+	return name
+}
@@ -0,0 +1,229 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"io"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/mocks"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestPool(t *testing.T, upstreams []string, rt roundTripFunc) (*upstreamPool, *mocks.MockLogger) {
+	t.Helper()
+	logger := &mocks.MockLogger{}
+	port, err := NewUpstreamPool(&config.Config{
+		OllamaUpstreams:   upstreams,
+		OllamaModel:       "gemma:2b",
+		UpstreamMaxFails:  2,
+		UpstreamEjectTime: time.Minute,
+	}, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := port.(*upstreamPool)
+	p.checker = &http.Client{Transport: rt}
+	for _, u := range p.upstreams {
+		u.chat.httpClient = &http.Client{Transport: rt}
+		u.embed.httpClient = &http.Client{Transport: rt}
+		u.manager.httpClient = &http.Client{Transport: rt}
+	}
+	return p, logger
+}
+
+func reply(status int, body string) (*http.Response, error) {
+	return &http.Response{StatusCode: status, Body: io.NopCloser(strings.NewReader(body))}, nil
+}
+
+func TestUpstreamPool_ParsesEntries(t *testing.T) {
+	p, _ := newTestPool(t, []string{"http://ws1:11434", "http://ws2:11434/api/;weight=3"}, nil)
+	if p.upstreams[0].baseURL != "http://ws1:11434/api" || p.upstreams[1].baseURL != "http://ws2:11434/api" {
+		t.Errorf("unexpected base URLs: %s, %s", p.upstreams[0].baseURL, p.upstreams[1].baseURL)
+	}
+	if p.upstreams[0].weight != 1 || p.upstreams[1].weight != 3 {
+		t.Errorf("unexpected weights: %d, %d", p.upstreams[0].weight, p.upstreams[1].weight)
+	}
+	for _, bad := range []string{"ws1:11434", "http://ws1;weight=0", "http://ws1;zone=a"} {
+		if _, err := NewUpstreamPool(&config.Config{OllamaUpstreams: []string{bad}}, &mocks.MockLogger{}); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestUpstreamPool_LeastOutstandingPerWeight(t *testing.T) {
+	p, _ := newTestPool(t, []string{"http://ws1", "http://ws2;weight=2", "http://ws3"}, nil)
+	ws1, ws2, ws3 := p.upstreams[0], p.upstreams[1], p.upstreams[2]
+	ws1.outstanding.Store(1)
+	ws2.outstanding.Store(3) // (3+1)/2 = 2
+	ws3.outstanding.Store(0)
+	if u, _ := p.pick("gemma:2b", nil); u != ws3 {
+		t.Errorf("expected idle ws3, got %s", u.baseURL)
+	}
+	ws3.outstanding.Store(4)
+	ws2.outstanding.Store(1) // (1+1)/2 = 1 beats ws1 with (1+1)/1 = 2
+	if u, _ := p.pick("gemma:2b", nil); u != ws2 {
+		t.Errorf("expected weighted ws2, got %s", u.baseURL)
+	}
+}
+
+func TestUpstreamPool_RoutesByInventory(t *testing.T) {
+	var served []string
+	p, _ := newTestPool(t, []string{"http://ws1", "http://ws2"}, func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/api/tags" {
+			if r.URL.Host == "ws2" {
+				return reply(200, `{"models":[{"name":"llama3:latest"},{"name":"gemma:2b"}]}`)
+			}
+			return reply(200, `{"models":[{"name":"gemma:2b"}]}`)
+		}
+		served = append(served, r.URL.Host)
+		return reply(200, `{"message":{"role":"assistant","content":"ok"}}`)
+	})
+	p.checkAll(context.Background())
+
+	for range 3 {
+		if _, err := p.CallOllama(context.Background(), domain.OllamaChatRequest{Model: "llama3"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if strings.Join(served, ",") != "ws2,ws2,ws2" {
+		t.Errorf("llama3 should only go to ws2, went to %v", served)
+	}
+
+	_, err := p.CallOllama(context.Background(), domain.OllamaChatRequest{Model: "phi3:mini"})
+	if !errors.Is(err, domain.ErrModelNotFound) {
+		t.Errorf("expected ErrModelNotFound for a model no upstream has, got %v", err)
+	}
+}
+
+func TestUpstreamPool_RetriesAndEjects(t *testing.T) {
+	calls := map[string]int{}
+	p, logger := newTestPool(t, []string{"http://ws1", "http://ws2"}, func(r *http.Request) (*http.Response, error) {
+		calls[r.URL.Host]++
+		if r.URL.Host == "ws1" {
+			return reply(503, "overloaded")
+		}
+		return reply(200, `{"message":{"role":"assistant","content":"ok"}}`)
+	})
+	now := time.Now()
+	p.now = func() time.Time { return now }
+
+	for range 2 {
+		p.upstreams[1].outstanding.Store(5) // make ws1 the first choice
+		if _, err := p.CallOllama(context.Background(), domain.OllamaChatRequest{}); err != nil {
+			t.Fatalf("expected retry on ws2 to succeed: %v", err)
+		}
+	}
+	if calls["ws1"] != 2 || calls["ws2"] != 2 {
+		t.Errorf("unexpected calls: %v", calls)
+	}
+	if len(logger.Warnings) != 1 || !strings.Contains(logger.Warnings[0], "ejected") {
+		t.Fatalf("expected ws1 to be ejected after 2 failures, got %v", logger.Warnings)
+	}
+
+	p.CallOllama(context.Background(), domain.OllamaChatRequest{})
+	if calls["ws1"] != 2 {
+		t.Error("an ejected upstream should get no traffic")
+	}
+	if status := p.Status(); len(status) != 2 || status[0].EjectedUntil == nil || status[1].EjectedUntil != nil || status[1].URL != "http://ws2/api" {
+		t.Errorf("expected ws1 to be reported as ejected, got %+v", status)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if u, _ := p.pick("gemma:2b", map[*upstream]bool{p.upstreams[1]: true}); u != p.upstreams[0] {
+		t.Error("expected ws1 back after the ejection time")
+	}
+}
+
+func TestUpstreamPool_HealthChecks(t *testing.T) {
+	down := true
+	p, logger := newTestPool(t, []string{"http://ws1"}, func(r *http.Request) (*http.Response, error) {
+		if down {
+			return nil, errors.New("connection refused")
+		}
+		return reply(200, `{"models":[{"name":"gemma:2b"}]}`)
+	})
+
+	p.checkAll(context.Background())
+	_, err := p.CallOllama(context.Background(), domain.OllamaChatRequest{})
+	if !errors.Is(err, domain.ErrUpstreamUnavailable) || len(logger.Warnings) != 1 {
+		t.Errorf("expected the failed check to take ws1 out: %v %v", err, logger.Warnings)
+	}
+	if status := p.Status(); status[0].Healthy || !strings.Contains(status[0].Error, "connection refused") {
+		t.Errorf("expected the failed check to be reported, got %+v", status)
+	}
+
+	down = false
+	p.checkAll(context.Background())
+	if u, err := p.pick("gemma:2b", nil); err != nil || u != p.upstreams[0] || len(logger.Infos) != 1 {
+		t.Errorf("expected ws1 to recover: %v %v", err, logger.Infos)
+	}
+}
+
+func TestCanonicalModel(t *testing.T) {
+	for in, want := range map[string]string{
+		"llama3":                "llama3:latest",
+		"gemma:2b":              "gemma:2b",
+		"registry:5000/org/m":   "registry:5000/org/m:latest",
+		"hf.co/org/repo:Q4_K_M": "hf.co/org/repo:Q4_K_M",
+	} {
+		if got := canonicalModel(in); got != want {
+			t.Errorf("canonicalModel(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestUpstreamPool_ManagesModelsOnEveryHost(t *testing.T) {
+	var mu sync.Mutex // health checks reach the hosts concurrently
+	var calls []string
+	p, _ := newTestPool(t, []string{"http://ws1", "http://ws2"}, func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.Host+r.URL.Path)
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/api/tags" && r.URL.Host == "ws1":
+			return reply(200, `{"models":[{"name":"gemma:2b"},{"name":"llama3:latest"}]}`)
+		case r.URL.Path == "/api/tags":
+			return reply(200, `{"models":[{"name":"gemma:2b"}]}`)
+		case r.URL.Path == "/api/pull":
+			return reply(200, `{"status":"success"}`)
+		case r.URL.Path == "/api/delete":
+			if body, _ := io.ReadAll(r.Body); r.URL.Host == "ws2" || !strings.Contains(string(body), "llama3") {
+				return reply(404, `{"error":"model not found"}`)
+			}
+		case r.URL.Path == "/api/generate" && r.URL.Host == "ws2":
+			return reply(500, `boom`)
+		}
+		return reply(200, `{}`)
+	})
+	p.checkAll(context.Background())
+
+	models, err := p.ListModels(context.Background())
+	if err != nil || len(models) != 2 {
+		t.Fatalf("expected the hosts' models merged, got %v %v", models, err)
+	}
+	pulls := 0
+	if err := p.PullModel(context.Background(), "phi3", func(domain.PullProgress) { pulls++ }); err != nil || pulls != 2 {
+		t.Fatalf("expected a pull on each host, got %d updates and %v", pulls, err)
+	}
+	if _, err := p.pick("phi3", nil); err != nil {
+		t.Errorf("a pulled model should be routable before the next health check: %v", err)
+	}
+	if err := p.DeleteModel(context.Background(), "llama3"); err != nil {
+		t.Errorf("a model missing on one host should still delete: %v", err)
+	}
+	if err := p.DeleteModel(context.Background(), "nothing"); !errors.Is(err, domain.ErrModelNotFound) {
+		t.Errorf("expected not found when no host has the model, got %v", err)
+	}
+	if err := p.LoadModel(context.Background(), "gemma:2b", ""); err == nil || !strings.Contains(err.Error(), "ws2") {
+		t.Errorf("expected the failing host to be named, got %v", err)
+	}
+	if !slices.Contains(calls, "POST ws1/api/generate") || !slices.Contains(calls, "POST ws2/api/generate") {
+		t.Errorf("expected warm-up loads on both hosts, got %v", calls)
+	}
+}
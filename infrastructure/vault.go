@@ -0,0 +1,203 @@
+package infrastructure
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"os"
+	"strings"
+)
+
+// VaultAlgorithm is recorded in the "enc" field of encrypted interaction records.
+const VaultAlgorithm = "aes-256-gcm"
+
+// vaultFields are the interaction record fields that are encrypted at rest.
+var vaultFields = []string{"prompt", "response"}
+
+// vault implements domain.VaultPort with AES-256-GCM over a keyring of named keys.
+// New data is sealed with the active key; any key in the ring can open old data.
+type vault struct {
+	keys     map[string]cipher.AEAD
+	activeID string
+}
+
+// NewVault loads the keyring described by config. It returns nil, nil when
+// encryption at rest is disabled.
+//
+// Keys come from VAULT_KEY (base64, named VAULT_KEY_ID) and/or VAULT_KEY_FILE,
+// which holds one "id=base64key" per line. The active key is VAULT_KEY_ID if set,
+// otherwise the last key in the file.
+func NewVault(cfg *config.Config) (domain.VaultPort, error) {
+	if !cfg.VaultEncrypt {
+		return nil, nil
+	}
+	v := &vault{keys: make(map[string]cipher.AEAD)}
+	if cfg.VaultKeyFile != "" {
+		if err := v.loadKeyFile(cfg.VaultKeyFile); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.VaultKey != "" {
+		id := cfg.VaultKeyID
+		if id == "" {
+			id = "default"
+		}
+		if err := v.addKey(id, cfg.VaultKey); err != nil {
+			return nil, fmt.Errorf("VAULT_KEY: %w", err)
+		}
+		v.activeID = id
+	}
+	if cfg.VaultKeyID != "" {
+		if _, ok := v.keys[cfg.VaultKeyID]; !ok {
+			return nil, fmt.Errorf("active vault key %q not found in keyring", cfg.VaultKeyID)
+		}
+		v.activeID = cfg.VaultKeyID
+	}
+	if v.activeID == "" {
+		return nil, errors.New("vault encryption enabled but no key configured (set VAULT_KEY or VAULT_KEY_FILE)")
+	}
+	return v, nil
+}
+
+func (v *vault) loadKeyFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open vault key file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		id, key, ok := strings.Cut(line, "=")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" {
+			return fmt.Errorf("vault key file %s:%d: expected id=base64key", path, lineNo)
+		}
+		if err := v.addKey(id, strings.TrimSpace(key)); err != nil {
+			return fmt.Errorf("vault key file %s:%d: %w", path, lineNo, err)
+		}
+		v.activeID = id
+	}
+	return scanner.Err()
+}
+
+func (v *vault) addKey(id, encoded string) error {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("key %q is not valid base64: %w", id, err)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("key %q must be 32 bytes, got %d", id, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	v.keys[id] = aead
+	return nil
+}
+
+func (v *vault) ActiveKeyID() string {
+	return v.activeID
+}
+
+// Seal encrypts plaintext with the active key. The field name and key ID are bound
+// as additional data so ciphertexts cannot be swapped between fields or keys.
+func (v *vault) Seal(field, plaintext string) (string, string, error) {
+	aead := v.keys[v.activeID]
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), vaultAAD(v.activeID, field))
+	return base64.StdEncoding.EncodeToString(sealed), v.activeID, nil
+}
+
+// Open decrypts a value produced by Seal with the named key.
+func (v *vault) Open(keyID, field, sealed string) (string, error) {
+	aead, ok := v.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", domain.ErrUnknownVaultKey, keyID)
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", domain.ErrVaultDecrypt
+	}
+	plaintext, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], vaultAAD(keyID, field))
+	if err != nil {
+		return "", domain.ErrVaultDecrypt
+	}
+	return string(plaintext), nil
+}
+
+func vaultAAD(keyID, field string) []byte {
+	return []byte("minivault/" + keyID + "/" + field)
+}
+
+// EncryptRecord seals the prompt/response fields of a decoded log record in place
+// with the active key. Records already sealed with another key are re-encrypted.
+func EncryptRecord(v domain.VaultPort, rec map[string]any) error {
+	if err := DecryptRecord(v, rec); err != nil {
+		return err
+	}
+	var keyID string
+	for _, field := range vaultFields {
+		plain, ok := rec[field].(string)
+		if !ok {
+			continue
+		}
+		sealed, id, err := v.Seal(field, plain)
+		if err != nil {
+			return err
+		}
+		rec[field] = sealed
+		keyID = id
+	}
+	if keyID != "" {
+		rec["enc"] = VaultAlgorithm
+		rec["key_id"] = keyID
+	}
+	return nil
+}
+
+// DecryptRecord opens the prompt/response fields of a decoded log record in place.
+// Plaintext records are left untouched.
+func DecryptRecord(v domain.VaultPort, rec map[string]any) error {
+	if rec["enc"] != VaultAlgorithm {
+		return nil
+	}
+	if v == nil {
+		return domain.ErrUnknownVaultKey
+	}
+	keyID, _ := rec["key_id"].(string)
+	for _, field := range vaultFields {
+		sealed, ok := rec[field].(string)
+		if !ok {
+			continue
+		}
+		plain, err := v.Open(keyID, field, sealed)
+		if err != nil {
+			return err
+		}
+		rec[field] = plain
+	}
+	delete(rec, "enc")
+	delete(rec, "key_id")
+	return nil
+}
@@ -0,0 +1,109 @@
+package infrastructure
+
+import (
+	"encoding/base64"
+	"errors"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/mocks"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), 32)))
+}
+
+func TestVault_SealOpen(t *testing.T) {
+	v, err := NewVault(&config.Config{VaultEncrypt: true, VaultKey: testKey('a'), VaultKeyID: "k1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, keyID, err := v.Seal("prompt", "top secret")
+	if err != nil || keyID != "k1" {
+		t.Fatalf("unexpected seal result: %q %v", keyID, err)
+	}
+	if strings.Contains(sealed, "top secret") {
+		t.Error("ciphertext contains plaintext")
+	}
+	plain, err := v.Open(keyID, "prompt", sealed)
+	if err != nil || plain != "top secret" {
+		t.Errorf("unexpected open result: %q %v", plain, err)
+	}
+	if _, err := v.Open(keyID, "response", sealed); !errors.Is(err, domain.ErrVaultDecrypt) {
+		t.Errorf("ciphertext should be bound to its field, got %v", err)
+	}
+}
+
+func TestVault_KeyFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys")
+	os.WriteFile(path, []byte("# keyring\nold="+testKey('o')+"\nnew="+testKey('n')+"\n"), 0600)
+
+	oldVault, err := NewVault(&config.Config{VaultEncrypt: true, VaultKeyFile: path, VaultKeyID: "old"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := map[string]any{"prompt": "p", "response": "r", "message": "generation interaction"}
+	if err := EncryptRecord(oldVault, rec); err != nil || rec["key_id"] != "old" {
+		t.Fatalf("unexpected encrypt result: %v %v", rec, err)
+	}
+
+	newVault, err := NewVault(&config.Config{VaultEncrypt: true, VaultKeyFile: path})
+	if err != nil || newVault.ActiveKeyID() != "new" {
+		t.Fatalf("last key in file should be active: %v", err)
+	}
+	if err := EncryptRecord(newVault, rec); err != nil || rec["key_id"] != "new" {
+		t.Fatalf("rekey failed: %v %v", rec, err)
+	}
+	if err := DecryptRecord(newVault, rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec["prompt"] != "p" || rec["response"] != "r" || rec["enc"] != nil || rec["message"] != "generation interaction" {
+		t.Errorf("unexpected decrypted record: %v", rec)
+	}
+}
+
+func TestVault_UnknownKey(t *testing.T) {
+	v, _ := NewVault(&config.Config{VaultEncrypt: true, VaultKey: testKey('a')})
+	rec := map[string]any{"prompt": "x", "enc": VaultAlgorithm, "key_id": "gone"}
+	if err := DecryptRecord(v, rec); !errors.Is(err, domain.ErrUnknownVaultKey) {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestVault_Config(t *testing.T) {
+	if v, err := NewVault(&config.Config{}); v != nil || err != nil {
+		t.Error("disabled vault should be nil")
+	}
+	if _, err := NewVault(&config.Config{VaultEncrypt: true}); err == nil {
+		t.Error("expected error without a key")
+	}
+	if _, err := NewVault(&config.Config{VaultEncrypt: true, VaultKey: "c2hvcnQ="}); err == nil {
+		t.Error("expected error for short key")
+	}
+}
+
+func TestLogger_EncryptsInteractionsAndRewrite(t *testing.T) {
+	dir := t.TempDir()
+	v, _ := NewVault(&config.Config{VaultEncrypt: true, VaultKey: testKey('a'), VaultKeyID: "k1"})
+	l := NewLogger(&config.Config{LogDir: dir}, &mocks.MockRedactor{}, v, nil)
+	l.LogInteraction(domain.Interaction{ID: "i1", Prompt: "the prompt", Response: "the response"})
+	l.Close()
+
+	path := filepath.Join(dir, InteractionLogName)
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "the prompt") || !strings.Contains(string(data), `"key_id":"k1"`) {
+		t.Fatalf("expected encrypted record, got %s", data)
+	}
+
+	if err := RewriteLogFile(path, func(rec map[string]any) error { return DecryptRecord(v, rec) }); err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]any
+	ReadLogRecords(path, func(rec map[string]any) error { got = rec; return nil })
+	if got["prompt"] != "the prompt" || got["response"] != "the response" {
+		t.Errorf("unexpected rewritten record: %v", got)
+	}
+}
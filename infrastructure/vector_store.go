@@ -0,0 +1,164 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"minivault/config"
+	"minivault/domain"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// vectorCollection is the on-disk form of one collection. Norms are derived on load.
+type vectorCollection struct {
+	Dimensions int            `json:"dimensions"`
+	Chunks     []domain.Chunk `json:"chunks"`
+	norms      []float64
+}
+
+// vectorStore implements domain.VectorStorePort with one JSON file per collection in
+// dir. Collections are loaded into memory on first use and searched exhaustively,
+// which is plenty for the document volumes a single local deployment ingests.
+type vectorStore struct {
+	dir string
+
+	mu          sync.Mutex
+	collections map[string]*vectorCollection
+}
+
+func NewVectorStore(cfg *config.Config) domain.VectorStorePort {
+	return &vectorStore{dir: cfg.RAGDir, collections: make(map[string]*vectorCollection)}
+}
+
+// Upsert implements VectorStorePort
+func (s *vectorStore) Upsert(collection, documentID string, chunks []domain.Chunk) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, err := s.load(collection)
+	if errors.Is(err, domain.ErrCollectionNotFound) {
+		c, err = &vectorCollection{}, nil
+	}
+	if err != nil {
+		return err
+	}
+
+	updated := &vectorCollection{Dimensions: c.Dimensions}
+	for i, chunk := range c.Chunks {
+		if chunk.DocumentID != documentID {
+			updated.Chunks = append(updated.Chunks, chunk)
+			updated.norms = append(updated.norms, c.norms[i])
+		}
+	}
+	if len(updated.Chunks) == 0 {
+		updated.Dimensions = 0 // an emptied collection may switch embedding models
+	}
+	for _, chunk := range chunks {
+		if updated.Dimensions == 0 {
+			updated.Dimensions = len(chunk.Embedding)
+		}
+		if len(chunk.Embedding) != updated.Dimensions {
+			return fmt.Errorf("%w: got %d, collection has %d", domain.ErrEmbeddingDimensions, len(chunk.Embedding), updated.Dimensions)
+		}
+		updated.Chunks = append(updated.Chunks, chunk)
+		updated.norms = append(updated.norms, norm(chunk.Embedding))
+	}
+
+	if err := s.save(collection, updated); err != nil {
+		return err
+	}
+	s.collections[collection] = updated
+	return nil
+}
+
+// Search implements VectorStorePort
+func (s *vectorStore) Search(collection string, vector []float64, k int) ([]domain.ScoredChunk, error) {
+	s.mu.Lock()
+	c, err := s.load(collection)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// collections are replaced on upsert, never modified, so c can be read unlocked
+	if len(c.Chunks) > 0 && len(vector) != c.Dimensions {
+		return nil, fmt.Errorf("%w: got %d, collection has %d", domain.ErrEmbeddingDimensions, len(vector), c.Dimensions)
+	}
+	qnorm := norm(vector)
+	hits := make([]domain.ScoredChunk, 0, len(c.Chunks))
+	for i, chunk := range c.Chunks {
+		var score float64
+		if qnorm > 0 && c.norms[i] > 0 {
+			score = dot(vector, chunk.Embedding) / (qnorm * c.norms[i])
+		}
+		hits = append(hits, domain.ScoredChunk{Chunk: chunk, Score: score})
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if k < len(hits) {
+		hits = hits[:k]
+	}
+	return hits, nil
+}
+
+// load returns a cached collection or reads it from disk. Callers hold s.mu.
+func (s *vectorStore) load(collection string) (*vectorCollection, error) {
+	if c, ok := s.collections[collection]; ok {
+		return c, nil
+	}
+	data, err := os.ReadFile(s.path(collection))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %q", domain.ErrCollectionNotFound, collection)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &vectorCollection{}
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to decode collection %q: %w", collection, err)
+	}
+	c.norms = make([]float64, len(c.Chunks))
+	for i, chunk := range c.Chunks {
+		c.norms[i] = norm(chunk.Embedding)
+	}
+	s.collections[collection] = c
+	return c, nil
+}
+
+// save atomically replaces a collection's file.
+func (s *vectorStore) save(collection string, c *vectorCollection) error {
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, collection+".json.tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	err = json.NewEncoder(tmp).Encode(c)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write collection %q: %w", collection, err)
+	}
+	return os.Rename(tmp.Name(), s.path(collection))
+}
+
+func (s *vectorStore) path(collection string) string {
+	return filepath.Join(s.dir, collection+".json")
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func norm(v []float64) float64 {
+	return math.Sqrt(dot(v, v))
+}
@@ -0,0 +1,51 @@
+package infrastructure
+
+import (
+	"errors"
+	"minivault/config"
+	"minivault/domain"
+	"testing"
+)
+
+func TestVectorStore_SearchPersistAndReplace(t *testing.T) {
+	cfg := &config.Config{RAGDir: t.TempDir()}
+	store := NewVectorStore(cfg)
+	err := store.Upsert("docs", "a", []domain.Chunk{
+		{DocumentID: "a", Index: 0, Text: "x", Embedding: []float64{1, 0}},
+		{DocumentID: "a", Index: 1, Text: "xy", Embedding: []float64{1, 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Upsert("docs", "b", []domain.Chunk{{DocumentID: "b", Text: "y", Embedding: []float64{0, 1}}})
+
+	// a fresh store reads the collection back from disk
+	hits, err := NewVectorStore(cfg).Search("docs", []float64{0, 2}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 2 || hits[0].DocumentID != "b" || hits[0].Score < 0.999 || hits[1].Index != 1 {
+		t.Errorf("unexpected hits: %+v", hits)
+	}
+
+	// re-ingesting a document replaces its chunks
+	store.Upsert("docs", "a", []domain.Chunk{{DocumentID: "a", Text: "z", Embedding: []float64{-1, 0}}})
+	hits, _ = store.Search("docs", []float64{1, 0}, 10)
+	if len(hits) != 2 || hits[1].Text != "z" || hits[1].Score > -0.999 {
+		t.Errorf("unexpected hits after replace: %+v", hits)
+	}
+}
+
+func TestVectorStore_Errors(t *testing.T) {
+	store := NewVectorStore(&config.Config{RAGDir: t.TempDir()})
+	if _, err := store.Search("missing", []float64{1}, 1); !errors.Is(err, domain.ErrCollectionNotFound) {
+		t.Errorf("expected ErrCollectionNotFound, got %v", err)
+	}
+	store.Upsert("docs", "a", []domain.Chunk{{DocumentID: "a", Embedding: []float64{1, 0}}})
+	if err := store.Upsert("docs", "b", []domain.Chunk{{DocumentID: "b", Embedding: []float64{1, 0, 0}}}); !errors.Is(err, domain.ErrEmbeddingDimensions) {
+		t.Errorf("expected ErrEmbeddingDimensions on upsert, got %v", err)
+	}
+	if _, err := store.Search("docs", []float64{1, 0, 0}, 1); !errors.Is(err, domain.ErrEmbeddingDimensions) {
+		t.Errorf("expected ErrEmbeddingDimensions on search, got %v", err)
+	}
+}
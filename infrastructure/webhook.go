@@ -0,0 +1,224 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"minivault/config"
+	"minivault/domain"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	headerCallbackTimestamp = "X-MiniVault-Timestamp"
+	headerCallbackSignature = "X-MiniVault-Signature"
+	maxCallbackBackoff      = 5 * time.Minute
+)
+
+// webhookNotifier implements domain.CallbackPort.
+// Payloads are signed with HMAC-SHA256 over "<timestamp>.<body>" and retried with
+// exponential backoff; undeliverable payloads are appended to a dead-letter file.
+type webhookNotifier struct {
+	httpClient     *http.Client
+	allowedHosts   []string
+	secret         []byte
+	maxAttempts    int
+	backoff        time.Duration
+	deadLetterPath string
+	logger         domain.LoggerPort
+
+	sleep func(ctx context.Context, d time.Duration) // returns early when ctx ends
+	now   func() time.Time
+	mu    sync.Mutex // serialises dead-letter writes
+}
+
+// NewWebhookNotifier refuses an allowlist without CALLBACK_SECRET, which would sign
+// every callback with an empty key.
+func NewWebhookNotifier(cfg *config.Config, logger domain.LoggerPort) (domain.CallbackPort, error) {
+	if len(cfg.CallbackAllowedHosts) > 0 && cfg.CallbackSecret == "" {
+		return nil, domain.ErrCallbackSecretRequired
+	}
+	maxAttempts := cfg.CallbackMaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	return &webhookNotifier{
+		httpClient: &http.Client{
+			Timeout: cfg.CallbackTimeout,
+			// a redirect could lead to a host the allowlist never approved
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		allowedHosts:   cfg.CallbackAllowedHosts,
+		secret:         []byte(cfg.CallbackSecret),
+		maxAttempts:    maxAttempts,
+		backoff:        cfg.CallbackBackoff,
+		deadLetterPath: cfg.CallbackDeadLetterPath,
+		logger:         logger,
+		sleep:          sleepCtx,
+		now:            time.Now,
+	}, nil
+}
+
+// Validate checks the URL scheme and that its host matches the allowlist.
+// Entries may be exact hostnames or "*.example.com" wildcards.
+func (n *webhookNotifier) Validate(rawURL string) error {
+	if len(n.allowedHosts) == 0 {
+		return domain.ErrCallbacksDisabled
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return domain.ErrInvalidCallbackURL
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range n.allowedHosts {
+		allowed = strings.ToLower(allowed)
+		if host == allowed {
+			return nil
+		}
+		if strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:]) {
+			return nil
+		}
+	}
+	return domain.ErrCallbackHostNotAllowed
+}
+
+// Deliver POSTs the signed payload, retrying transient failures (network errors,
+// 408, 429 and 5xx) with exponential backoff. Once ctx ends, as it does for jobs
+// still running when a drain runs out of time, no further attempt is made and the
+// payload is dead-lettered at once.
+func (n *webhookNotifier) Deliver(ctx context.Context, rawURL string, payload domain.CallbackPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal callback payload: %w", err)
+	}
+
+	var lastErr error
+	attempt := 0
+	for attempt < n.maxAttempts {
+		attempt++
+		var retryable bool
+		retryable, lastErr = n.post(rawURL, body)
+		if lastErr == nil {
+			n.logger.LogInfo(fmt.Sprintf("callback delivered [reqID: %s] attempt %d", payload.RequestID, attempt))
+			return nil
+		}
+		n.logger.LogWarn(fmt.Sprintf("callback attempt %d/%d failed [reqID: %s]: %v", attempt, n.maxAttempts, payload.RequestID, lastErr))
+		if !retryable || attempt == n.maxAttempts || ctx.Err() != nil {
+			break
+		}
+		n.sleep(ctx, n.backoffFor(attempt))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	err = fmt.Errorf("callback undeliverable after %d attempt(s): %w", attempt, lastErr)
+	n.logger.LogError(fmt.Sprintf("callback dead-lettered [reqID: %s]", payload.RequestID), err)
+	if dlErr := n.deadLetter(rawURL, payload, attempt, lastErr); dlErr != nil {
+		n.logger.LogError("failed to write callback dead-letter", dlErr)
+	}
+	return err
+}
+
+// post performs a single delivery attempt and reports whether a failure is worth retrying.
+func (n *webhookNotifier) post(rawURL string, body []byte) (bool, error) {
+	ts := strconv.FormatInt(n.now().Unix(), 10)
+	request, err := http.NewRequest("POST", rawURL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create callback request: %w", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(headerCallbackTimestamp, ts)
+	request.Header.Set(headerCallbackSignature, "sha256="+SignCallback(n.secret, ts, body))
+
+	resp, err := n.httpClient.Do(request)
+	if err != nil {
+		return true, fmt.Errorf("failed to perform callback request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests ||
+		resp.StatusCode >= 500
+	return retryable, fmt.Errorf("callback endpoint returned status %d", resp.StatusCode)
+}
+
+// sleepCtx waits for d or until ctx ends, whichever comes first.
+func sleepCtx(ctx context.Context, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+func (n *webhookNotifier) backoffFor(attempt int) time.Duration {
+	d := n.backoff << (attempt - 1)
+	if d <= 0 || d > maxCallbackBackoff {
+		return maxCallbackBackoff
+	}
+	return d
+}
+
+type deadLetterRecord struct {
+	CallbackURL string                 `json:"callback_url"`
+	Payload     domain.CallbackPayload `json:"payload"`
+	Attempts    int                    `json:"attempts"`
+	LastError   string                 `json:"last_error"`
+	FailedAt    time.Time              `json:"failed_at"`
+}
+
+func (n *webhookNotifier) deadLetter(rawURL string, payload domain.CallbackPayload, attempts int, lastErr error) error {
+	rec := deadLetterRecord{
+		CallbackURL: rawURL,
+		Payload:     payload,
+		Attempts:    attempts,
+		FailedAt:    n.now().UTC(),
+	}
+	if lastErr != nil {
+		rec.LastError = lastErr.Error()
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(n.deadLetterPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(n.deadLetterPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// SignCallback returns the hex HMAC-SHA256 of "<timestamp>.<body>" so receivers can verify callbacks.
+func SignCallback(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
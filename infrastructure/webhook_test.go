@@ -0,0 +1,196 @@
+package infrastructure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/mocks"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestNotifier(t *testing.T, rt http.RoundTripper) (*webhookNotifier, *mocks.MockLogger) {
+	mockLog := &mocks.MockLogger{}
+	return &webhookNotifier{
+		httpClient:     &http.Client{Transport: rt},
+		allowedHosts:   []string{"hooks.example.com", "*.internal"},
+		secret:         []byte("s3cret"),
+		maxAttempts:    3,
+		backoff:        time.Second,
+		deadLetterPath: filepath.Join(t.TempDir(), "dead.jsonl"),
+		logger:         mockLog,
+		sleep:          func(context.Context, time.Duration) {},
+		now:            func() time.Time { return time.Unix(1700000000, 0) },
+	}, mockLog
+}
+
+func TestWebhookNotifier_Validate(t *testing.T) {
+	n, _ := newTestNotifier(t, nil)
+	cases := map[string]error{
+		"https://hooks.example.com/cb":    nil,
+		"http://svc.internal:9000/done":   nil,
+		"https://evil.example.com/cb":     domain.ErrCallbackHostNotAllowed,
+		"ftp://hooks.example.com/cb":      domain.ErrInvalidCallbackURL,
+		"/relative/path":                  domain.ErrInvalidCallbackURL,
+		"https://hooks.example.com.evil/": domain.ErrCallbackHostNotAllowed,
+	}
+	for raw, want := range cases {
+		if err := n.Validate(raw); !errors.Is(err, want) {
+			t.Errorf("Validate(%q) = %v, want %v", raw, err, want)
+		}
+	}
+
+	n.allowedHosts = nil
+	if err := n.Validate("https://hooks.example.com/cb"); !errors.Is(err, domain.ErrCallbacksDisabled) {
+		t.Errorf("expected callbacks disabled, got %v", err)
+	}
+}
+
+func TestWebhookNotifier_DeliverSigned(t *testing.T) {
+	var gotTS, gotSig string
+	var gotBody []byte
+	n, mockLog := newTestNotifier(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		gotTS = r.Header.Get(headerCallbackTimestamp)
+		gotSig = r.Header.Get(headerCallbackSignature)
+		gotBody, _ = io.ReadAll(r.Body)
+		return &http.Response{StatusCode: 204, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}))
+
+	err := n.Deliver(context.Background(), "https://hooks.example.com/cb", domain.CallbackPayload{RequestID: "r1", Status: "completed", Response: "hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if gotTS != "1700000000" {
+		t.Errorf("unexpected timestamp header: %q", gotTS)
+	}
+	if want := "sha256=" + SignCallback([]byte("s3cret"), gotTS, gotBody); gotSig != want {
+		t.Errorf("signature mismatch: got %q want %q", gotSig, want)
+	}
+	if len(mockLog.Infos) != 1 {
+		t.Error("expected delivery to be logged")
+	}
+}
+
+func TestWebhookNotifier_RetriesWithBackoff(t *testing.T) {
+	calls := 0
+	n, mockLog := newTestNotifier(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		if calls < 3 {
+			return &http.Response{StatusCode: 503, Body: io.NopCloser(strings.NewReader(""))}, nil
+		}
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}))
+	var sleeps []time.Duration
+	n.sleep = func(_ context.Context, d time.Duration) { sleeps = append(sleeps, d) }
+
+	if err := n.Deliver(context.Background(), "https://hooks.example.com/cb", domain.CallbackPayload{RequestID: "r2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 attempts, got %d", calls)
+	}
+	if len(sleeps) != 2 || sleeps[0] != time.Second || sleeps[1] != 2*time.Second {
+		t.Errorf("unexpected backoff schedule: %v", sleeps)
+	}
+	if len(mockLog.Warnings) != 2 {
+		t.Errorf("expected 2 failed attempts logged, got %d", len(mockLog.Warnings))
+	}
+}
+
+func TestWebhookNotifier_DeadLetter(t *testing.T) {
+	calls := 0
+	n, mockLog := newTestNotifier(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: 400, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}))
+
+	err := n.Deliver(context.Background(), "https://hooks.example.com/cb", domain.CallbackPayload{RequestID: "r3"})
+	if err == nil {
+		t.Fatal("expected delivery error")
+	}
+	if calls != 1 {
+		t.Errorf("non-retryable status should not be retried, got %d attempts", calls)
+	}
+	if len(mockLog.Errors) != 1 {
+		t.Error("expected dead-letter to be logged")
+	}
+
+	data, err := os.ReadFile(n.deadLetterPath)
+	if err != nil {
+		t.Fatalf("dead-letter file not written: %v", err)
+	}
+	var rec deadLetterRecord
+	if err := json.Unmarshal(data, &rec); err != nil {
+		t.Fatalf("bad dead-letter record: %v", err)
+	}
+	if rec.Payload.RequestID != "r3" || rec.Attempts != 1 || !strings.Contains(rec.LastError, "400") {
+		t.Errorf("unexpected dead-letter record: %+v", rec)
+	}
+}
+
+func TestWebhookNotifier_DeadLettersWhenCancelled(t *testing.T) {
+	calls := 0
+	n, _ := newTestNotifier(t, roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{StatusCode: 503, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}))
+	n.sleep = sleepCtx
+	n.backoff = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error)
+	go func() { done <- n.Deliver(ctx, "https://hooks.example.com/cb", domain.CallbackPayload{RequestID: "r5"}) }()
+	select {
+	case err := <-done:
+		if err == nil || calls != 1 {
+			t.Errorf("expected one attempt and a failure, got %d attempts and %v", calls, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("a cancelled delivery should not back off")
+	}
+	if data, err := os.ReadFile(n.deadLetterPath); err != nil || !strings.Contains(string(data), `"r5"`) {
+		t.Errorf("expected the payload to be dead-lettered at once, got %q %v", data, err)
+	}
+}
+
+func TestWebhookNotifier_DoesNotFollowRedirects(t *testing.T) {
+	reached := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached = true }))
+	defer target.Close()
+	hook := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer hook.Close()
+
+	port, err := NewWebhookNotifier(&config.Config{
+		CallbackAllowedHosts:   []string{"127.0.0.1"},
+		CallbackSecret:         "s3cret",
+		CallbackMaxAttempts:    1,
+		CallbackDeadLetterPath: filepath.Join(t.TempDir(), "dead.jsonl"),
+	}, &mocks.MockLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := port.Deliver(context.Background(), hook.URL, domain.CallbackPayload{RequestID: "r4"}); err == nil || !strings.Contains(err.Error(), "307") {
+		t.Errorf("expected the redirect to fail the delivery, got %v", err)
+	}
+	if reached {
+		t.Error("the redirect target must not be contacted")
+	}
+}
+
+func TestNewWebhookNotifier_RequiresSecret(t *testing.T) {
+	if _, err := NewWebhookNotifier(&config.Config{CallbackAllowedHosts: []string{"hooks.example.com"}}, &mocks.MockLogger{}); !errors.Is(err, domain.ErrCallbackSecretRequired) {
+		t.Errorf("expected an allowlist without a secret to be refused, got %v", err)
+	}
+	if _, err := NewWebhookNotifier(&config.Config{}, &mocks.MockLogger{}); err != nil {
+		t.Errorf("callbacks disabled should not need a secret, got %v", err)
+	}
+}
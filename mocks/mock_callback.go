@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"context"
+	"minivault/domain"
+	"sync"
+)
+
+// MockCallback implements domain.CallbackPort
+// Set ValidateError to reject URLs; deliveries are recorded and signalled on Delivered if non-nil.
+type MockCallback struct {
+	ValidateError error
+	DeliverError  error
+	Delivered     chan domain.CallbackPayload
+
+	mu         sync.Mutex
+	Deliveries []struct {
+		URL     string
+		Payload domain.CallbackPayload
+	}
+}
+
+func (m *MockCallback) Validate(rawURL string) error {
+	return m.ValidateError
+}
+
+func (m *MockCallback) Deliver(ctx context.Context, rawURL string, payload domain.CallbackPayload) error {
+	m.mu.Lock()
+	m.Deliveries = append(m.Deliveries, struct {
+		URL     string
+		Payload domain.CallbackPayload
+	}{rawURL, payload})
+	m.mu.Unlock()
+	if m.Delivered != nil {
+		m.Delivered <- payload
+	}
+	return m.DeliverError
+}
@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"context"
+	"minivault/domain"
+)
+
+// MockEmbedding implements domain.EmbeddingPort
+// Each input gets a vector of Dimensions copies of its length; Error, if set, fails every call.
+type MockEmbedding struct {
+	Dimensions int
+	Model      string
+	Error      error
+	Requests   []domain.OllamaEmbedRequest
+}
+
+func (m *MockEmbedding) Embed(ctx context.Context, req domain.OllamaEmbedRequest) (*domain.OllamaEmbedResponse, error) {
+	m.Requests = append(m.Requests, req)
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	resp := &domain.OllamaEmbedResponse{Model: m.Model}
+	for _, text := range req.Input {
+		vec := make([]float64, m.Dimensions)
+		for i := range vec {
+			vec[i] = float64(len(text))
+		}
+		resp.Embeddings = append(resp.Embeddings, vec)
+	}
+	return resp, nil
+}
+
+// MockEmbedder implements domain.EmbedderPort
+// You can set the Response and Error fields to control its behavior.
+type MockEmbedder struct {
+	Response    *domain.EmbeddingResponse
+	Error       error
+	LastRequest domain.EmbeddingRequest
+}
+
+func (m *MockEmbedder) Embed(ctx context.Context, req domain.EmbeddingRequest) (*domain.EmbeddingResponse, error) {
+	m.LastRequest = req
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return m.Response, nil
+}
@@ -1,15 +1,35 @@
 package mocks
 
+import (
+	"context"
+	"minivault/domain"
+	"strings"
+)
 
 // MockGenerator implements domain.GeneratorPort
-// You can set the Response and Error fields to control its behavior.
+// You can set the Response, Usage and Error fields to control its behavior.
+// With a domain.TokenSink in ctx, Response is streamed to it word by word, even
+// when Error is then returned.
 type MockGenerator struct {
-	Response string
-	Error    error
-	LastPrompt string
+	Response    string
+	Usage       *domain.Usage
+	Error       error
+	LastPrompt  string
+	LastRequest domain.GenerateRequest
+	LastCtx     context.Context
 }
 
-func (m *MockGenerator) Generate(prompt string) (string, error) {
-	m.LastPrompt = prompt
-	return m.Response, m.Error
+func (m *MockGenerator) Generate(ctx context.Context, req domain.GenerateRequest) (*domain.GenerateResponse, error) {
+	m.LastPrompt = req.Prompt
+	m.LastRequest = req
+	m.LastCtx = ctx
+	if sink := domain.TokenSinkFromContext(ctx); sink != nil && m.Response != "" {
+		for _, word := range strings.SplitAfter(m.Response, " ") {
+			sink(word)
+		}
+	}
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return &domain.GenerateResponse{Response: m.Response, Usage: m.Usage}, nil
 }
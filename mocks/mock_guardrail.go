@@ -0,0 +1,15 @@
+package mocks
+
+import "minivault/domain"
+
+// MockGuardrail implements domain.GuardrailPort
+// It returns Results for every input and records what was checked.
+type MockGuardrail struct {
+	Results []domain.PolicyResult
+	Checked []string
+}
+
+func (m *MockGuardrail) Check(text string) []domain.PolicyResult {
+	m.Checked = append(m.Checked, text)
+	return m.Results
+}
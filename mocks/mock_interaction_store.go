@@ -0,0 +1,29 @@
+package mocks
+
+import "minivault/domain"
+
+// MockInteractionStore implements domain.InteractionStorePort
+// It returns Page/Interaction/ModelStats/Error and records the last query.
+type MockInteractionStore struct {
+	Page        *domain.InteractionPage
+	Interaction *domain.Interaction
+	ModelStats  []domain.ModelStats
+	Error       error
+	LastQuery   domain.InteractionQuery
+	LastID      string
+}
+
+func (m *MockInteractionStore) Query(q domain.InteractionQuery) (*domain.InteractionPage, error) {
+	m.LastQuery = q
+	return m.Page, m.Error
+}
+
+func (m *MockInteractionStore) Get(id string) (*domain.Interaction, error) {
+	m.LastID = id
+	return m.Interaction, m.Error
+}
+
+func (m *MockInteractionStore) Stats(q domain.InteractionQuery) ([]domain.ModelStats, error) {
+	m.LastQuery = q
+	return m.ModelStats, m.Error
+}
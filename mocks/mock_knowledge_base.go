@@ -0,0 +1,62 @@
+package mocks
+
+import (
+	"context"
+	"minivault/domain"
+)
+
+// MockKnowledgeBase implements domain.KnowledgeBasePort
+// You can set the Document, Chunks and Error fields to control its behavior.
+type MockKnowledgeBase struct {
+	Document    *domain.Document
+	Chunks      []domain.ScoredChunk
+	Error       error
+	LastRequest domain.DocumentRequest
+	LastOptions domain.RetrievalOptions
+	LastQuery   string
+}
+
+func (m *MockKnowledgeBase) Ingest(ctx context.Context, req domain.DocumentRequest) (*domain.Document, error) {
+	m.LastRequest = req
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return m.Document, nil
+}
+
+func (m *MockKnowledgeBase) Retrieve(ctx context.Context, opts domain.RetrievalOptions, query string) ([]domain.ScoredChunk, error) {
+	m.LastOptions = opts
+	m.LastQuery = query
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return m.Chunks, nil
+}
+
+// MockVectorStore implements domain.VectorStorePort
+// Upserted chunks are kept per collection; Search returns Hits.
+type MockVectorStore struct {
+	Upserted map[string][]domain.Chunk
+	Hits     []domain.ScoredChunk
+	Error    error
+	LastK    int
+}
+
+func (m *MockVectorStore) Upsert(collection, documentID string, chunks []domain.Chunk) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	if m.Upserted == nil {
+		m.Upserted = make(map[string][]domain.Chunk)
+	}
+	m.Upserted[collection] = append(m.Upserted[collection], chunks...)
+	return nil
+}
+
+func (m *MockVectorStore) Search(collection string, vector []float64, k int) ([]domain.ScoredChunk, error) {
+	m.LastK = k
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return m.Hits, nil
+}
@@ -1,17 +1,19 @@
 package mocks
 
+import "minivault/domain"
 
 // MockLogger implements domain.LoggerPort
 // It records logs for inspection in tests.
 type MockLogger struct {
-	Interactions []struct{Prompt, Response string}
+	Interactions []domain.Interaction
 	Errors       []struct{Message string; Err error}
 	Warnings     []string
 	Infos        []string
+	Closed       bool
 }
 
-func (m *MockLogger) LogInteraction(prompt, response string) {
-	m.Interactions = append(m.Interactions, struct{Prompt, Response string}{prompt, response})
+func (m *MockLogger) LogInteraction(interaction domain.Interaction) {
+	m.Interactions = append(m.Interactions, interaction)
 }
 func (m *MockLogger) LogError(message string, err error) {
 	m.Errors = append(m.Errors, struct{Message string; Err error}{message, err})
@@ -22,3 +24,7 @@ func (m *MockLogger) LogWarn(message string) {
 func (m *MockLogger) LogInfo(message string) {
 	m.Infos = append(m.Infos, message)
 }
+func (m *MockLogger) Close() error {
+	m.Closed = true
+	return nil
+}
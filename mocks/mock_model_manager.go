@@ -0,0 +1,57 @@
+package mocks
+
+import (
+	"context"
+	"minivault/domain"
+)
+
+// MockModelManager implements domain.ModelManagerPort
+// Pull reports each of Progress in order; Error, if set, fails every call.
+type MockModelManager struct {
+	Models   []domain.ModelSummary
+	Running  []domain.RunningModel
+	Info     domain.ModelInfo
+	Progress []domain.PullProgress
+	Error    error
+	Deleted  []string
+	Loaded   []string
+	// LoadErrors fails LoadModel for the named models
+	LoadErrors map[string]error
+	KeepAlive  domain.KeepAlive
+}
+
+func (m *MockModelManager) ListModels(ctx context.Context) ([]domain.ModelSummary, error) {
+	return m.Models, m.Error
+}
+
+func (m *MockModelManager) ShowModel(ctx context.Context, name string) (domain.ModelInfo, error) {
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	return m.Info, nil
+}
+
+func (m *MockModelManager) PullModel(ctx context.Context, name string, progress func(domain.PullProgress)) error {
+	for _, p := range m.Progress {
+		progress(p)
+	}
+	return m.Error
+}
+
+func (m *MockModelManager) DeleteModel(ctx context.Context, name string) error {
+	if m.Error != nil {
+		return m.Error
+	}
+	m.Deleted = append(m.Deleted, name)
+	return nil
+}
+
+func (m *MockModelManager) RunningModels(ctx context.Context) ([]domain.RunningModel, error) {
+	return m.Running, m.Error
+}
+
+func (m *MockModelManager) LoadModel(ctx context.Context, name string, keepAlive domain.KeepAlive) error {
+	m.Loaded = append(m.Loaded, name)
+	m.KeepAlive = keepAlive
+	return m.LoadErrors[name]
+}
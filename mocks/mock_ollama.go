@@ -1,15 +1,51 @@
 package mocks
 
+import (
+	"context"
+	"minivault/domain"
+	"strings"
+)
 
 // MockOllama implements domain.OllamaPort
-// You can set the Response and Error fields to control its behavior.
+// You can set the Response, Model and Error fields to control its behavior.
+// Replies and then Responses, if set, are returned one per call before falling back to Response.
+// PromptEvalCount, EvalCount and EvalDuration are reported on every reply.
+// With a domain.TokenSink in ctx, the reply's content is streamed to it word by word.
 type MockOllama struct {
-	Response string
-	Error    error
-	LastPrompt string
+	Response        string
+	Responses       []string
+	Replies         []domain.OllamaChatMessage
+	Model           string
+	PromptEvalCount int
+	EvalCount       int
+	EvalDuration    int64
+	Error           error
+	Calls           int
+	LastPrompt      string
+	LastRequest     domain.OllamaChatRequest
 }
 
-func (m *MockOllama) CallOllama(prompt string) (string, error) {
-	m.LastPrompt = prompt
-	return m.Response, m.Error
+func (m *MockOllama) CallOllama(ctx context.Context, req domain.OllamaChatRequest) (*domain.OllamaChatResponse, error) {
+	m.Calls++
+	m.LastRequest = req
+	if len(req.Messages) > 0 {
+		m.LastPrompt = req.Messages[len(req.Messages)-1].Content
+	}
+	if m.Error != nil {
+		return nil, m.Error
+	}
+	resp := &domain.OllamaChatResponse{Model: m.Model, PromptEvalCount: m.PromptEvalCount, EvalCount: m.EvalCount, EvalDuration: m.EvalDuration}
+	resp.Message.Role = "assistant"
+	resp.Message.Content = m.Response
+	if len(m.Replies) > 0 {
+		resp.Message, m.Replies = m.Replies[0], m.Replies[1:]
+	} else if len(m.Responses) > 0 {
+		resp.Message.Content, m.Responses = m.Responses[0], m.Responses[1:]
+	}
+	if sink := domain.TokenSinkFromContext(ctx); sink != nil && resp.Message.Content != "" {
+		for _, word := range strings.SplitAfter(resp.Message.Content, " ") {
+			sink(word)
+		}
+	}
+	return resp, nil
 }
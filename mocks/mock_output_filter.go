@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"minivault/domain"
+	"strings"
+)
+
+// MockOutputFilter implements domain.OutputFilterPort
+// It replaces Secret with [REDACTED], reporting Results, or fails with Error.
+// Streams pass chunks through unchanged.
+type MockOutputFilter struct {
+	Secret  string
+	Results []domain.FilterResult
+	Error   error
+}
+
+func (m *MockOutputFilter) Filter(text string) (string, []domain.FilterResult, error) {
+	if m.Error != nil {
+		return "", nil, m.Error
+	}
+	if m.Secret != "" {
+		text = strings.ReplaceAll(text, m.Secret, "[REDACTED]")
+	}
+	return text, m.Results, nil
+}
+
+func (m *MockOutputFilter) Stream() domain.OutputStreamPort {
+	return &mockOutputStream{filter: m}
+}
+
+type mockOutputStream struct {
+	filter *MockOutputFilter
+}
+
+func (s *mockOutputStream) Write(chunk string) (string, error) {
+	return chunk, nil
+}
+
+func (s *mockOutputStream) Close() (string, []domain.FilterResult, error) {
+	return "", s.filter.Results, s.filter.Error
+}
@@ -0,0 +1,54 @@
+package mocks
+
+import "minivault/domain"
+
+// MockQuota implements domain.QuotaPort
+// Check returns CheckError, counting a request when it is nil; debits and resets
+// are recorded per key ID.
+type MockQuota struct {
+	CheckError error
+	Debited    map[string]int // key ID -> tokens
+	Requests   map[string]int // key ID -> requests
+	Resets     []string
+}
+
+func (m *MockQuota) Check(keyID string) error {
+	if m.CheckError != nil {
+		return m.CheckError
+	}
+	m.init()
+	m.Requests[keyID]++
+	return nil
+}
+
+func (m *MockQuota) Debit(keyID string, tokens int) error {
+	m.init()
+	m.Debited[keyID] += tokens
+	return nil
+}
+
+func (m *MockQuota) init() {
+	if m.Debited == nil {
+		m.Debited = make(map[string]int)
+		m.Requests = make(map[string]int)
+	}
+}
+
+func (m *MockQuota) Usage(keyID string) domain.QuotaUsage {
+	return domain.QuotaUsage{KeyID: keyID, Day: domain.QuotaCounter{Requests: m.Requests[keyID], Tokens: m.Debited[keyID]}}
+}
+
+func (m *MockQuota) List() []domain.QuotaUsage {
+	var list []domain.QuotaUsage
+	for keyID := range m.Requests {
+		list = append(list, m.Usage(keyID))
+	}
+	return list
+}
+
+func (m *MockQuota) Reset(keyID string) error {
+	m.Resets = append(m.Resets, keyID)
+	delete(m.Debited, keyID)
+	delete(m.Requests, keyID)
+	return nil
+}
@@ -0,0 +1,20 @@
+package mocks
+
+import "strings"
+
+// MockRedactor implements domain.RedactorPort
+// It replaces every occurrence of Secret with [REDACTED:SECRET].
+type MockRedactor struct {
+	Secret string
+}
+
+func (m *MockRedactor) Redact(text string) (string, map[string]int) {
+	if m.Secret == "" {
+		return text, nil
+	}
+	n := strings.Count(text, m.Secret)
+	if n == 0 {
+		return text, nil
+	}
+	return strings.ReplaceAll(text, m.Secret, "[REDACTED:SECRET]"), map[string]int{"SECRET": n}
+}
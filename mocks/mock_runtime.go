@@ -0,0 +1,49 @@
+package mocks
+
+import (
+	"context"
+	"minivault/domain"
+)
+
+// MockRuntime implements domain.RuntimePort
+// It returns RuntimeStatus, Settings and Requests, cancels the request IDs listed
+// in Running and records mode changes.
+type MockRuntime struct {
+	RuntimeStatus domain.RuntimeStatus
+	Settings      map[string]any
+	Requests      []domain.InFlightRequest
+	Running       []string
+	Cancelled     []string
+	Maintenance   *bool
+	Drained       bool
+}
+
+func (m *MockRuntime) Status(ctx context.Context) domain.RuntimeStatus {
+	return m.RuntimeStatus
+}
+
+func (m *MockRuntime) Config() map[string]any {
+	return m.Settings
+}
+
+func (m *MockRuntime) InFlight() []domain.InFlightRequest {
+	return m.Requests
+}
+
+func (m *MockRuntime) Cancel(reqID string) bool {
+	for _, id := range m.Running {
+		if id == reqID {
+			m.Cancelled = append(m.Cancelled, reqID)
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MockRuntime) SetMaintenance(on bool) {
+	m.Maintenance = &on
+}
+
+func (m *MockRuntime) Drain() {
+	m.Drained = true
+}
@@ -0,0 +1,47 @@
+package mocks
+
+import (
+	"minivault/domain"
+	"time"
+)
+
+// MockTenants implements domain.TenantsPort
+// Keys are bound through each tenant's APIKeyIDs; Allow refuses tenants listed in Limited.
+type MockTenants struct {
+	Tenants    []domain.Tenant
+	Limited    []string
+	RetryAfter time.Duration
+}
+
+func (m *MockTenants) TenantFor(keyID string) (domain.Tenant, bool) {
+	for _, t := range m.Tenants {
+		for _, id := range t.APIKeyIDs {
+			if id == keyID {
+				return t, true
+			}
+		}
+	}
+	return domain.Tenant{}, false
+}
+
+func (m *MockTenants) Tenant(id string) (domain.Tenant, bool) {
+	for _, t := range m.Tenants {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return domain.Tenant{}, false
+}
+
+func (m *MockTenants) List() []domain.Tenant {
+	return m.Tenants
+}
+
+func (m *MockTenants) Allow(id string) (bool, time.Duration) {
+	for _, limited := range m.Limited {
+		if limited == id {
+			return false, m.RetryAfter
+		}
+	}
+	return true, 0
+}
@@ -0,0 +1,34 @@
+package mocks
+
+import (
+	"context"
+	"fmt"
+	"minivault/domain"
+)
+
+// MockToolbox implements domain.ToolboxPort
+// Results maps tool names to their output; Error, if set, is returned by every call.
+type MockToolbox struct {
+	Results map[string]string
+	Error   error
+	Calls   []domain.ToolCall
+}
+
+func (m *MockToolbox) Tools(names []string) ([]domain.Tool, error) {
+	defs := make([]domain.Tool, 0, len(names))
+	for _, name := range names {
+		if _, ok := m.Results[name]; !ok {
+			return nil, fmt.Errorf("%w: %q", domain.ErrUnknownTool, name)
+		}
+		defs = append(defs, domain.Tool{Type: domain.ToolTypeFunction, Function: domain.ToolFunction{Name: name}})
+	}
+	return defs, nil
+}
+
+func (m *MockToolbox) Call(ctx context.Context, call domain.ToolCall) (string, error) {
+	m.Calls = append(m.Calls, call)
+	if m.Error != nil {
+		return "", m.Error
+	}
+	return m.Results[call.Function.Name], nil
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"context"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/infrastructure"
+	"minivault/usecases"
+)
+
+// Backend holds the adapters that talk to Ollama, shared by the server and the
+// commands that generate in-process.
+type Backend struct {
+	Ollama     domain.OllamaPort // for generation, behind the FALLBACK_CHAIN if any
+	Embeddings domain.EmbeddingPort
+	Models     domain.ModelManagerPort
+
+	pool domain.UpstreamPoolPort // nil unless OLLAMA_UPSTREAMS is set
+}
+
+// NewBackend connects generation, embeddings and model management to OLLAMA_UPSTREAMS
+// when set, and to OLLAMA_URL, OLLAMA_EMBED_URL and OLLAMA_API_URL otherwise.
+func NewBackend(cfg *config.Config, logger domain.LoggerPort) (*Backend, error) {
+	pool, err := infrastructure.NewUpstreamPool(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	b := &Backend{pool: pool}
+	var primary domain.OllamaPort
+	if pool != nil {
+		primary, b.Embeddings, b.Models = pool, pool, pool
+	} else {
+		primary, b.Embeddings = infrastructure.NewOllamaClient(cfg), infrastructure.NewOllamaEmbedder(cfg)
+		b.Models = infrastructure.NewModelManager(cfg)
+	}
+	steps, err := infrastructure.NewFallbackSteps(cfg, primary)
+	if err != nil {
+		return nil, err
+	}
+	b.Ollama, err = usecases.NewFallbackChain(domain.FallbackStep{Model: cfg.OllamaModel, Ollama: primary, Timeout: cfg.OllamaTimeout}, steps, cfg.FallbackOn, logger)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// MonitorHealth runs the upstream health checks until ctx is done. Without
+// OLLAMA_UPSTREAMS it returns immediately.
+func (b *Backend) MonitorHealth(ctx context.Context) {
+	if b.pool != nil {
+		b.pool.MonitorHealth(ctx)
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// HealthHandler answers liveness probes: the process is up and serving.
+func HealthHandler(w http.ResponseWriter, r *http.Request) {
+	writeStatus(w, http.StatusOK, "ok")
+}
+
+// ReadinessHandler answers readiness probes with 503 until startup models have
+// loaded, and again while the server drains or is in maintenance.
+func ReadinessHandler(rt *serverRuntime) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case rt.draining.Load():
+			writeStatus(w, http.StatusServiceUnavailable, "draining")
+		case rt.maintenance.Load():
+			writeStatus(w, http.StatusServiceUnavailable, "maintenance")
+		case !rt.ready.Load():
+			writeStatus(w, http.StatusServiceUnavailable, "starting")
+		default:
+			writeStatus(w, http.StatusOK, "ready")
+		}
+	})
+}
+
+func writeStatus(w http.ResponseWriter, code int, status string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"status": status})
+}
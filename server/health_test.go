@@ -0,0 +1,60 @@
+package server
+
+import (
+	"minivault/config"
+	"minivault/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadinessHandler(t *testing.T) {
+	rt := newRuntime(&config.Config{}, &Backend{})
+	h := ReadinessHandler(rt)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 before warm-up finishes, got %d", rec.Code)
+	}
+
+	rt.ready.Store(true)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200 once ready, got %d", rec.Code)
+	}
+
+	rt.SetMaintenance(true)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "maintenance") {
+		t.Errorf("expected 503 during maintenance, got %d %s", rec.Code, rec.Body)
+	}
+	rt.SetMaintenance(false)
+	rt.Drain()
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "draining") {
+		t.Errorf("expected 503 while draining, got %d %s", rec.Code, rec.Body)
+	}
+}
+
+func TestProbesSkipAuthentication(t *testing.T) {
+	cfg := &config.Config{APIKeys: map[string]string{"alice": "key-a"}}
+	backend := &Backend{Models: &mocks.MockModelManager{}}
+	srv := newServer(cfg, serverDeps{logger: &mocks.MockLogger{}, backend: backend}, newRuntime(cfg, backend))
+
+	for path, want := range map[string]int{
+		"/healthz":      http.StatusOK,
+		"/readyz":       http.StatusServiceUnavailable,
+		"/interactions": http.StatusUnauthorized,
+	} {
+		rec := httptest.NewRecorder()
+		srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: got %d, want %d", path, rec.Code, want)
+		}
+	}
+}
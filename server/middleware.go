@@ -1,15 +1,22 @@
 package server
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
-	"net/http"
+	"math"
 	"minivault/domain"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
 )
 
-// BodyLimitMiddleware limits incoming request body size to 4KB (4096 bytes).
-func BodyLimitMiddleware(next http.Handler) http.Handler {
+// BodyLimitMiddleware limits incoming request body size to limit bytes (MAX_BODY_BYTES, 4KB by default).
+func BodyLimitMiddleware(limit int64, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		r.Body = http.MaxBytesReader(w, r.Body, 4096)
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
 		next.ServeHTTP(w, r)
 	})
 }
@@ -27,3 +34,126 @@ func RecoveryMiddleware(logger domain.LoggerPort, next http.Handler) http.Handle
 		next.ServeHTTP(w, r)
 	})
 }
+
+// AuthMiddleware requires a valid API key in "Authorization: Bearer <key>" or "X-API-Key"
+// and stores the caller's key ID in the request context. Browsers cannot set headers
+// on WebSocket handshakes, so there the key may also be offered as a
+// "bearer.<base64url key>" entry of Sec-WebSocket-Protocol. keys maps key ID to secret;
+// if it is empty, authentication is disabled and requests pass through anonymously.
+// Callers whose key ID is in adminIDs are marked as admins.
+func AuthMiddleware(keys map[string]string, adminIDs []string, logger domain.LoggerPort, next http.Handler) http.Handler {
+	if len(keys) == 0 {
+		return next
+	}
+	// index by digest so lookups do not compare secrets byte-by-byte
+	byDigest := make(map[[sha256.Size]byte]string, len(keys))
+	for id, secret := range keys {
+		byDigest[sha256.Sum256([]byte(secret))] = id
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("X-API-Key")
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			key = strings.TrimSpace(bearer)
+		}
+		if key == "" {
+			key = subprotocolKey(r.Header)
+		}
+		id, ok := byDigest[sha256.Sum256([]byte(key))]
+		if key == "" || !ok {
+			logger.LogWarn(fmt.Sprintf("unauthorized request to %s from %s", r.URL.Path, r.RemoteAddr))
+			w.Header().Set("WWW-Authenticate", `Bearer realm="minivault"`)
+			http.Error(w, "Unauthorized: "+domain.ErrUnauthorized.Error(), http.StatusUnauthorized)
+			return
+		}
+		caller := domain.Caller{KeyID: id, Admin: slices.Contains(adminIDs, id)}
+		next.ServeHTTP(w, r.WithContext(domain.WithCaller(r.Context(), caller)))
+	})
+}
+
+// subprotocolKey returns the API key offered as a "bearer.<base64url key>"
+// WebSocket subprotocol, or "".
+func subprotocolKey(h http.Header) string {
+	for _, value := range h.Values("Sec-WebSocket-Protocol") {
+		for _, protocol := range strings.Split(value, ",") {
+			encoded, ok := strings.CutPrefix(strings.TrimSpace(protocol), "bearer.")
+			if !ok {
+				continue
+			}
+			if key, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(encoded, "=")); err == nil {
+				return string(key)
+			}
+		}
+	}
+	return ""
+}
+
+// TenantMiddleware binds authenticated callers to their tenant and enforces the
+// tenant's rate limit, answering 429 with Retry-After once it is used up. Callers
+// whose key belongs to no tenant pass through unchanged. With tenancy off it is a no-op.
+func TenantMiddleware(tenants domain.TenantsPort, logger domain.LoggerPort, next http.Handler) http.Handler {
+	if tenants == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, ok := domain.CallerFromContext(r.Context())
+		tenant, bound := tenants.TenantFor(caller.KeyID)
+		if !ok || !bound {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if allowed, retryAfter := tenants.Allow(tenant.ID); !allowed {
+			logger.LogWarn(fmt.Sprintf("rate limited request to %s by %q of tenant %s", r.URL.Path, caller.KeyID, tenant.ID))
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			http.Error(w, "Too Many Requests: "+domain.ErrRateLimited.Error(), http.StatusTooManyRequests)
+			return
+		}
+		caller.Tenant = tenant.ID
+		next.ServeHTTP(w, r.WithContext(domain.WithCaller(r.Context(), caller)))
+	})
+}
+
+// unavailableRetryAfter is the Retry-After, in seconds, sent with 503s during
+// maintenance and draining.
+const unavailableRetryAfter = "30"
+
+// MaintenanceMiddleware answers every request outside /admin/ with 503 while
+// maintenance is on, so that admins can still switch it off.
+func MaintenanceMiddleware(maintenance *atomic.Bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if maintenance.Load() && !strings.HasPrefix(r.URL.Path, "/admin/") {
+			unavailable(w, domain.ErrServerUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// DrainMiddleware refuses new generations with 503 once the server is draining.
+func DrainMiddleware(draining *atomic.Bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if draining.Load() {
+			unavailable(w, domain.ErrServerDraining)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func unavailable(w http.ResponseWriter, err error) {
+	w.Header().Set("Retry-After", unavailableRetryAfter)
+	http.Error(w, "Service Unavailable: "+err.Error(), http.StatusServiceUnavailable)
+}
+
+// AdminMiddleware only lets admin callers through. Without authentication there are
+// no admins, so admin endpoints are closed unless API keys are configured.
+func AdminMiddleware(logger domain.LoggerPort, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ := domain.CallerFromContext(r.Context())
+		if !caller.Admin {
+			logger.LogWarn(fmt.Sprintf("forbidden admin request to %s by %q from %s", r.URL.Path, caller.KeyID, r.RemoteAddr))
+			http.Error(w, "Forbidden: "+domain.ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
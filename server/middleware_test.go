@@ -0,0 +1,140 @@
+package server
+
+import (
+	"io"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/mocks"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	var caller domain.Caller
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ = domain.CallerFromContext(r.Context())
+	})
+	h := AuthMiddleware(map[string]string{"alice": "key-a", "bob": "key-b"}, []string{"bob"}, &mocks.MockLogger{}, next)
+
+	cases := []struct {
+		header, value string
+		wantCode      int
+		wantCaller    string
+	}{
+		{"Authorization", "Bearer key-a", http.StatusOK, "alice"},
+		{"X-API-Key", "key-b", http.StatusOK, "bob"},
+		{"Sec-WebSocket-Protocol", "minivault, bearer.a2V5LWE", http.StatusOK, "alice"},
+		{"Sec-WebSocket-Protocol", "minivault, bearer.d3Jvbmc", http.StatusUnauthorized, ""},
+		{"Authorization", "Bearer wrong", http.StatusUnauthorized, ""},
+		{"", "", http.StatusUnauthorized, ""},
+	}
+	for _, c := range cases {
+		caller = domain.Caller{}
+		req := httptest.NewRequest(http.MethodGet, "/interactions", nil)
+		if c.header != "" {
+			req.Header.Set(c.header, c.value)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != c.wantCode || caller.KeyID != c.wantCaller {
+			t.Errorf("%s=%q: got %d caller %q, want %d %q", c.header, c.value, rec.Code, caller.KeyID, c.wantCode, c.wantCaller)
+		}
+	}
+}
+
+func TestAuthMiddleware_DisabledWithoutKeys(t *testing.T) {
+	called := false
+	h := AuthMiddleware(nil, nil, &mocks.MockLogger{}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/generate", nil))
+	if !called {
+		t.Error("requests should pass through when no keys are configured")
+	}
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	})
+	h := BodyLimitMiddleware(8, next)
+	for body, want := range map[string]int{"12345678": http.StatusOK, "123456789": http.StatusRequestEntityTooLarge} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		if rec.Code != want {
+			t.Errorf("%d-byte body: got %d, want %d", len(body), rec.Code, want)
+		}
+	}
+}
+
+func TestEmbeddingsBodyLimit(t *testing.T) {
+	cfg := &config.Config{MaxBodyBytes: 4096, EmbedMaxInputs: 64, EmbedMaxInputChars: 8192}
+	if got := embeddingsBodyLimit(cfg); got != 64*8192*4+4096 {
+		t.Errorf("expected room for every allowed input, got %d", got)
+	}
+	cfg.EmbedMaxInputs = 0
+	if got := embeddingsBodyLimit(cfg); got != 4096 {
+		t.Errorf("expected MAX_BODY_BYTES without input limits, got %d", got)
+	}
+}
+
+func TestAdminMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := AuthMiddleware(map[string]string{"alice": "key-a", "ops": "key-o"}, []string{"ops"}, &mocks.MockLogger{}, AdminMiddleware(&mocks.MockLogger{}, next))
+	for key, want := range map[string]int{"key-o": http.StatusOK, "key-a": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/models", nil)
+		req.Header.Set("X-API-Key", key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: got %d, want %d", key, rec.Code, want)
+		}
+	}
+
+	// without authentication nobody is an admin
+	rec := httptest.NewRecorder()
+	AuthMiddleware(nil, nil, &mocks.MockLogger{}, AdminMiddleware(&mocks.MockLogger{}, next)).
+		ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/models", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("anonymous admin request: got %d, want 403", rec.Code)
+	}
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	var caller domain.Caller
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caller, _ = domain.CallerFromContext(r.Context())
+	})
+	tenants := &mocks.MockTenants{
+		Tenants:    []domain.Tenant{{ID: "red", APIKeyIDs: []string{"alice"}}, {ID: "blue", APIKeyIDs: []string{"bob"}}},
+		Limited:    []string{"blue"},
+		RetryAfter: 1500 * time.Millisecond,
+	}
+	h := AuthMiddleware(map[string]string{"alice": "key-a", "bob": "key-b", "ops": "key-o"}, nil, &mocks.MockLogger{}, TenantMiddleware(tenants, &mocks.MockLogger{}, next))
+
+	cases := []struct {
+		key        string
+		wantCode   int
+		wantTenant string
+	}{
+		{"key-a", http.StatusOK, "red"},
+		{"key-b", http.StatusTooManyRequests, ""},
+		{"key-o", http.StatusOK, ""},
+	}
+	for _, c := range cases {
+		caller = domain.Caller{}
+		req := httptest.NewRequest(http.MethodGet, "/interactions", nil)
+		req.Header.Set("X-API-Key", c.key)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != c.wantCode || caller.Tenant != c.wantTenant {
+			t.Errorf("%s: got %d tenant %q, want %d %q", c.key, rec.Code, caller.Tenant, c.wantCode, c.wantTenant)
+		}
+		if c.wantCode == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "2" {
+			t.Errorf("%s: expected Retry-After 2, got %q", c.key, rec.Header().Get("Retry-After"))
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/usecases"
+	"net/http"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// upstreamCheckTimeout bounds the live check of a single OLLAMA_URL in Status.
+const upstreamCheckTimeout = 2 * time.Second
+
+// cancelledWait bounds how long shutdown waits, once the grace period is over, for
+// cancelled generations to be logged. Callback jobs also get CALLBACK_TIMEOUT for
+// their last delivery attempt before they are dead-lettered.
+const cancelledWait = 5 * time.Second
+
+// serverRuntime implements domain.RuntimePort. It holds the state that admins can
+// inspect and switch while the server runs.
+type serverRuntime struct {
+	cfg       *config.Config
+	backend   *Backend
+	inflight  domain.InFlightPort
+	startedAt time.Time
+
+	ready       atomic.Bool // startup warm-up has finished
+	maintenance atomic.Bool
+	draining    atomic.Bool
+	drained     chan struct{} // closed by Drain
+	drainOnce   sync.Once
+}
+
+func newRuntime(cfg *config.Config, backend *Backend) *serverRuntime {
+	return &serverRuntime{
+		cfg:       cfg,
+		backend:   backend,
+		inflight:  usecases.NewInFlightTracker(),
+		startedAt: time.Now(),
+		drained:   make(chan struct{}),
+	}
+}
+
+// Status implements RuntimePort
+func (s *serverRuntime) Status(ctx context.Context) domain.RuntimeStatus {
+	inflight := s.inflight.List()
+	queued := 0
+	for _, r := range inflight {
+		if r.Async {
+			queued++
+		}
+	}
+	return domain.RuntimeStatus{
+		Build:       buildInfo(),
+		StartedAt:   s.startedAt.UTC(),
+		Uptime:      time.Since(s.startedAt).Round(time.Second).String(),
+		Ready:       s.ready.Load() && !s.draining.Load(),
+		Maintenance: s.maintenance.Load(),
+		Draining:    s.draining.Load(),
+		InFlight:    inflight,
+		QueueDepth:  queued,
+		Upstreams:   s.upstreams(ctx),
+	}
+}
+
+// upstreams reports the pool's view of its hosts, or checks OLLAMA_URL live when
+// there is no pool.
+func (s *serverRuntime) upstreams(ctx context.Context) []domain.UpstreamStatus {
+	if s.backend.pool != nil {
+		return s.backend.pool.Status()
+	}
+	ctx, cancel := context.WithTimeout(ctx, upstreamCheckTimeout)
+	defer cancel()
+	status := domain.UpstreamStatus{URL: config.RedactURL(s.cfg.OllamaAPIURL), Healthy: true}
+	if _, err := s.backend.Models.ListModels(ctx); err != nil {
+		status.Healthy, status.Error = false, err.Error()
+	}
+	return []domain.UpstreamStatus{status}
+}
+
+// Config implements RuntimePort
+func (s *serverRuntime) Config() map[string]any {
+	return s.cfg.Redacted()
+}
+
+// InFlight implements RuntimePort
+func (s *serverRuntime) InFlight() []domain.InFlightRequest {
+	return s.inflight.List()
+}
+
+// Cancel implements RuntimePort
+func (s *serverRuntime) Cancel(reqID string) bool {
+	return s.inflight.Cancel(reqID)
+}
+
+// SetMaintenance implements RuntimePort
+func (s *serverRuntime) SetMaintenance(on bool) {
+	s.maintenance.Store(on)
+}
+
+// Drain implements RuntimePort. Run notices the closed channel and shuts down.
+func (s *serverRuntime) Drain() {
+	s.drainOnce.Do(func() {
+		s.draining.Store(true)
+		close(s.drained)
+	})
+}
+
+// shutdown drains srv. Readiness fails and new generations get 503 at once; after
+// DRAIN_DELAY the listener closes, and in-flight requests, streams and callback jobs
+// get SHUTDOWN_GRACE_PERIOD to finish. Whatever is left is then cancelled: callback
+// jobs stop retrying and dead-letter their payload, which shutdown waits for so the
+// logger is still open. It then reports that the drain was not clean.
+func (s *serverRuntime) shutdown(srv *http.Server) error {
+	s.draining.Store(true)
+	time.Sleep(s.cfg.DrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownGracePeriod)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	if err == nil {
+		err = s.inflight.Wait(ctx)
+	}
+	if err == nil {
+		return nil
+	}
+
+	cancelled := s.inflight.CancelAll(domain.ErrServerDraining)
+	srv.Close()
+	waitCtx, cancelWait := context.WithTimeout(context.Background(), cancelledWait+s.cfg.CallbackTimeout)
+	defer cancelWait()
+	s.inflight.Wait(waitCtx)
+	return fmt.Errorf("%w after %s: cancelled %d generations", domain.ErrDrainTimeout, s.cfg.ShutdownGracePeriod, cancelled)
+}
+
+// buildInfo reads the module version and VCS stamp embedded by the Go toolchain.
+func buildInfo() domain.BuildInfo {
+	info := domain.BuildInfo{Version: "unknown"}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	info.Version, info.GoVersion = bi.Main.Version, bi.GoVersion
+	for _, setting := range bi.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Revision = setting.Value
+		case "vcs.time":
+			info.Time = setting.Value
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
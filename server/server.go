@@ -2,47 +2,200 @@ package server
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"minivault/api"
 	"minivault/config"
+	"minivault/domain"
 	"minivault/infrastructure"
 	"minivault/usecases"
 	"net/http"
-	"time"
+	"path/filepath"
 )
 
+// serverDeps are the ports newServer wires into its handlers. Optional features
+// that are switched off are left nil.
+type serverDeps struct {
+	logger   domain.LoggerPort
+	vault    domain.VaultPort
+	tools    domain.ToolboxPort
+	guard    domain.GuardrailPort
+	filter   domain.OutputFilterPort
+	quotas   domain.QuotaPort
+	tenants  domain.TenantsPort
+	notifier domain.CallbackPort
+	backend  *Backend
+}
+
 // newServer creates and configures the MiniVault HTTP server with all middleware and routes.
-func newServer(cfg *config.Config) *http.Server {
-	logger := infrastructure.NewLogger()
-	ollama := infrastructure.NewOllamaClient(cfg)
-	generator := usecases.NewGenerator(ollama, logger)
-	handler := api.NewHttpHandler(generator, logger)
+// rt holds the readiness, maintenance and drain state and the in-flight generations.
+func newServer(cfg *config.Config, deps serverDeps, rt *serverRuntime) *http.Server {
+	logger, vault, quotas, tenants, backend := deps.logger, deps.vault, deps.quotas, deps.tenants, deps.backend
+	vectorStores := make(map[string]domain.VectorStorePort)
+	if tenants != nil {
+		for _, t := range tenants.List() {
+			tenantCfg := *cfg
+			tenantCfg.RAGDir = filepath.Join(cfg.RAGDir, "tenants", t.ID)
+			vectorStores[t.ID] = infrastructure.NewVectorStore(&tenantCfg)
+		}
+	}
+	kb := usecases.NewKnowledgeBase(backend.Embeddings, infrastructure.NewVectorStore(cfg), vectorStores, logger, cfg)
+	generator := usecases.NewGenerator(backend.Ollama, logger, deps.tools, kb, deps.guard, deps.filter, tenants, cfg.StructuredOutputRetries, cfg.ToolsMaxRounds)
+	generator = usecases.NewQuotaGenerator(generator, quotas, logger)
+	handler := api.NewHttpHandler(generator, logger, deps.notifier, rt.inflight)
+	chat := api.NewChatSocketHandler(generator, logger, rt.inflight, tenants, cfg.WSAllowedOrigins, cfg.MaxBodyBytes, cfg.GenerateMaxBodyBytes, cfg.WSPingInterval)
+	embedder := usecases.NewEmbedder(backend.Embeddings, logger, tenants, cfg)
+	embeddings := api.NewEmbeddingsHandler(embedder, logger)
+	documents := api.NewDocumentsHandler(kb, logger)
+	store := infrastructure.NewInteractionStore(cfg, vault)
+	tenantStores := make(map[string]domain.InteractionStorePort)
+	if tenants != nil {
+		for _, t := range tenants.List() {
+			tenantCfg := *cfg
+			tenantCfg.LogDir = t.LogDir
+			tenantStores[t.ID] = infrastructure.NewInteractionStore(&tenantCfg, vault)
+		}
+	}
+	interactions := api.NewInteractionsHandler(store, tenantStores, logger)
+	modelsHandler := api.NewModelsHandler(backend.Models, logger)
+	adminHandler := api.NewAdminHandler(rt, logger)
+	admin := func(h http.HandlerFunc) http.Handler { return AdminMiddleware(logger, h) }
 
+	// body limits are per route so documents can be larger than prompts
 	mux := http.NewServeMux()
-	mux.HandleFunc("/generate", handler.Generate)
+	mux.Handle("/generate", DrainMiddleware(&rt.draining, BodyLimitMiddleware(cfg.GenerateMaxBodyBytes, http.HandlerFunc(handler.Generate))))
+	mux.Handle("GET /ws/chat", DrainMiddleware(&rt.draining, http.HandlerFunc(chat.Chat)))
+	mux.Handle("POST /embeddings", BodyLimitMiddleware(embeddingsBodyLimit(cfg), http.HandlerFunc(embeddings.Embed)))
+	mux.Handle("POST /documents", BodyLimitMiddleware(cfg.RAGMaxDocumentBytes, http.HandlerFunc(documents.Ingest)))
+	mux.HandleFunc("GET /interactions", interactions.List)
+	mux.HandleFunc("GET /interactions/stats", interactions.Stats)
+	mux.HandleFunc("GET /interactions/{id}", interactions.Get)
+	mux.Handle("GET /admin/models", admin(modelsHandler.List))
+	mux.Handle("GET /admin/models/{name...}", admin(modelsHandler.Show))
+	mux.Handle("DELETE /admin/models/{name...}", admin(modelsHandler.Delete))
+	mux.Handle("POST /admin/models/pull", BodyLimitMiddleware(cfg.MaxBodyBytes, admin(modelsHandler.Pull)))
+	mux.Handle("GET /admin/ps", admin(modelsHandler.Running))
+	mux.Handle("GET /admin/runtime", admin(adminHandler.Status))
+	mux.Handle("GET /admin/config", admin(adminHandler.Config))
+	mux.Handle("GET /admin/requests", admin(adminHandler.Requests))
+	mux.Handle("DELETE /admin/requests/{id}", admin(adminHandler.Cancel))
+	mux.Handle("POST /admin/drain", admin(adminHandler.Drain))
+	mux.Handle("PUT /admin/maintenance", BodyLimitMiddleware(cfg.MaxBodyBytes, admin(adminHandler.Maintenance)))
+	if quotas != nil {
+		quotasHandler := api.NewQuotasHandler(quotas, logger)
+		mux.Handle("GET /admin/quotas", admin(quotasHandler.List))
+		mux.Handle("GET /admin/quotas/{key}", admin(quotasHandler.Get))
+		mux.Handle("DELETE /admin/quotas/{key}", admin(quotasHandler.Reset))
+	}
 
-	wrapped := BodyLimitMiddleware(mux)
-	wrapped = RecoveryMiddleware(logger, wrapped)
+	// probes are served outside authentication
+	root := http.NewServeMux()
+	root.HandleFunc("GET /healthz", HealthHandler)
+	root.Handle("GET /readyz", ReadinessHandler(rt))
+	root.Handle("/", AuthMiddleware(cfg.APIKeys, cfg.AdminKeyIDs, logger, TenantMiddleware(tenants, logger, MaintenanceMiddleware(&rt.maintenance, mux))))
+	wrapped := RecoveryMiddleware(logger, root)
 
-	return &http.Server{
+	srv := &http.Server{
 		Addr:    cfg.ServerPort,
 		Handler: wrapped,
 	}
+	// WebSocket connections are hijacked, so Shutdown does not close them itself
+	srv.RegisterOnShutdown(chat.Shutdown)
+	return srv
+}
+
+// embeddingsBodyLimit fits EMBED_MAX_INPUTS texts of EMBED_MAX_INPUT_CHARS characters,
+// at up to 4 bytes each, plus MAX_BODY_BYTES for the rest of the request. Without
+// both limits it is MAX_BODY_BYTES.
+func embeddingsBodyLimit(cfg *config.Config) int64 {
+	if cfg.EmbedMaxInputs <= 0 || cfg.EmbedMaxInputChars <= 0 {
+		return cfg.MaxBodyBytes
+	}
+	return int64(cfg.EmbedMaxInputs)*int64(cfg.EmbedMaxInputChars)*4 + cfg.MaxBodyBytes
 }
 
 // Run starts the MiniVault server and blocks until it exits. Accepts context for graceful shutdown.
 func Run(ctx context.Context, cfg *config.Config) error {
-	server := newServer(cfg)
-	log.Printf("MiniVault API running on %s\n", cfg.ServerPort)
+	redactor, err := infrastructure.NewRedactor(cfg)
+	if err != nil {
+		return err
+	}
+	vault, err := infrastructure.NewVault(cfg)
+	if err != nil {
+		return err
+	}
+	tools, err := infrastructure.NewToolbox(cfg)
+	if err != nil {
+		return err
+	}
+	guard, err := infrastructure.NewGuardrails(cfg)
+	if err != nil {
+		return err
+	}
+	filter, err := infrastructure.NewOutputFilters(cfg)
+	if err != nil {
+		return err
+	}
+	quotas, err := infrastructure.NewQuotaStore(cfg)
+	if err != nil {
+		return err
+	}
+	tenants, err := infrastructure.NewTenants(cfg)
+	if err != nil {
+		return err
+	}
+	logger := infrastructure.NewLogger(cfg, redactor, vault, tenants)
+	notifier, err := infrastructure.NewWebhookNotifier(cfg, logger)
+	if err != nil {
+		return errors.Join(err, logger.Close())
+	}
+	backend, err := NewBackend(cfg, logger)
+	if err != nil {
+		return errors.Join(err, logger.Close())
+	}
+	warmer, err := usecases.NewWarmer(backend.Models, logger, cfg)
+	if err != nil {
+		return errors.Join(err, logger.Close())
+	}
+	rt := newRuntime(cfg, backend)
+	server := newServer(cfg, serverDeps{
+		logger:   logger,
+		vault:    vault,
+		tools:    tools,
+		guard:    guard,
+		filter:   filter,
+		quotas:   quotas,
+		tenants:  tenants,
+		notifier: notifier,
+		backend:  backend,
+	}, rt)
+	go backend.MonitorHealth(ctx)
+	// serve while models load; /readyz reports 503 until warm-up finishes
 	go func() {
-		<-ctx.Done()
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		server.Shutdown(shutdownCtx)
+		if err := warmer.Warmup(ctx); err != nil {
+			logger.LogError("model warm-up failed", err)
+		}
+		rt.ready.Store(true)
+		warmer.KeepWarm(ctx)
 	}()
-	err := server.ListenAndServe()
-	if err != nil && err != http.ErrServerClosed {
-		log.Fatalf("Server failed: %v", err)
+	served := make(chan error, 1)
+	go func() { served <- server.ListenAndServe() }()
+	log.Printf("MiniVault API running on %s\n", cfg.ServerPort)
+
+	select {
+	case err := <-served:
+		return errors.Join(fmt.Errorf("server failed: %w", err), logger.Close())
+	case <-ctx.Done():
+		logger.LogInfo("shutdown requested, draining")
+	case <-rt.drained:
+		logger.LogInfo("drain requested, draining")
+	}
+	err = rt.shutdown(server)
+	if err != nil {
+		logger.LogError("drain was not clean", err)
+	} else {
+		logger.LogInfo("drained cleanly")
 	}
-	return err
+	return errors.Join(err, logger.Close())
 }
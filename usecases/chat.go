@@ -0,0 +1,158 @@
+package usecases
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"minivault/domain"
+	"strings"
+)
+
+// chatSession implements domain.ChatSessionPort on top of a GeneratorPort, which
+// may run in-process or call a remote server.
+type chatSession struct {
+	generator domain.GeneratorPort
+	model     string
+	system    string
+	history   []domain.OllamaChatMessage
+	maxBytes  int64 // JSON size a request may reach; 0 is unlimited
+}
+
+// NewChatSession starts an empty conversation answered by model (empty for the
+// default). With maxBytes above 0, the oldest turns are forgotten as needed to keep
+// each request's JSON within maxBytes.
+func NewChatSession(generator domain.GeneratorPort, model string, maxBytes int64) domain.ChatSessionPort {
+	return &chatSession{generator: generator, model: model, maxBytes: maxBytes}
+}
+
+// Send implements ChatSessionPort. A failed turn leaves the history as it was,
+// including any turns that would have been forgotten to make room.
+func (c *chatSession) Send(ctx context.Context, prompt string) (*domain.GenerateResponse, error) {
+	history, err := c.fit(prompt)
+	if err != nil {
+		return nil, err
+	}
+	req := c.request(prompt, history)
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	resp, err := c.generator.Generate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.history = append(history,
+		domain.OllamaChatMessage{Role: "user", Content: prompt},
+		domain.OllamaChatMessage{Role: "assistant", Content: resp.Response},
+	)
+	return resp, nil
+}
+
+func (c *chatSession) request(prompt string, history []domain.OllamaChatMessage) domain.GenerateRequest {
+	var messages []domain.OllamaChatMessage
+	if c.system != "" {
+		messages = append(messages, domain.OllamaChatMessage{Role: "system", Content: c.system})
+	}
+	messages = append(messages, history...)
+	return domain.GenerateRequest{Prompt: prompt, Model: c.model, Messages: messages}
+}
+
+// fit returns the most recent history that keeps the request within maxBytes,
+// starting at a user turn.
+func (c *chatSession) fit(prompt string) ([]domain.OllamaChatMessage, error) {
+	history := c.history
+	if c.maxBytes <= 0 {
+		return history, nil
+	}
+	data, _ := json.Marshal(c.request(prompt, history))
+	size := int64(len(data))
+	for size > c.maxBytes && len(history) > 0 {
+		dropped, _ := json.Marshal(history[0])
+		size -= int64(len(dropped)) + 1 // and its comma
+		history = history[1:]
+		for len(history) > 0 && history[0].Role != "user" {
+			dropped, _ = json.Marshal(history[0])
+			size -= int64(len(dropped)) + 1
+			history = history[1:]
+		}
+	}
+	if size > c.maxBytes {
+		return nil, fmt.Errorf("%w (%d bytes)", domain.ErrConversationTooLarge, c.maxBytes)
+	}
+	return history, nil
+}
+
+// Model implements ChatSessionPort
+func (c *chatSession) Model() string { return c.model }
+
+// SetModel implements ChatSessionPort
+func (c *chatSession) SetModel(model string) { c.model = model }
+
+// System implements ChatSessionPort
+func (c *chatSession) System() string { return c.system }
+
+// SetSystem implements ChatSessionPort
+func (c *chatSession) SetSystem(prompt string) { c.system = prompt }
+
+// History implements ChatSessionPort
+func (c *chatSession) History() []domain.OllamaChatMessage {
+	return append([]domain.OllamaChatMessage(nil), c.history...)
+}
+
+// Load implements ChatSessionPort
+func (c *chatSession) Load(messages []domain.OllamaChatMessage) {
+	c.system = ""
+	if len(messages) > 0 && messages[0].Role == "system" {
+		c.system, messages = messages[0].Content, messages[1:]
+	}
+	c.history = append([]domain.OllamaChatMessage(nil), messages...)
+}
+
+// Reset implements ChatSessionPort. The system prompt and model are kept.
+func (c *chatSession) Reset() { c.history = nil }
+
+// transcriptHeadings title each turn of a saved conversation.
+var transcriptHeadings = map[string]string{"system": "### System", "user": "### User", "assistant": "### Assistant"}
+
+// FormatTranscript writes a conversation as Markdown, one "### Role" section per turn.
+func FormatTranscript(messages []domain.OllamaChatMessage) []byte {
+	var buf bytes.Buffer
+	for i, m := range messages {
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "%s\n\n%s\n", transcriptHeadings[m.Role], strings.TrimSpace(m.Content))
+	}
+	return buf.Bytes()
+}
+
+// ParseTranscript reads a conversation written by FormatTranscript. A turn ends at
+// the next line that is exactly a role heading.
+func ParseTranscript(data []byte) ([]domain.OllamaChatMessage, error) {
+	roles := make(map[string]string, len(transcriptHeadings))
+	for role, heading := range transcriptHeadings {
+		roles[heading] = role
+	}
+	var messages []domain.OllamaChatMessage
+	var content strings.Builder
+	flush := func() {
+		if len(messages) > 0 {
+			messages[len(messages)-1].Content = strings.TrimSpace(content.String())
+		}
+		content.Reset()
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if role, ok := roles[strings.TrimSpace(line)]; ok {
+			flush()
+			messages = append(messages, domain.OllamaChatMessage{Role: role})
+			continue
+		}
+		if len(messages) == 0 && strings.TrimSpace(line) != "" {
+			return nil, errors.New("transcript must start with a ### System, ### User or ### Assistant heading")
+		}
+		content.WriteString(line + "\n")
+	}
+	flush()
+	return messages, nil
+}
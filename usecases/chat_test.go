@@ -0,0 +1,79 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"minivault/domain"
+	"minivault/mocks"
+	"strings"
+	"testing"
+)
+
+func TestChatSession(t *testing.T) {
+	gen := &mocks.MockGenerator{Response: "Hi there"}
+	chat := NewChatSession(gen, "gemma:2b", 0)
+	chat.SetSystem("Be brief.")
+	if _, err := chat.Send(context.Background(), "Hello"); err != nil {
+		t.Fatal(err)
+	}
+	gen.Response = "Fine"
+	if _, err := chat.Send(context.Background(), "How are you?"); err != nil {
+		t.Fatal(err)
+	}
+	req := gen.LastRequest
+	if req.Model != "gemma:2b" || req.Prompt != "How are you?" || len(req.Messages) != 3 ||
+		req.Messages[0].Content != "Be brief." || req.Messages[2].Content != "Hi there" {
+		t.Errorf("expected the system prompt and history to be sent, got %+v", req)
+	}
+
+	gen.Error = errors.New("boom")
+	if _, err := chat.Send(context.Background(), "Again?"); err == nil || len(chat.History()) != 4 {
+		t.Errorf("a failed turn should not be recorded, got %v with %d turns", err, len(chat.History()))
+	}
+
+	saved := FormatTranscript(append([]domain.OllamaChatMessage{{Role: "system", Content: chat.System()}}, chat.History()...))
+	loaded, err := ParseTranscript(saved)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := NewChatSession(gen, "", 0)
+	other.Load(loaded)
+	if other.System() != "Be brief." || len(other.History()) != 4 || other.History()[3].Content != "Fine" {
+		t.Errorf("expected the transcript to round-trip, got %q %+v", other.System(), other.History())
+	}
+	other.Reset()
+	if len(other.History()) != 0 || other.System() != "Be brief." {
+		t.Error("reset should clear the turns but keep the system prompt")
+	}
+	if _, err := ParseTranscript([]byte("no heading")); err == nil {
+		t.Error("expected text before the first heading to be refused")
+	}
+}
+
+func TestChatSession_ForgetsOldestTurnsToFit(t *testing.T) {
+	gen := &mocks.MockGenerator{Response: strings.Repeat("a", 100)}
+	chat := NewChatSession(gen, "", 600)
+	chat.SetSystem("Be brief.")
+	for i := range 5 {
+		if _, err := chat.Send(context.Background(), fmt.Sprintf("question %d", i)); err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := json.Marshal(gen.LastRequest); len(data) > 600 {
+			t.Fatalf("turn %d: request of %d bytes exceeds the limit", i, len(data))
+		}
+	}
+	history := chat.History()
+	if len(history) >= 10 || history[0].Role != "user" || history[len(history)-2].Content != "question 4" {
+		t.Errorf("expected the oldest turns to be forgotten from a user turn on, got %+v", history)
+	}
+	if req := gen.LastRequest; req.Messages[0].Content != "Be brief." {
+		t.Errorf("the system prompt should always be sent, got %+v", req.Messages)
+	}
+
+	kept := len(chat.History())
+	if _, err := chat.Send(context.Background(), strings.Repeat("x", 700)); !errors.Is(err, domain.ErrConversationTooLarge) || len(chat.History()) != kept {
+		t.Errorf("expected a prompt over the limit to be refused without losing turns, got %v", err)
+	}
+}
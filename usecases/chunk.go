@@ -0,0 +1,107 @@
+package usecases
+
+import (
+	"minivault/domain"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ChunkText splits text into spans of at most size bytes, consecutive spans sharing
+// about overlap bytes. Spans end at the last paragraph, line, sentence or word break
+// in their second half when there is one, and never split a UTF-8 sequence. For
+// markdown, headings always start a new span so chunks do not straddle sections.
+func ChunkText(text string, size, overlap int, markdown bool) []domain.TextSpan {
+	if size < 1 {
+		size = len(text)
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+	var spans []domain.TextSpan
+	for _, section := range sections(text, markdown) {
+		pos := section.Start
+		for pos < section.End {
+			end := section.End
+			if end-pos > size {
+				end = runeStart(text, pos+size)
+				if brk := lastBreak(text[pos:end], size/2); brk > 0 {
+					end = pos + brk
+				}
+				if end <= pos { // a single rune longer than size
+					_, n := utf8.DecodeRuneInString(text[pos:])
+					end = pos + n
+				}
+			}
+			if span, ok := trimSpan(text, pos, end); ok {
+				spans = append(spans, span)
+			}
+			if end >= section.End {
+				break
+			}
+			next := runeStart(text, end-overlap)
+			if next <= pos {
+				next = end
+			} else if ws := strings.IndexFunc(text[next:end], unicode.IsSpace); ws >= 0 {
+				next += ws // start the overlap on a word boundary
+			}
+			pos = next
+		}
+	}
+	return spans
+}
+
+// sections returns the whole text, or for markdown one span per heading-led section.
+// Lines inside fenced code blocks are not treated as headings.
+func sections(text string, markdown bool) []domain.TextSpan {
+	if !markdown {
+		return []domain.TextSpan{{Start: 0, End: len(text)}}
+	}
+	var out []domain.TextSpan
+	start, inFence := 0, false
+	for offset := 0; offset < len(text); {
+		lineEnd := strings.IndexByte(text[offset:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(text)
+		} else {
+			lineEnd += offset + 1
+		}
+		line := strings.TrimLeft(text[offset:lineEnd], " ")
+		switch {
+		case strings.HasPrefix(line, "```"):
+			inFence = !inFence
+		case !inFence && strings.HasPrefix(line, "#") && offset > start:
+			out = append(out, domain.TextSpan{Start: start, End: offset})
+			start = offset
+		}
+		offset = lineEnd
+	}
+	return append(out, domain.TextSpan{Start: start, End: len(text)})
+}
+
+// lastBreak returns the offset just after the best break in s beyond from,
+// preferring paragraph, then line, then sentence, then word breaks; 0 if none.
+func lastBreak(s string, from int) int {
+	for _, sep := range []string{"\n\n", "\n", ". ", "? ", "! ", " "} {
+		if i := strings.LastIndex(s, sep); i >= 0 && i+len(sep) > from {
+			return i + len(sep)
+		}
+	}
+	return 0
+}
+
+// runeStart moves i back to the start of the UTF-8 sequence containing it.
+func runeStart(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+func trimSpan(text string, start, end int) (domain.TextSpan, bool) {
+	chunk := text[start:end]
+	trimmed := strings.TrimLeftFunc(chunk, unicode.IsSpace)
+	start += len(chunk) - len(trimmed)
+	end = start + len(strings.TrimRightFunc(trimmed, unicode.IsSpace))
+	return domain.TextSpan{Start: start, End: end}, end > start
+}
@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunkText_SizeOverlapAndBoundaries(t *testing.T) {
+	text := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 20)
+	spans := ChunkText(text, 100, 20, false)
+	if len(spans) < 9 {
+		t.Fatalf("expected text to be split into many chunks, got %d", len(spans))
+	}
+	for i, s := range spans {
+		chunk := text[s.Start:s.End]
+		if len(chunk) > 100 {
+			t.Errorf("chunk %d is %d bytes", i, len(chunk))
+		}
+		if chunk != strings.TrimSpace(chunk) {
+			t.Errorf("chunk %d has surrounding whitespace: %q", i, chunk)
+		}
+		if i > 0 && s.Start >= spans[i-1].End {
+			t.Errorf("chunk %d does not overlap the previous one", i)
+		}
+		if i < len(spans)-1 && !strings.HasSuffix(chunk, ".") {
+			t.Errorf("chunk %d does not end at a sentence break: %q", i, chunk)
+		}
+	}
+	if spans[len(spans)-1].End != len(strings.TrimSpace(text)) {
+		t.Error("last chunk does not reach the end of the text")
+	}
+}
+
+func TestChunkText_SmallTextAndUTF8(t *testing.T) {
+	if spans := ChunkText("  short  ", 100, 10, false); len(spans) != 1 || spans[0].Start != 2 || spans[0].End != 7 {
+		t.Errorf("unexpected spans for short text: %+v", spans)
+	}
+	text := strings.Repeat("é", 50) // no break opportunities at all
+	for _, s := range ChunkText(text, 7, 2, false) {
+		if !utf8.ValidString(text[s.Start:s.End]) {
+			t.Fatalf("chunk splits a UTF-8 sequence: %+v", s)
+		}
+	}
+}
+
+func TestChunkText_MarkdownSections(t *testing.T) {
+	text := "# Install\nRun the installer.\n\n```sh\n# not a heading\nmake\n```\n## Configure\nEdit the file.\n"
+	spans := ChunkText(text, 1000, 0, true)
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 sections, got %+v", spans)
+	}
+	if !strings.HasPrefix(text[spans[1].Start:spans[1].End], "## Configure") {
+		t.Errorf("second chunk should start at the heading: %q", text[spans[1].Start:spans[1].End])
+	}
+	if !strings.Contains(text[spans[0].Start:spans[0].End], "# not a heading") {
+		t.Error("heading inside a code fence should not split the section")
+	}
+}
@@ -0,0 +1,96 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// embedder enforces input limits, splits requests into batches for the
+// EmbeddingPort and records each request in the interaction log.
+type embedder struct {
+	embeddings domain.EmbeddingPort
+	logger     domain.LoggerPort
+	tenants    domain.TenantsPort // nil with tenancy off
+
+	maxInputs     int
+	maxInputChars int
+	batchSize     int
+}
+
+// NewEmbedder constructs the default EmbedderPort
+func NewEmbedder(embeddings domain.EmbeddingPort, logger domain.LoggerPort, tenants domain.TenantsPort, cfg *config.Config) domain.EmbedderPort {
+	return &embedder{
+		embeddings:    embeddings,
+		logger:        logger,
+		tenants:       tenants,
+		maxInputs:     cfg.EmbedMaxInputs,
+		maxInputChars: cfg.EmbedMaxInputChars,
+		batchSize:     cfg.EmbedBatchSize,
+	}
+}
+
+// Embed implements EmbedderPort. A model the request names must be in the caller's
+// tenant's allowlist; without one, OLLAMA_EMBED_MODEL is used.
+func (e *embedder) Embed(ctx context.Context, req domain.EmbeddingRequest) (*domain.EmbeddingResponse, error) {
+	if e.tenants != nil {
+		caller, _ := domain.CallerFromContext(ctx)
+		tenant, _ := e.tenants.Tenant(caller.Tenant)
+		if _, err := tenantModel(tenant, req.Model); err != nil {
+			e.logger.LogWarn(fmt.Sprintf("tenant %s refused %v [reqID: %s]", tenant.ID, err, domain.RequestIDFromContext(ctx)))
+			return nil, err
+		}
+	}
+	if e.maxInputs > 0 && len(req.Input) > e.maxInputs {
+		return nil, fmt.Errorf("%w: %d, max %d", domain.ErrTooManyEmbeddingInputs, len(req.Input), e.maxInputs)
+	}
+	for i, text := range req.Input {
+		if n := utf8.RuneCountInString(text); e.maxInputChars > 0 && n > e.maxInputChars {
+			return nil, fmt.Errorf("%w: input %d has %d characters, max %d", domain.ErrEmbeddingInputTooLong, i, n, e.maxInputChars)
+		}
+	}
+
+	start := time.Now()
+	resp, err := embedBatches(ctx, e.embeddings, req.Model, req.Input, e.batchSize)
+	if err != nil {
+		e.logger.LogError("embedding failed", err)
+		return nil, err
+	}
+
+	interaction := newInteraction(ctx, resp.Model, strings.Join(req.Input, "\n"),
+		fmt.Sprintf("%d embeddings, %d dimensions", len(resp.Embeddings), resp.Dimensions))
+	interaction.Kind = domain.InteractionKindEmbed
+	interaction.LatencyMS = time.Since(start).Milliseconds()
+	e.logger.LogInteraction(interaction)
+	return resp, nil
+}
+
+// embedBatches embeds inputs batchSize at a time and checks that every vector has
+// the same number of dimensions.
+func embedBatches(ctx context.Context, port domain.EmbeddingPort, model string, inputs []string, batchSize int) (*domain.EmbeddingResponse, error) {
+	if batchSize < 1 {
+		batchSize = len(inputs)
+	}
+	resp := &domain.EmbeddingResponse{Model: model, Embeddings: make([][]float64, 0, len(inputs))}
+	for lo := 0; lo < len(inputs); lo += batchSize {
+		hi := min(lo+batchSize, len(inputs))
+		batch, err := port.Embed(ctx, domain.OllamaEmbedRequest{Model: model, Input: inputs[lo:hi]})
+		if err != nil {
+			return nil, fmt.Errorf("ollama embed failed: %w", err)
+		}
+		resp.Model = batch.Model
+		resp.Embeddings = append(resp.Embeddings, batch.Embeddings...)
+	}
+	for _, vec := range resp.Embeddings {
+		if resp.Dimensions == 0 {
+			resp.Dimensions = len(vec)
+		} else if len(vec) != resp.Dimensions {
+			return nil, fmt.Errorf("embedding dimensions differ between batches (%d and %d)", resp.Dimensions, len(vec))
+		}
+	}
+	return resp, nil
+}
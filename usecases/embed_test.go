@@ -0,0 +1,77 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"minivault/domain"
+	"minivault/mocks"
+	"strings"
+	"testing"
+)
+
+func TestEmbedder_Batches(t *testing.T) {
+	port := &mocks.MockEmbedding{Dimensions: 3, Model: "nomic-embed-text"}
+	logger := &mocks.MockLogger{}
+	e := &embedder{embeddings: port, logger: logger, batchSize: 2}
+	resp, err := e.Embed(context.Background(), domain.EmbeddingRequest{Input: domain.EmbeddingInput{"a", "bb", "ccc", "dddd", "eeeee"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(port.Requests) != 3 || len(port.Requests[2].Input) != 1 {
+		t.Errorf("expected batches of 2, 2, 1, got %+v", port.Requests)
+	}
+	if resp.Dimensions != 3 || resp.Model != "nomic-embed-text" || len(resp.Embeddings) != 5 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	for i, vec := range resp.Embeddings {
+		if vec[0] != float64(i+1) {
+			t.Errorf("embedding %d out of order: %v", i, vec)
+		}
+	}
+	if len(logger.Interactions) != 1 || logger.Interactions[0].Kind != domain.InteractionKindEmbed {
+		t.Errorf("embedding interaction not logged: %+v", logger.Interactions)
+	}
+}
+
+func TestEmbedder_Limits(t *testing.T) {
+	port := &mocks.MockEmbedding{Dimensions: 1}
+	e := &embedder{embeddings: port, logger: &mocks.MockLogger{}, maxInputs: 2, maxInputChars: 5}
+	_, err := e.Embed(context.Background(), domain.EmbeddingRequest{Input: domain.EmbeddingInput{"a", "b", "c"}})
+	if !errors.Is(err, domain.ErrTooManyEmbeddingInputs) {
+		t.Errorf("expected ErrTooManyEmbeddingInputs, got %v", err)
+	}
+	_, err = e.Embed(context.Background(), domain.EmbeddingRequest{Input: domain.EmbeddingInput{strings.Repeat("é", 6)}})
+	if !errors.Is(err, domain.ErrEmbeddingInputTooLong) {
+		t.Errorf("expected ErrEmbeddingInputTooLong, got %v", err)
+	}
+	if len(port.Requests) != 0 {
+		t.Error("oversized requests should not reach Ollama")
+	}
+}
+
+func TestEmbedder_PortError(t *testing.T) {
+	logger := &mocks.MockLogger{}
+	e := &embedder{embeddings: &mocks.MockEmbedding{Error: errors.New("down")}, logger: logger}
+	_, err := e.Embed(context.Background(), domain.EmbeddingRequest{Input: domain.EmbeddingInput{"a"}})
+	if err == nil || !strings.Contains(err.Error(), "ollama embed failed: down") || len(logger.Errors) != 1 {
+		t.Errorf("unexpected error handling: %v", err)
+	}
+}
+
+func TestEmbedder_TenantModels(t *testing.T) {
+	port := &mocks.MockEmbedding{Dimensions: 1}
+	tenants := &mocks.MockTenants{Tenants: []domain.Tenant{{ID: "red", Models: []string{"nomic-embed-text"}}}}
+	e := &embedder{embeddings: port, logger: &mocks.MockLogger{}, tenants: tenants}
+	ctx := domain.WithCaller(context.Background(), domain.Caller{KeyID: "alice", Tenant: "red"})
+	if _, err := e.Embed(ctx, domain.EmbeddingRequest{Model: "bge-m3", Input: domain.EmbeddingInput{"a"}}); !errors.Is(err, domain.ErrModelNotAllowed) {
+		t.Errorf("expected ErrModelNotAllowed, got %v", err)
+	}
+	for _, model := range []string{"nomic-embed-text", ""} {
+		if _, err := e.Embed(ctx, domain.EmbeddingRequest{Model: model, Input: domain.EmbeddingInput{"a"}}); err != nil {
+			t.Errorf("model %q: unexpected error %v", model, err)
+		}
+	}
+	if len(port.Requests) != 2 {
+		t.Errorf("a refused model should not reach Ollama, got %d requests", len(port.Requests))
+	}
+}
@@ -0,0 +1,138 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"minivault/domain"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// evaluator runs eval suites through the same GeneratorPort the server uses.
+type evaluator struct {
+	generator domain.GeneratorPort
+}
+
+// NewEvaluator constructs the default EvaluatorPort.
+func NewEvaluator(generator domain.GeneratorPort) domain.EvaluatorPort {
+	return &evaluator{generator: generator}
+}
+
+// Run implements EvaluatorPort. Cases run sequentially so latency assertions are not
+// skewed by the suite competing with itself.
+func (e *evaluator) Run(ctx context.Context, suite domain.EvalSuite) domain.EvalReport {
+	report := domain.EvalReport{Suite: suite.Name, StartedAt: time.Now().UTC(), Total: len(suite.Cases)}
+	var assertions, assertionsPassed int
+	for i, c := range suite.Cases {
+		if c.Name == "" {
+			c.Name = fmt.Sprintf("case-%d", i+1)
+		}
+		result := e.runCase(ctx, c)
+		if result.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		for _, a := range result.Assertions {
+			assertions++
+			if a.Passed {
+				assertionsPassed++
+			}
+		}
+		report.Cases = append(report.Cases, result)
+	}
+	report.DurationMS = time.Since(report.StartedAt).Milliseconds()
+	if report.Total > 0 {
+		report.Score = float64(report.Passed) / float64(report.Total)
+	}
+	if assertions > 0 {
+		report.AssertionScore = float64(assertionsPassed) / float64(assertions)
+	}
+	return report
+}
+
+func (e *evaluator) runCase(ctx context.Context, c domain.EvalCase) domain.EvalCaseResult {
+	result := domain.EvalCaseResult{Name: c.Name}
+	req := domain.GenerateRequest{Prompt: c.Prompt, Options: c.Options, Format: c.Format}
+	if err := req.Validate(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	ctx = domain.WithRequestID(ctx, "eval:"+c.Name)
+	start := time.Now()
+	resp, err := e.generator.Generate(ctx, req)
+	result.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Response = resp.Response
+
+	result.Passed = true
+	for _, a := range c.Assertions {
+		ar := checkAssertion(a, resp.Response, result.LatencyMS)
+		result.Passed = result.Passed && ar.Passed
+		result.Assertions = append(result.Assertions, ar)
+	}
+	return result
+}
+
+// checkAssertion evaluates one assertion against a response.
+func checkAssertion(a domain.EvalAssertion, response string, latencyMS int64) domain.EvalAssertionResult {
+	res := domain.EvalAssertionResult{Type: a.Type, Passed: true}
+	fail := func(format string, args ...any) domain.EvalAssertionResult {
+		res.Passed = false
+		res.Message = fmt.Sprintf(format, args...)
+		return res
+	}
+
+	switch a.Type {
+	case domain.AssertContains:
+		if !strings.Contains(response, a.Value) {
+			return fail("response does not contain %q", a.Value)
+		}
+	case domain.AssertNotContains:
+		if strings.Contains(response, a.Value) {
+			return fail("response contains %q", a.Value)
+		}
+	case domain.AssertRegex:
+		re, err := regexp.Compile(a.Value)
+		if err != nil {
+			return fail("invalid regex: %v", err)
+		}
+		if !re.MatchString(response) {
+			return fail("response does not match %q", a.Value)
+		}
+	case domain.AssertValidJSON:
+		if !json.Valid([]byte(stripCodeFence(response))) {
+			return fail("response is not valid JSON")
+		}
+	case domain.AssertJSONSchema:
+		var v any
+		if err := json.Unmarshal([]byte(stripCodeFence(response)), &v); err != nil {
+			return fail("response is not valid JSON: %v", err)
+		}
+		errs, err := ValidateJSONSchema(a.Schema, v)
+		if err != nil {
+			return fail("%v", err)
+		}
+		if len(errs) > 0 {
+			return fail("%s", strings.Join(errs, "; "))
+		}
+	case domain.AssertMaxLength:
+		if n := utf8.RuneCountInString(response); int64(n) > a.Max {
+			return fail("response is %d characters, max %d", n, a.Max)
+		}
+	case domain.AssertMaxLatency:
+		if latencyMS > a.Max {
+			return fail("took %d ms, max %d ms", latencyMS, a.Max)
+		}
+	default:
+		return fail("unknown assertion type %q", a.Type)
+	}
+	return res
+}
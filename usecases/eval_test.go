@@ -0,0 +1,69 @@
+package usecases
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"minivault/domain"
+	"minivault/mocks"
+	"testing"
+)
+
+func TestEvaluator_Run(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Response: `{"city": "Paris"}`}
+	suite := domain.EvalSuite{Name: "geo", Cases: []domain.EvalCase{
+		{Name: "capital", Prompt: "Capital of France as JSON?", Assertions: []domain.EvalAssertion{
+			{Type: domain.AssertContains, Value: "Paris"},
+			{Type: domain.AssertNotContains, Value: "London"},
+			{Type: domain.AssertRegex, Value: `"city"\s*:`},
+			{Type: domain.AssertValidJSON},
+			{Type: domain.AssertJSONSchema, Schema: json.RawMessage(`{"type":"object","required":["city"]}`)},
+			{Type: domain.AssertMaxLength, Max: 100},
+			{Type: domain.AssertMaxLatency, Max: 60000},
+		}},
+		{Name: "strict", Prompt: "p", Assertions: []domain.EvalAssertion{
+			{Type: domain.AssertMaxLength, Max: 3},
+			{Type: domain.AssertJSONSchema, Schema: json.RawMessage(`{"required":["country"]}`)},
+		}},
+	}}
+
+	report := NewEvaluator(mockGen).Run(context.Background(), suite)
+
+	if report.Total != 2 || report.Passed != 1 || report.Failed != 1 || report.Score != 0.5 {
+		t.Fatalf("unexpected totals: %+v", report)
+	}
+	if report.AssertionScore != 7.0/9.0 {
+		t.Errorf("unexpected assertion score %f", report.AssertionScore)
+	}
+	if !report.Cases[0].Passed {
+		t.Errorf("first case should pass: %+v", report.Cases[0])
+	}
+	for _, a := range report.Cases[1].Assertions {
+		if a.Passed || a.Message == "" {
+			t.Errorf("expected failure with message, got %+v", a)
+		}
+	}
+	if got := domain.RequestIDFromContext(mockGen.LastCtx); got != "eval:strict" {
+		t.Errorf("expected eval request ID, got %q", got)
+	}
+}
+
+func TestEvaluator_JSONInCodeFence(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Response: "```json\n{\"city\": \"Paris\"}\n```"}
+	suite := domain.EvalSuite{Cases: []domain.EvalCase{{Prompt: "p", Assertions: []domain.EvalAssertion{
+		{Type: domain.AssertValidJSON},
+		{Type: domain.AssertJSONSchema, Schema: json.RawMessage(`{"type":"object","required":["city"]}`)},
+	}}}}
+	report := NewEvaluator(mockGen).Run(context.Background(), suite)
+	if report.Passed != 1 {
+		t.Errorf("expected the fenced JSON to pass: %+v", report.Cases[0].Assertions)
+	}
+}
+
+func TestEvaluator_GenerationError(t *testing.T) {
+	mockGen := &mocks.MockGenerator{Error: errors.New("ollama down")}
+	report := NewEvaluator(mockGen).Run(context.Background(), domain.EvalSuite{Cases: []domain.EvalCase{{Prompt: "p"}}})
+	if report.Failed != 1 || report.Cases[0].Error != "ollama down" || report.Cases[0].Name != "case-1" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+}
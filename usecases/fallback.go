@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"minivault/domain"
+	"slices"
+)
+
+// fallbackChain is an OllamaPort that tries each step in order until one answers.
+// Only failures whose class is in the policy move on to the next step; anything
+// else, or a cancelled request, is returned immediately.
+type fallbackChain struct {
+	steps  []domain.FallbackStep
+	policy []string
+	logger domain.LoggerPort
+}
+
+// NewFallbackChain wraps primary and the fallback steps after it. primary is wrapped
+// even without fallback steps, so that tenants' model allowlists apply to it.
+func NewFallbackChain(primary domain.FallbackStep, steps []domain.FallbackStep, policy []string, logger domain.LoggerPort) (domain.OllamaPort, error) {
+	for _, class := range policy {
+		switch class {
+		case domain.FailureTimeout, domain.FailureMissing, domain.FailureUnavailable, domain.FailureRejected:
+		default:
+			return nil, fmt.Errorf("unknown FALLBACK_ON class %q", class)
+		}
+	}
+	return &fallbackChain{steps: append([]domain.FallbackStep{primary}, steps...), policy: policy, logger: logger}, nil
+}
+
+// CallOllama implements OllamaPort. The response's Model names the model that answered.
+// A request that names its model goes to the primary step's backend and is not
+// swapped for another model. Otherwise only the steps whose model is allowed by
+// domain.AllowedModelsFromContext are tried. A streamed reply that fails after
+// sending text is not retried, since the caller has already seen part of it.
+func (f *fallbackChain) CallOllama(ctx context.Context, req domain.OllamaChatRequest) (*domain.OllamaChatResponse, error) {
+	if req.Model != "" {
+		primary := f.steps[0]
+		primary.Model = req.Model
+		return f.call(ctx, primary, req)
+	}
+	streamed := false
+	if sink := domain.TokenSinkFromContext(ctx); sink != nil {
+		ctx = domain.WithTokenSink(ctx, func(token string) {
+			streamed = true
+			sink(token)
+		})
+	}
+	steps := f.allowedSteps(domain.AllowedModelsFromContext(ctx))
+	if len(steps) == 1 {
+		return f.call(ctx, steps[0], req)
+	}
+	var errs []error
+	for i, step := range steps {
+		resp, err := f.call(ctx, step, req)
+		if err == nil {
+			return resp, nil
+		}
+		model := step.Model
+		if model == "" {
+			model = "default model"
+		}
+		class := failureClass(err)
+		f.logger.LogError(fmt.Sprintf("model attempt %d/%d (%s) failed [class: %s, reqID: %s]",
+			i+1, len(steps), model, class, domain.RequestIDFromContext(ctx)), err)
+		errs = append(errs, fmt.Errorf("%s: %w", model, err))
+		if ctx.Err() != nil || streamed || !slices.Contains(f.policy, class) {
+			break
+		}
+	}
+	return nil, fmt.Errorf("%d model attempt(s) failed: %w", len(errs), errors.Join(errs...))
+}
+
+// allowedSteps returns the steps whose model is in models, all of them when models
+// is empty. When no step is allowed, the first allowed model is tried on the
+// primary step's backend instead.
+func (f *fallbackChain) allowedSteps(models []string) []domain.FallbackStep {
+	if len(models) == 0 {
+		return f.steps
+	}
+	var steps []domain.FallbackStep
+	for _, step := range f.steps {
+		if slices.Contains(models, step.Model) {
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) == 0 {
+		primary := f.steps[0]
+		primary.Model = models[0]
+		steps = append(steps, primary)
+	}
+	return steps
+}
+
+func (f *fallbackChain) call(ctx context.Context, step domain.FallbackStep, req domain.OllamaChatRequest) (*domain.OllamaChatResponse, error) {
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
+	req.Model = step.Model
+	resp, err := step.Ollama.CallOllama(ctx, req)
+	if err == nil && resp.Model == "" {
+		resp.Model = step.Model
+	}
+	return resp, err
+}
+
+// failureClass maps an Ollama error to its FALLBACK_ON class, or "" for errors no
+// policy covers.
+func failureClass(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrUpstreamTimeout), errors.Is(err, context.DeadlineExceeded):
+		return domain.FailureTimeout
+	case errors.Is(err, domain.ErrModelNotFound):
+		return domain.FailureMissing
+	case errors.Is(err, domain.ErrUpstreamUnavailable):
+		return domain.FailureUnavailable
+	case errors.Is(err, domain.ErrUpstreamRejected):
+		return domain.FailureRejected
+	}
+	return ""
+}
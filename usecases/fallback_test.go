@@ -0,0 +1,115 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"minivault/domain"
+	"minivault/mocks"
+	"strings"
+	"testing"
+	"time"
+)
+
+var defaultPolicy = []string{domain.FailureTimeout, domain.FailureMissing, domain.FailureUnavailable}
+
+func TestFallbackChain_FallsBackInOrder(t *testing.T) {
+	primary := &mocks.MockOllama{Error: fmt.Errorf("%w: model 'llama3:8b' not found", domain.ErrModelNotFound)}
+	second := &mocks.MockOllama{Error: fmt.Errorf("%w: status 503", domain.ErrUpstreamUnavailable)}
+	third := &mocks.MockOllama{Response: "hi"}
+	logger := &mocks.MockLogger{}
+	chain, err := NewFallbackChain(domain.FallbackStep{Ollama: primary}, []domain.FallbackStep{
+		{Model: "gemma:2b", Ollama: second},
+		{Model: "gemma:2b", Ollama: third},
+	}, defaultPolicy, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := chain.CallOllama(context.Background(), domain.OllamaChatRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Message.Content != "hi" || third.LastRequest.Model != "gemma:2b" {
+		t.Errorf("expected the third step to answer as gemma:2b, got %+v / %q", resp, third.LastRequest.Model)
+	}
+	if len(logger.Errors) != 2 {
+		t.Errorf("expected both failed attempts to be logged, got %d", len(logger.Errors))
+	}
+}
+
+func TestFallbackChain_NamedModelIsNotSwapped(t *testing.T) {
+	primary := &mocks.MockOllama{Error: domain.ErrModelNotFound}
+	backup := &mocks.MockOllama{Response: "hi"}
+	chain, _ := NewFallbackChain(domain.FallbackStep{Model: "llama3:8b", Ollama: primary}, []domain.FallbackStep{{Model: "gemma:2b", Ollama: backup}}, defaultPolicy, &mocks.MockLogger{})
+
+	_, err := chain.CallOllama(context.Background(), domain.OllamaChatRequest{Model: "mistral:7b"})
+	if !errors.Is(err, domain.ErrModelNotFound) || primary.LastRequest.Model != "mistral:7b" || backup.Calls != 0 {
+		t.Errorf("expected only the named model to be tried, got %v / %q / %d backup call(s)", err, primary.LastRequest.Model, backup.Calls)
+	}
+}
+
+func TestFallbackChain_PolicyStopsOnOtherFailures(t *testing.T) {
+	primary := &mocks.MockOllama{Error: fmt.Errorf("%w: status 400", domain.ErrUpstreamRejected)}
+	backup := &mocks.MockOllama{Response: "hi"}
+	chain, _ := NewFallbackChain(domain.FallbackStep{Ollama: primary}, []domain.FallbackStep{{Model: "gemma:2b", Ollama: backup}}, defaultPolicy, &mocks.MockLogger{})
+
+	_, err := chain.CallOllama(context.Background(), domain.OllamaChatRequest{})
+	if !errors.Is(err, domain.ErrUpstreamRejected) || backup.Calls != 0 {
+		t.Errorf("a rejected request should not fall back: %v, backup calls %d", err, backup.Calls)
+	}
+}
+
+func TestFallbackChain_StepTimeout(t *testing.T) {
+	slow := ollamaFunc(func(ctx context.Context, req domain.OllamaChatRequest) (*domain.OllamaChatResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	backup := &mocks.MockOllama{Response: "fast", Model: "gemma:2b"}
+	chain, _ := NewFallbackChain(domain.FallbackStep{Ollama: slow, Timeout: 10 * time.Millisecond},
+		[]domain.FallbackStep{{Model: "gemma:2b", Ollama: backup}}, defaultPolicy, &mocks.MockLogger{})
+
+	resp, err := chain.CallOllama(context.Background(), domain.OllamaChatRequest{})
+	if err != nil || resp.Model != "gemma:2b" {
+		t.Errorf("expected the backup to answer after the timeout, got %+v %v", resp, err)
+	}
+}
+
+func TestFallbackChain_AllFail(t *testing.T) {
+	down := &mocks.MockOllama{Error: domain.ErrUpstreamUnavailable}
+	chain, _ := NewFallbackChain(domain.FallbackStep{Ollama: down}, []domain.FallbackStep{{Model: "gemma:2b", Ollama: down}}, defaultPolicy, &mocks.MockLogger{})
+
+	_, err := chain.CallOllama(context.Background(), domain.OllamaChatRequest{})
+	if err == nil || !strings.Contains(err.Error(), "2 model attempt(s) failed") {
+		t.Errorf("expected both attempts in the error, got %v", err)
+	}
+}
+
+func TestNewFallbackChain_UnknownClass(t *testing.T) {
+	_, err := NewFallbackChain(domain.FallbackStep{}, []domain.FallbackStep{{}}, []string{"sometimes"}, &mocks.MockLogger{})
+	if err == nil {
+		t.Error("expected error for unknown FALLBACK_ON class")
+	}
+}
+
+type ollamaFunc func(ctx context.Context, req domain.OllamaChatRequest) (*domain.OllamaChatResponse, error)
+
+func (f ollamaFunc) CallOllama(ctx context.Context, req domain.OllamaChatRequest) (*domain.OllamaChatResponse, error) {
+	return f(ctx, req)
+}
+
+func TestService_Generate_ReportsFallbackModel(t *testing.T) {
+	primary := &mocks.MockOllama{Error: domain.ErrModelNotFound}
+	backup := &mocks.MockOllama{Response: "ok"}
+	chain, _ := NewFallbackChain(domain.FallbackStep{Ollama: primary}, []domain.FallbackStep{{Model: "gemma:2b", Ollama: backup}}, defaultPolicy, &mocks.MockLogger{})
+	logger := &mocks.MockLogger{}
+	g := &service{ollama: chain, logger: logger}
+
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "hi"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Model != "gemma:2b" || logger.Interactions[0].Model != "gemma:2b" {
+		t.Errorf("expected gemma:2b to be reported, got %q / %q", resp.Model, logger.Interactions[0].Model)
+	}
+}
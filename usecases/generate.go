@@ -1,31 +1,382 @@
 package usecases
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"minivault/domain"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // service is the default implementation, depends on OllamaPort and Logger
 // (Logger interface is from infrastructure)
 type service struct {
-	ollama domain.OllamaPort
-	logger domain.LoggerPort
+	ollama  domain.OllamaPort
+	logger  domain.LoggerPort
+	tools   domain.ToolboxPort       // nil when no server-side tools are enabled
+	kb      domain.KnowledgeBasePort // nil when retrieval is not available
+	guard   domain.GuardrailPort     // nil when no input policies are configured
+	filter  domain.OutputFilterPort  // nil when no output filters are configured
+	tenants domain.TenantsPort       // nil when tenancy is off
+
+	// structuredRetries is how many times a reply that does not satisfy the
+	// requested format is sent back to the model with the validation errors.
+	structuredRetries int
+	// maxToolRounds bounds how many times the model may call server-side tools
+	// before it has to answer.
+	maxToolRounds int
 }
 
-// NewGenerator constructs the default Generator
-func NewGenerator(ollama domain.OllamaPort, logger domain.LoggerPort) domain.GeneratorPort {
-	return &service{ollama: ollama, logger: logger}
+// NewGenerator constructs the default Generator. structuredRetries and maxToolRounds
+// bound the format repair and server tool loops.
+func NewGenerator(ollama domain.OllamaPort, logger domain.LoggerPort, tools domain.ToolboxPort, kb domain.KnowledgeBasePort, guard domain.GuardrailPort, filter domain.OutputFilterPort, tenants domain.TenantsPort, structuredRetries, maxToolRounds int) domain.GeneratorPort {
+	return &service{
+		ollama:            ollama,
+		logger:            logger,
+		tools:             tools,
+		kb:                kb,
+		guard:             guard,
+		filter:            filter,
+		tenants:           tenants,
+		structuredRetries: structuredRetries,
+		maxToolRounds:     maxToolRounds,
+	}
 }
 
-// Generate implements GeneratorPort
-func (g *service) Generate(prompt string) (string, error) {
+// Generate implements GeneratorPort. The caller's tenant decides which models may be
+// used and adds its system prompt. Input policies run first; a blocking match is
+// logged and returned as a *domain.PolicyError. Retrieved document chunks, if
+// requested, are given to the model in a system message. With a domain.TokenSink in
+// ctx, answers are streamed to it as they are generated. The model may call server-side tools,
+// whose results are fed back as "tool" messages, and structured-output replies that
+// fail validation are sent back for repair; both loops are bounded. Output filters
+// run over each answer before it is validated, returned or logged. A failure after
+// the model has been called is a *domain.UsageError carrying the tokens used so far.
+func (g *service) Generate(ctx context.Context, req domain.GenerateRequest) (*domain.GenerateResponse, error) {
 	// prompt validation is now handled in the domain layer (interfaces)
-	response, err := g.ollama.CallOllama(prompt)
+	start := time.Now()
+	tenant, _ := g.tenant(ctx)
+	model, err := tenantModel(tenant, req.Model)
+	if err != nil {
+		g.logger.LogWarn(fmt.Sprintf("tenant %s refused %v [reqID: %s]", tenant.ID, err, domain.RequestIDFromContext(ctx)))
+		return nil, err
+	}
+	if len(tenant.Models) > 0 {
+		ctx = domain.WithAllowedModels(ctx, tenant.Models)
+	}
+	policies, err := g.checkInput(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	tools, err := g.requestTools(req)
 	if err != nil {
-		err = fmt.Errorf("ollama call failed: %w", err)
-		g.logger.LogError("generation failed", err)
-		return "", err
+		return nil, err
+	}
+	messages := req.ChatMessages()
+	var cited []domain.Citation
+	if req.Retrieval != nil {
+		if g.kb == nil {
+			return nil, fmt.Errorf("%w: retrieval is not enabled", domain.ErrCollectionNotFound)
+		}
+		chunks, err := g.kb.Retrieve(ctx, *req.Retrieval, req.LastUserInput())
+		if err != nil {
+			err = fmt.Errorf("retrieval failed: %w", err)
+			g.logger.LogError("generation failed", err)
+			return nil, err
+		}
+		if len(chunks) > 0 {
+			grounding := domain.OllamaChatMessage{Role: "system", Content: retrievalPrompt(chunks)}
+			messages = append([]domain.OllamaChatMessage{grounding}, messages...)
+			cited = citations(chunks)
+		}
+	}
+	// the tenant's prompt comes first and is not checked by the input policies
+	if tenant.SystemPrompt != "" {
+		messages = append([]domain.OllamaChatMessage{{Role: "system", Content: tenant.SystemPrompt}}, messages...)
+	}
+	var toolsUsed []domain.ToolInvocation
+	var usage domain.Usage
+	fail := func(err error) (*domain.GenerateResponse, error) {
+		if usage.TotalTokens == 0 {
+			return nil, err
+		}
+		return nil, &domain.UsageError{Err: err, Usage: usage}
+	}
+	repairs, toolRounds := 0, 0
+	for {
+		// structured output is only returned once it validates, so it is not streamed
+		callCtx, stream := g.streamReply(ctx, len(req.Format) == 0)
+		chatResp, err := g.ollama.CallOllama(callCtx, domain.OllamaChatRequest{
+			Model:    model,
+			Messages: messages,
+			Options:  req.Options,
+			Format:   req.Format,
+			Tools:    tools,
+		})
+		stream.stop()
+		if err == nil {
+			usage.Add(chatResp)
+		} else {
+			// a reply cut short, by a filter or a cancelled stream, was still generated
+			usage.AddStreamed(stream.streamed())
+		}
+		if stream != nil && stream.err != nil {
+			_, _, err = g.filterOutput(ctx, req, stream, model, "", policies, &usage)
+			return fail(err)
+		}
+		if err != nil {
+			err = fmt.Errorf("ollama call failed: %w", err)
+			g.logger.LogError("generation failed", err)
+			return fail(err)
+		}
+		reply := chatResp.Message
+		response := reply.Content
+
+		if len(reply.ToolCalls) > 0 && g.serverSide(req, reply.ToolCalls) {
+			if toolRounds >= g.maxToolRounds {
+				err := fmt.Errorf("%w (%d rounds)", domain.ErrToolRoundsExceeded, toolRounds)
+				g.logger.LogError("generation failed", err)
+				return fail(err)
+			}
+			toolRounds++
+			messages = append(messages, domain.OllamaChatMessage{Role: "assistant", Content: response, ToolCalls: reply.ToolCalls})
+			for _, call := range reply.ToolCalls {
+				invocation := g.runTool(ctx, call)
+				toolsUsed = append(toolsUsed, invocation)
+				result := invocation.Result
+				if invocation.Error != "" {
+					result = "error: " + invocation.Error
+				}
+				messages = append(messages, domain.OllamaChatMessage{Role: "tool", Content: result, ToolName: call.Function.Name})
+			}
+			continue
+		}
+
+		response, filtered, err := g.filterOutput(ctx, req, stream, chatResp.Model, response, policies, &usage)
+		if err != nil {
+			return fail(err)
+		}
+
+		// Calls for client-side tools end the turn; the client runs them and continues the conversation.
+		var output json.RawMessage
+		if len(req.Format) > 0 && len(reply.ToolCalls) == 0 {
+			var problems []string
+			output, problems = checkStructuredOutput(req.Schema(), response)
+			if len(problems) > 0 {
+				if repairs >= g.structuredRetries {
+					err := fmt.Errorf("%w after %d attempt(s): %s", domain.ErrInvalidStructuredOutput, repairs+1, strings.Join(problems, "; "))
+					g.logger.LogError("generation failed", err)
+					return fail(err)
+				}
+				repairs++
+				g.logger.LogWarn(fmt.Sprintf("structured output rejected (attempt %d): %s", repairs, strings.Join(problems, "; ")))
+				messages = append(messages,
+					domain.OllamaChatMessage{Role: "assistant", Content: response},
+					domain.OllamaChatMessage{Role: "user", Content: repairPrompt(problems)},
+				)
+				continue
+			}
+		}
+
+		interaction := newInteraction(ctx, chatResp.Model, req.LastUserInput(), response)
+		interaction.LatencyMS = time.Since(start).Milliseconds()
+		interaction.Usage = &usage
+		interaction.Policies = policies
+		interaction.Filters = filtered
+		g.logger.LogInteraction(interaction)
+		return &domain.GenerateResponse{
+			Response:  response,
+			Model:     chatResp.Model,
+			JSON:      output,
+			ToolCalls: reply.ToolCalls,
+			ToolsUsed: toolsUsed,
+			Citations: cited,
+			Filters:   filtered,
+			Usage:     &usage,
+		}, nil
+	}
+}
+
+// tenant returns the caller's tenant; ok is false when tenancy is off or the caller
+// belongs to no tenant.
+func (g *service) tenant(ctx context.Context) (domain.Tenant, bool) {
+	if g.tenants == nil {
+		return domain.Tenant{}, false
+	}
+	caller, _ := domain.CallerFromContext(ctx)
+	return g.tenants.Tenant(caller.Tenant)
+}
+
+// tenantModel refuses a model outside the tenant's allowlist. A request that names
+// no model keeps the fallback chain, which the allowlist in ctx limits.
+func tenantModel(tenant domain.Tenant, model string) (string, error) {
+	switch {
+	case len(tenant.Models) == 0, model == "":
+		return model, nil
+	case !slices.Contains(tenant.Models, model):
+		return "", fmt.Errorf("%w: %s", domain.ErrModelNotAllowed, model)
+	}
+	return model, nil
+}
+
+// checkInput runs the input policies. Warnings are logged; any blocking match ends
+// the request, and the blocked attempt is recorded in the interaction log.
+func (g *service) checkInput(ctx context.Context, req domain.GenerateRequest) ([]domain.PolicyResult, error) {
+	if g.guard == nil {
+		return nil, nil
+	}
+	results := g.guard.Check(req.InputText())
+	blocked := false
+	for _, r := range results {
+		switch r.Action {
+		case domain.PolicyActionBlock:
+			blocked = true
+		case domain.PolicyActionWarn:
+			g.logger.LogWarn(fmt.Sprintf("input policy %s matched: %s [reqID: %s]", r.Policy, r.Reason, domain.RequestIDFromContext(ctx)))
+		}
+	}
+	if blocked {
+		interaction := newInteraction(ctx, "", req.LastUserInput(), "")
+		interaction.Policies = results
+		g.logger.LogInteraction(interaction)
+		return nil, &domain.PolicyError{Results: results}
+	}
+	return results, nil
+}
+
+// filterOutput runs the output filters over an answer, or finishes a streamed one
+// that went through them token by token. A blocked answer is recorded in the
+// interaction log without its text.
+func (g *service) filterOutput(ctx context.Context, req domain.GenerateRequest, stream *replyStream, model, response string, policies []domain.PolicyResult, usage *domain.Usage) (string, []domain.FilterResult, error) {
+	var filtered string
+	var results []domain.FilterResult
+	var err error
+	switch {
+	case stream != nil:
+		filtered, results, err = stream.finish()
+	case g.filter != nil:
+		filtered, results, err = g.filter.Filter(response)
+	default:
+		return response, nil, nil
+	}
+	var filterErr *domain.FilterError
+	if errors.As(err, &filterErr) {
+		interaction := newInteraction(ctx, model, req.LastUserInput(), "")
+		interaction.Usage = usage
+		interaction.Policies = policies
+		interaction.Filters = filterErr.Results
+		g.logger.LogInteraction(interaction)
+	}
+	return filtered, results, err
+}
+
+// requestTools merges the client's tool definitions with the server tools it asked for.
+func (g *service) requestTools(req domain.GenerateRequest) ([]domain.Tool, error) {
+	tools := req.Tools
+	if len(req.ServerTools) == 0 {
+		return tools, nil
+	}
+	if g.tools == nil {
+		return nil, fmt.Errorf("%w: server tools are not enabled", domain.ErrUnknownTool)
+	}
+	defs, err := g.tools.Tools(req.ServerTools)
+	if err != nil {
+		return nil, err
+	}
+	for _, def := range defs {
+		for _, t := range req.Tools {
+			if t.Function.Name == def.Function.Name {
+				return nil, fmt.Errorf("%w: %q is both a client and a server tool", domain.ErrInvalidTool, def.Function.Name)
+			}
+		}
+	}
+	return append(append([]domain.Tool(nil), tools...), defs...), nil
+}
+
+// serverSide reports whether every call targets a server tool the request enabled.
+// A mix of client and server calls is returned to the client as-is.
+func (g *service) serverSide(req domain.GenerateRequest, calls []domain.ToolCall) bool {
+	for _, call := range calls {
+		if !slices.Contains(req.ServerTools, call.Function.Name) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *service) runTool(ctx context.Context, call domain.ToolCall) domain.ToolInvocation {
+	invocation := domain.ToolInvocation{Name: call.Function.Name, Arguments: call.Function.Arguments}
+	result, err := g.tools.Call(ctx, call)
+	if err != nil {
+		invocation.Error = err.Error()
+		g.logger.LogWarn(fmt.Sprintf("tool %s failed: %v", call.Function.Name, err))
+	} else {
+		invocation.Result = result
+	}
+	return invocation
+}
+
+// checkStructuredOutput parses a reply as JSON and validates it against schema (if
+// any), returning the compacted JSON or the problems to report back to the model.
+// Models often wrap JSON in a markdown code fence even when asked not to, so a
+// single surrounding fence is tolerated.
+func checkStructuredOutput(schema json.RawMessage, response string) (json.RawMessage, []string) {
+	text := stripCodeFence(response)
+	var value any
+	if err := json.Unmarshal([]byte(text), &value); err != nil {
+		return nil, []string{"reply is not valid JSON: " + err.Error()}
+	}
+	if schema != nil {
+		problems, err := ValidateJSONSchema(schema, value)
+		if err != nil {
+			return nil, []string{err.Error()}
+		}
+		if len(problems) > 0 {
+			return nil, problems
+		}
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(text)); err != nil {
+		return nil, []string{"reply is not valid JSON: " + err.Error()}
+	}
+	return buf.Bytes(), nil
+}
+
+func stripCodeFence(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") || len(s) < 6 {
+		return s
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "```"), "```")
+	if nl := strings.IndexByte(s, '\n'); nl >= 0 && !strings.ContainsAny(s[:nl], "{[") {
+		s = s[nl+1:] // drop the info string, e.g. ```json
+	}
+	return strings.TrimSpace(s)
+}
+
+func repairPrompt(problems []string) string {
+	return "Your previous reply was rejected:\n- " + strings.Join(problems, "\n- ") +
+		"\nReply again with only the corrected JSON, without explanations or code fences."
+}
+
+// newInteraction builds the interaction record, taking request ID and caller from ctx.
+func newInteraction(ctx context.Context, model, prompt, response string) domain.Interaction {
+	caller, _ := domain.CallerFromContext(ctx)
+	return domain.Interaction{
+		ID:        uuid.New().String(),
+		Kind:      domain.InteractionKindGenerate,
+		RequestID: domain.RequestIDFromContext(ctx),
+		Time:      time.Now().UTC(),
+		Model:     model,
+		APIKeyID:  caller.KeyID,
+		Tenant:    caller.Tenant,
+		Prompt:    prompt,
+		Response:  response,
 	}
-	g.logger.LogInteraction(prompt, response)
-	return response, nil
 }
@@ -1,7 +1,10 @@
 package usecases
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"minivault/domain"
 	"minivault/mocks"
 	"testing"
 	"strings"
@@ -11,8 +14,8 @@ func TestService_Generate_Success(t *testing.T) {
 	mockOllama := &mocks.MockOllama{Response: "ok"}
 	mockLogger := &mocks.MockLogger{}
 	g := &service{ollama: mockOllama, logger: mockLogger}
-	resp, err := g.Generate("prompt")
-	if err != nil || resp != "ok" {
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "prompt"})
+	if err != nil || resp.Response != "ok" {
 		t.Errorf("unexpected: %v %v", resp, err)
 	}
 	if len(mockLogger.Interactions) != 1 {
@@ -27,7 +30,7 @@ func TestService_Generate_LoggerValues(t *testing.T) {
 	g := &service{ollama: mockOllama, logger: mockLogger}
 	prompt := "foo"
 	response := "ok"
-	g.Generate(prompt)
+	g.Generate(context.Background(), domain.GenerateRequest{Prompt: prompt})
 	if len(mockLogger.Interactions) == 0 || mockLogger.Interactions[0].Prompt != prompt || mockLogger.Interactions[0].Response != response {
 		t.Error("logger did not record correct prompt/response")
 	}
@@ -38,7 +41,7 @@ func TestService_Generate_MultipleCalls(t *testing.T) {
 	mockLogger := &mocks.MockLogger{}
 	g := &service{ollama: mockOllama, logger: mockLogger}
 	for i := 0; i < 5; i++ {
-		g.Generate("p")
+		g.Generate(context.Background(), domain.GenerateRequest{Prompt: "p"})
 	}
 	if len(mockLogger.Interactions) != 5 {
 		t.Error("logger should record all calls")
@@ -51,8 +54,8 @@ func TestService_Generate_OllamaError_MessageAndLogger(t *testing.T) {
 	mockOllama := &mocks.MockOllama{Error: ollamaErr}
 	mockLogger := &mocks.MockLogger{}
 	g := &service{ollama: mockOllama, logger: mockLogger}
-	resp, err := g.Generate("prompt")
-	if err == nil || resp != "" {
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "prompt"})
+	if err == nil || resp != nil {
 		t.Error("ollama error should propagate")
 	}
 	if !strings.Contains(err.Error(), "ollama call failed: fail") {
@@ -65,3 +68,378 @@ func TestService_Generate_OllamaError_MessageAndLogger(t *testing.T) {
 		t.Error("interaction should not be logged on ollama error")
 	}
 }
+
+func TestService_Generate_InteractionMetadata(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: "ok", Model: "gemma:2b"}
+	mockLogger := &mocks.MockLogger{}
+	g := &service{ollama: mockOllama, logger: mockLogger}
+	ctx := domain.WithRequestID(context.Background(), "req-1")
+	ctx = domain.WithCaller(ctx, domain.Caller{KeyID: "alice"})
+	g.Generate(ctx, domain.GenerateRequest{Prompt: "p"})
+	if len(mockLogger.Interactions) != 1 {
+		t.Fatal("interaction not logged")
+	}
+	got := mockLogger.Interactions[0]
+	if got.ID == "" || got.RequestID != "req-1" || got.APIKeyID != "alice" || got.Model != "gemma:2b" || got.Time.IsZero() {
+		t.Errorf("unexpected interaction metadata: %+v", got)
+	}
+}
+
+func TestService_Generate_StructuredOutput(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: "```json\n{\"name\": \"Ada\", \"age\": 36}\n```"}
+	mockLogger := &mocks.MockLogger{}
+	g := &service{ollama: mockOllama, logger: mockLogger}
+	schema := json.RawMessage(`{"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}`)
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "p", Format: schema})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.JSON) != `{"name":"Ada","age":36}` {
+		t.Errorf("unexpected json: %s", resp.JSON)
+	}
+	if string(mockOllama.LastRequest.Format) != string(schema) {
+		t.Errorf("format not forwarded: %s", mockOllama.LastRequest.Format)
+	}
+}
+
+func TestService_Generate_StructuredOutputRepair(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Responses: []string{"not json", `{"age": 36}`, `{"name": "Ada"}`}}
+	mockLogger := &mocks.MockLogger{}
+	g := &service{ollama: mockOllama, logger: mockLogger, structuredRetries: 2}
+	schema := json.RawMessage(`{"type":"object","required":["name"]}`)
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "p", Format: schema})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(resp.JSON) != `{"name":"Ada"}` || mockOllama.Calls != 3 {
+		t.Errorf("unexpected result after %d calls: %s", mockOllama.Calls, resp.JSON)
+	}
+	msgs := mockOllama.LastRequest.Messages
+	if len(msgs) != 5 || msgs[3].Role != "assistant" || !strings.Contains(msgs[4].Content, `missing required property "name"`) {
+		t.Errorf("repair conversation not built as expected: %+v", msgs)
+	}
+	if len(mockLogger.Warnings) != 2 || len(mockLogger.Interactions) != 1 || mockLogger.Interactions[0].Prompt != "p" {
+		t.Errorf("unexpected logging: %+v", mockLogger)
+	}
+}
+
+func TestService_Generate_StructuredOutputGivesUp(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: "still not json", PromptEvalCount: 10, EvalCount: 5}
+	mockLogger := &mocks.MockLogger{}
+	g := &service{ollama: mockOllama, logger: mockLogger, structuredRetries: 1}
+	_, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "p", Format: json.RawMessage(`"json"`)})
+	if !errors.Is(err, domain.ErrInvalidStructuredOutput) {
+		t.Fatalf("expected ErrInvalidStructuredOutput, got %v", err)
+	}
+	if mockOllama.Calls != 2 || len(mockLogger.Interactions) != 0 {
+		t.Errorf("expected 2 calls and no interaction, got %d calls", mockOllama.Calls)
+	}
+	var usageErr *domain.UsageError
+	if !errors.As(err, &usageErr) || usageErr.Usage.TotalTokens != 30 || usageErr.Usage.ModelCalls != 2 {
+		t.Errorf("expected the tokens of both attempts with the error, got %v", err)
+	}
+}
+
+func toolCall(name string, args map[string]any) domain.ToolCall {
+	return domain.ToolCall{Function: domain.ToolCallFunction{Name: name, Arguments: args}}
+}
+
+func TestService_Generate_ServerTools(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Replies: []domain.OllamaChatMessage{
+		{Role: "assistant", ToolCalls: []domain.ToolCall{toolCall("calculator", map[string]any{"expression": "6*7"})}},
+		{Role: "assistant", Content: "It is 42."},
+	}}
+	mockLogger := &mocks.MockLogger{}
+	toolbox := &mocks.MockToolbox{Results: map[string]string{"calculator": "42"}}
+	g := &service{ollama: mockOllama, logger: mockLogger, tools: toolbox, maxToolRounds: 3}
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "6 times 7?", ServerTools: []string{"calculator"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.Response != "It is 42." || len(resp.ToolCalls) != 0 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if len(resp.ToolsUsed) != 1 || resp.ToolsUsed[0].Result != "42" {
+		t.Errorf("unexpected tools_used: %+v", resp.ToolsUsed)
+	}
+	msgs := mockOllama.LastRequest.Messages
+	if len(msgs) != 3 || msgs[2].Role != "tool" || msgs[2].Content != "42" || msgs[2].ToolName != "calculator" {
+		t.Errorf("tool result not fed back: %+v", msgs)
+	}
+	if len(mockOllama.LastRequest.Tools) != 1 || mockOllama.LastRequest.Tools[0].Function.Name != "calculator" {
+		t.Errorf("tool definitions not sent: %+v", mockOllama.LastRequest.Tools)
+	}
+	if len(mockLogger.Interactions) != 1 {
+		t.Error("interaction not logged")
+	}
+}
+
+func TestService_Generate_ToolErrorIsFedBack(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Replies: []domain.OllamaChatMessage{
+		{Role: "assistant", ToolCalls: []domain.ToolCall{toolCall("clock", nil)}},
+		{Role: "assistant", Content: "I could not tell the time."},
+	}}
+	toolbox := &mocks.MockToolbox{Results: map[string]string{"clock": ""}, Error: errors.New("boom")}
+	g := &service{ollama: mockOllama, logger: &mocks.MockLogger{}, tools: toolbox, maxToolRounds: 3}
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "time?", ServerTools: []string{"clock"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.ToolsUsed[0].Error != "boom" || mockOllama.LastRequest.Messages[2].Content != "error: boom" {
+		t.Errorf("tool error not reported: %+v", resp.ToolsUsed)
+	}
+}
+
+func TestService_Generate_ToolRoundsExceeded(t *testing.T) {
+	loop := domain.OllamaChatMessage{Role: "assistant", ToolCalls: []domain.ToolCall{toolCall("clock", nil)}}
+	mockOllama := &mocks.MockOllama{Replies: []domain.OllamaChatMessage{loop, loop, loop}}
+	toolbox := &mocks.MockToolbox{Results: map[string]string{"clock": "now"}}
+	g := &service{ollama: mockOllama, logger: &mocks.MockLogger{}, tools: toolbox, maxToolRounds: 2}
+	_, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "time?", ServerTools: []string{"clock"}})
+	if !errors.Is(err, domain.ErrToolRoundsExceeded) || len(toolbox.Calls) != 2 {
+		t.Errorf("expected ErrToolRoundsExceeded after 2 rounds, got %v after %d calls", err, len(toolbox.Calls))
+	}
+}
+
+func TestService_Generate_ClientToolCallsReturned(t *testing.T) {
+	call := toolCall("lookup_order", map[string]any{"id": "A1"})
+	mockOllama := &mocks.MockOllama{Replies: []domain.OllamaChatMessage{{Role: "assistant", ToolCalls: []domain.ToolCall{call}}}}
+	g := &service{ollama: mockOllama, logger: &mocks.MockLogger{}}
+	tool := domain.Tool{Type: domain.ToolTypeFunction, Function: domain.ToolFunction{Name: "lookup_order"}}
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "where is A1?", Tools: []domain.Tool{tool}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Function.Name != "lookup_order" || mockOllama.Calls != 1 {
+		t.Errorf("client tool call not returned: %+v", resp)
+	}
+}
+
+func TestService_Generate_UnknownServerTool(t *testing.T) {
+	g := &service{ollama: &mocks.MockOllama{}, logger: &mocks.MockLogger{}}
+	_, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "p", ServerTools: []string{"calculator"}})
+	if !errors.Is(err, domain.ErrUnknownTool) {
+		t.Errorf("expected ErrUnknownTool, got %v", err)
+	}
+}
+
+func TestService_Generate_ContinuesConversation(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: "Your order ships today."}
+	mockLogger := &mocks.MockLogger{}
+	g := &service{ollama: mockOllama, logger: mockLogger}
+	req := domain.GenerateRequest{Messages: []domain.OllamaChatMessage{
+		{Role: "user", Content: "where is A1?"},
+		{Role: "assistant", ToolCalls: []domain.ToolCall{toolCall("lookup_order", nil)}},
+		{Role: "tool", Content: `{"status":"packed"}`, ToolName: "lookup_order"},
+	}}
+	if _, err := g.Generate(context.Background(), req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(mockOllama.LastRequest.Messages) != 3 || mockLogger.Interactions[0].Prompt != `{"status":"packed"}` {
+		t.Errorf("conversation not passed through: %+v", mockOllama.LastRequest.Messages)
+	}
+}
+
+func TestService_Generate_InputPolicyBlocks(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: "ok"}
+	mockLogger := &mocks.MockLogger{}
+	guard := &mocks.MockGuardrail{Results: []domain.PolicyResult{
+		{Policy: "injection", Action: domain.PolicyActionBlock, Reason: "matched prompt-injection heuristic jailbreak"},
+		{Policy: "max_length", Action: domain.PolicyActionWarn, Reason: "too long"},
+	}}
+	g := &service{ollama: mockOllama, logger: mockLogger, guard: guard}
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "enable developer mode"})
+	var policyErr *domain.PolicyError
+	if resp != nil || !errors.As(err, &policyErr) || !errors.Is(err, domain.ErrInputBlocked) {
+		t.Fatalf("expected a policy error, got %v %v", resp, err)
+	}
+	if len(policyErr.Results) != 2 {
+		t.Errorf("expected both results on the error, got %+v", policyErr.Results)
+	}
+	if mockOllama.Calls != 0 {
+		t.Error("blocked input must not reach the model")
+	}
+	if len(mockLogger.Interactions) != 1 || len(mockLogger.Interactions[0].Policies) != 2 {
+		t.Errorf("blocked attempt should be logged with its policies: %+v", mockLogger.Interactions)
+	}
+	if len(mockLogger.Warnings) != 1 || !strings.Contains(mockLogger.Warnings[0], "max_length") {
+		t.Errorf("expected a warning for the warn policy, got %v", mockLogger.Warnings)
+	}
+}
+
+func TestService_Generate_InputPolicyTags(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: "ok"}
+	mockLogger := &mocks.MockLogger{}
+	guard := &mocks.MockGuardrail{Results: []domain.PolicyResult{{Policy: "language", Action: domain.PolicyActionTag, Reason: "detected language fr"}}}
+	g := &service{ollama: mockOllama, logger: mockLogger, guard: guard}
+	req := domain.GenerateRequest{Messages: []domain.OllamaChatMessage{
+		{Role: "system", Content: "be brief"},
+		{Role: "assistant", Content: "earlier answer"},
+		{Role: "user", Content: "bonjour"},
+	}}
+	if _, err := g.Generate(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if len(guard.Checked) != 1 || guard.Checked[0] != "be brief\n\nbonjour" {
+		t.Errorf("policies should see every non-assistant message, got %q", guard.Checked)
+	}
+	if len(mockLogger.Interactions) != 1 || len(mockLogger.Interactions[0].Policies) != 1 || len(mockLogger.Warnings) != 0 {
+		t.Errorf("tag results should be recorded without a warning: %+v %v", mockLogger.Interactions, mockLogger.Warnings)
+	}
+}
+
+func TestService_Generate_Tenant(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: "ok"}
+	mockLogger := &mocks.MockLogger{}
+	guard := &mocks.MockGuardrail{}
+	tenants := &mocks.MockTenants{Tenants: []domain.Tenant{{ID: "red", Models: []string{"gemma:2b", "llama3:8b"}, SystemPrompt: "You work for red."}}}
+	g := &service{ollama: mockOllama, logger: mockLogger, guard: guard, tenants: tenants}
+	ctx := domain.WithCaller(context.Background(), domain.Caller{KeyID: "alice", Tenant: "red"})
+
+	if _, err := g.Generate(ctx, domain.GenerateRequest{Prompt: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+	req := mockOllama.LastRequest
+	if req.Model != "" || len(req.Messages) != 2 || req.Messages[0].Role != "system" || req.Messages[0].Content != "You work for red." {
+		t.Errorf("expected the fallback chain to pick the model and the tenant's system prompt, got %+v", req)
+	}
+	if guard.Checked[0] != "hi" {
+		t.Errorf("the tenant's system prompt should not be checked by input policies, got %q", guard.Checked)
+	}
+	if mockLogger.Interactions[0].Tenant != "red" {
+		t.Errorf("expected the interaction to be tagged with the tenant, got %+v", mockLogger.Interactions[0])
+	}
+
+	if _, err := g.Generate(ctx, domain.GenerateRequest{Prompt: "hi", Model: "llama3:8b"}); err != nil || mockOllama.LastRequest.Model != "llama3:8b" {
+		t.Errorf("an allowed model should be used, got %v / %q", err, mockOllama.LastRequest.Model)
+	}
+	calls := mockOllama.Calls
+	if _, err := g.Generate(ctx, domain.GenerateRequest{Prompt: "hi", Model: "mistral:7b"}); !errors.Is(err, domain.ErrModelNotAllowed) || mockOllama.Calls != calls {
+		t.Errorf("expected ErrModelNotAllowed without a model call, got %v", err)
+	}
+}
+
+func TestService_Generate_TenantFallback(t *testing.T) {
+	primary := &mocks.MockOllama{Response: "from llama3"}
+	backup := &mocks.MockOllama{Response: "from gemma"}
+	chain, err := NewFallbackChain(domain.FallbackStep{Model: "llama3:8b", Ollama: primary},
+		[]domain.FallbackStep{{Model: "gemma:2b", Ollama: backup}}, defaultPolicy, &mocks.MockLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tenants := &mocks.MockTenants{Tenants: []domain.Tenant{{ID: "red", Models: []string{"gemma:2b"}}, {ID: "blue", Models: []string{"phi3:mini"}}}}
+	g := &service{ollama: chain, logger: &mocks.MockLogger{}, tenants: tenants}
+
+	red := domain.WithCaller(context.Background(), domain.Caller{KeyID: "alice", Tenant: "red"})
+	resp, err := g.Generate(red, domain.GenerateRequest{Prompt: "hi"})
+	if err != nil || resp.Model != "gemma:2b" || primary.Calls != 0 {
+		t.Fatalf("expected the tenant's allowed fallback step to answer, got %+v %v, %d primary call(s)", resp, err, primary.Calls)
+	}
+
+	blue := domain.WithCaller(context.Background(), domain.Caller{KeyID: "bob", Tenant: "blue"})
+	if resp, err := g.Generate(blue, domain.GenerateRequest{Prompt: "hi"}); err != nil || primary.LastRequest.Model != "phi3:mini" || resp.Model != "phi3:mini" {
+		t.Errorf("expected the tenant's first model on the primary backend when no step is allowed, got %v %q", err, primary.LastRequest.Model)
+	}
+
+	if resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "hi"}); err != nil || resp.Model != "llama3:8b" {
+		t.Errorf("callers outside a tenant should keep the whole chain, got %+v %v", resp, err)
+	}
+}
+
+func TestService_Generate_OutputFilters(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: `{"token": "hunter2"}`}
+	mockLogger := &mocks.MockLogger{}
+	results := []domain.FilterResult{{Filter: "secrets", Action: domain.FilterActionRedacted, Detail: "TOKEN=1"}}
+	g := &service{ollama: mockOllama, logger: mockLogger, filter: &mocks.MockOutputFilter{Secret: "hunter2", Results: results}}
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "p", Format: json.RawMessage(`"json"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Response != `{"token": "[REDACTED]"}` || string(resp.JSON) != `{"token":"[REDACTED]"}` {
+		t.Errorf("filters should apply before the reply is parsed: %q %s", resp.Response, resp.JSON)
+	}
+	if len(resp.Filters) != 1 || len(mockLogger.Interactions) != 1 || mockLogger.Interactions[0].Response != resp.Response || len(mockLogger.Interactions[0].Filters) != 1 {
+		t.Errorf("filter results should be returned and logged: %+v %+v", resp.Filters, mockLogger.Interactions)
+	}
+}
+
+func TestService_Generate_OutputBlocked(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: "classified", Model: "gemma:2b"}
+	mockLogger := &mocks.MockLogger{}
+	blocked := &domain.FilterError{Results: []domain.FilterResult{{Filter: "deny", Action: domain.FilterActionBlocked, Detail: "deny list line 1"}}}
+	g := &service{ollama: mockOllama, logger: mockLogger, filter: &mocks.MockOutputFilter{Error: blocked}}
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "p"})
+	if resp != nil || !errors.Is(err, domain.ErrOutputBlocked) {
+		t.Fatalf("expected the response to be blocked, got %v %v", resp, err)
+	}
+	if len(mockLogger.Interactions) != 1 || mockLogger.Interactions[0].Response != "" || len(mockLogger.Interactions[0].Filters) != 1 {
+		t.Errorf("blocked response should be logged without its text: %+v", mockLogger.Interactions)
+	}
+}
+
+func TestService_Generate_Streams(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: "one two three"}
+	results := []domain.FilterResult{{Filter: "secrets", Action: domain.FilterActionRedacted}}
+	g := &service{ollama: mockOllama, logger: &mocks.MockLogger{}, filter: &mocks.MockOutputFilter{Results: results}}
+	var tokens []string
+	ctx := domain.WithTokenSink(context.Background(), func(token string) { tokens = append(tokens, token) })
+	resp, err := g.Generate(ctx, domain.GenerateRequest{Prompt: "count"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 3 || strings.Join(tokens, "") != "one two three" || resp.Response != "one two three" || len(resp.Filters) != 1 {
+		t.Errorf("expected the reply streamed through the filters, got %q %+v", tokens, resp)
+	}
+
+	tokens = nil
+	mockOllama.Response = `{"n": 3}`
+	if _, err := g.Generate(ctx, domain.GenerateRequest{Prompt: "count", Format: json.RawMessage(`"json"`)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(tokens) != 0 {
+		t.Errorf("structured output should not be streamed, got %q", tokens)
+	}
+
+	// a stream cut short still used the tokens it sent
+	mockOllama.Response, mockOllama.Error = "", context.Canceled
+	g.ollama = streamThenFail{mockOllama, []string{"one ", "two "}}
+	_, err = g.Generate(ctx, domain.GenerateRequest{Prompt: "count"})
+	var usageErr *domain.UsageError
+	if !errors.As(err, &usageErr) || usageErr.Usage.CompletionTokens != 2 {
+		t.Errorf("expected the streamed chunks counted with the error, got %v", err)
+	}
+}
+
+// streamThenFail streams chunks and then fails the call like next does.
+type streamThenFail struct {
+	next   domain.OllamaPort
+	chunks []string
+}
+
+func (s streamThenFail) CallOllama(ctx context.Context, req domain.OllamaChatRequest) (*domain.OllamaChatResponse, error) {
+	for _, c := range s.chunks {
+		domain.TokenSinkFromContext(ctx)(c)
+	}
+	return s.next.CallOllama(ctx, req)
+}
+
+func TestService_Generate_UsageSumsModelCalls(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Replies: []domain.OllamaChatMessage{
+		{Role: "assistant", ToolCalls: []domain.ToolCall{toolCall("calculator", map[string]any{"expression": "6*7"})}},
+		{Role: "assistant", Content: "It is 42."},
+	}, PromptEvalCount: 30, EvalCount: 20, EvalDuration: 500_000_000}
+	mockLogger := &mocks.MockLogger{}
+	toolbox := &mocks.MockToolbox{Results: map[string]string{"calculator": "42"}}
+	g := &service{ollama: mockOllama, logger: mockLogger, tools: toolbox, maxToolRounds: 3}
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "6 times 7?", ServerTools: []string{"calculator"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	u := resp.Usage
+	if u == nil || u.ModelCalls != 2 || u.PromptTokens != 60 || u.CompletionTokens != 40 || u.TotalTokens != 100 || u.EvalMS != 1000 || u.TokensPerSecond != 40 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+	if len(mockLogger.Interactions) != 1 || mockLogger.Interactions[0].Usage != u {
+		t.Error("usage should be logged with the interaction")
+	}
+}
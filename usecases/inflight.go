@@ -0,0 +1,116 @@
+package usecases
+
+import (
+	"context"
+	"minivault/domain"
+	"slices"
+	"sync"
+	"time"
+)
+
+// inFlight implements domain.InFlightPort with a registry of running generations,
+// each with the cancel function of its context.
+type inFlight struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	requests map[string]*trackedRequest
+	idle     chan struct{} // closed while nothing is in flight
+}
+
+type trackedRequest struct {
+	info   domain.InFlightRequest
+	cancel context.CancelCauseFunc
+}
+
+// NewInFlightTracker constructs an empty tracker.
+func NewInFlightTracker() domain.InFlightPort {
+	idle := make(chan struct{})
+	close(idle)
+	return &inFlight{now: time.Now, requests: make(map[string]*trackedRequest), idle: idle}
+}
+
+// Start implements InFlightPort. A cancelled generation's context reports
+// domain.ErrGenerationCancelled as its cause.
+func (t *inFlight) Start(ctx context.Context, req domain.GenerateRequest, async bool) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	caller, _ := domain.CallerFromContext(ctx)
+	reqID := domain.RequestIDFromContext(ctx)
+	tracked := &trackedRequest{
+		info: domain.InFlightRequest{
+			RequestID: reqID,
+			APIKeyID:  caller.KeyID,
+			Tenant:    caller.Tenant,
+			Model:     req.Model,
+			Async:     async,
+			StartedAt: t.now().UTC(),
+		},
+		cancel: cancel,
+	}
+	t.mu.Lock()
+	if len(t.requests) == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.requests[reqID] = tracked
+	t.mu.Unlock()
+	return ctx, func() {
+		t.mu.Lock()
+		if t.requests[reqID] == tracked {
+			delete(t.requests, reqID)
+			if len(t.requests) == 0 {
+				close(t.idle)
+			}
+		}
+		t.mu.Unlock()
+		cancel(nil)
+	}
+}
+
+// List implements InFlightPort
+func (t *inFlight) List() []domain.InFlightRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	list := make([]domain.InFlightRequest, 0, len(t.requests))
+	for _, r := range t.requests {
+		info := r.info
+		info.AgeMS = now.Sub(info.StartedAt).Milliseconds()
+		list = append(list, info)
+	}
+	slices.SortFunc(list, func(a, b domain.InFlightRequest) int { return a.StartedAt.Compare(b.StartedAt) })
+	return list
+}
+
+// Cancel implements InFlightPort
+func (t *inFlight) Cancel(reqID string) bool {
+	t.mu.Lock()
+	r, ok := t.requests[reqID]
+	t.mu.Unlock()
+	if ok {
+		r.cancel(domain.ErrGenerationCancelled)
+	}
+	return ok
+}
+
+// CancelAll implements InFlightPort
+func (t *inFlight) CancelAll(cause error) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, r := range t.requests {
+		r.cancel(cause)
+	}
+	return len(t.requests)
+}
+
+// Wait implements InFlightPort
+func (t *inFlight) Wait(ctx context.Context) error {
+	t.mu.Lock()
+	idle := t.idle
+	t.mu.Unlock()
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,49 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"minivault/domain"
+	"testing"
+	"time"
+)
+
+func TestInFlightTracker(t *testing.T) {
+	tracker := NewInFlightTracker().(*inFlight)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	ctx := domain.WithCaller(domain.WithRequestID(context.Background(), "r1"), domain.Caller{KeyID: "alice", Tenant: "red"})
+	genCtx, done := tracker.Start(ctx, domain.GenerateRequest{Model: "gemma:2b"}, false)
+	now = now.Add(time.Second)
+	_, done2 := tracker.Start(domain.WithRequestID(context.Background(), "r2"), domain.GenerateRequest{}, true)
+	now = now.Add(time.Second)
+
+	list := tracker.List()
+	if len(list) != 2 || list[0].RequestID != "r1" || list[0].AgeMS != 2000 || list[0].Tenant != "red" || list[0].Model != "gemma:2b" || !list[1].Async {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+
+	if !tracker.Cancel("r1") || !errors.Is(context.Cause(genCtx), domain.ErrGenerationCancelled) {
+		t.Errorf("expected r1 to be cancelled, cause %v", context.Cause(genCtx))
+	}
+	if tracker.Cancel("missing") {
+		t.Error("cancelling an unknown request should report false")
+	}
+	done()
+	waitCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := tracker.Wait(waitCtx); err == nil {
+		t.Error("Wait should block while r2 is in flight")
+	}
+	if n := tracker.CancelAll(domain.ErrServerDraining); n != 1 {
+		t.Errorf("expected CancelAll to stop r2 only, got %d", n)
+	}
+	done2()
+	if err := tracker.Wait(context.Background()); err != nil {
+		t.Errorf("Wait should return once idle, got %v", err)
+	}
+	if list := tracker.List(); len(list) != 0 {
+		t.Errorf("finished generations should be removed, got %+v", list)
+	}
+}
@@ -0,0 +1,231 @@
+package usecases
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"minivault/domain"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateJSONSchema validates a decoded JSON value (as produced by encoding/json
+// into an any) against a JSON Schema and returns one message per violation.
+//
+// The supported subset covers what model output contracts typically need: type,
+// enum, const, properties, required, additionalProperties, items, minItems,
+// maxItems, minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, allOf, anyOf, oneOf and not. Unknown keywords are ignored; a
+// malformed schema is an error, see domain.CheckJSONSchema.
+func ValidateJSONSchema(schema json.RawMessage, value any) ([]string, error) {
+	if err := domain.CheckJSONSchema(schema); err != nil {
+		return nil, err
+	}
+	var s any
+	json.Unmarshal(schema, &s) // checked above
+	var errs []string
+	validateSchema(s, value, "$", &errs)
+	return errs, nil
+}
+
+// validateSchema appends the violations of value against schema to errs. The
+// schema must have passed domain.CheckJSONSchema.
+func validateSchema(schema any, value any, path string, errs *[]string) {
+	switch s := schema.(type) {
+	case bool:
+		if !s {
+			*errs = append(*errs, path+": no value is allowed here")
+		}
+	case map[string]any:
+		validateObjectSchema(s, value, path, errs)
+	}
+}
+
+func validateObjectSchema(s map[string]any, value any, path string, errs *[]string) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, value) {
+		fail("expected %s, got %s", describeType(t), jsonType(value))
+		return // further keywords would only add noise
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if reflect.DeepEqual(e, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value is not one of the allowed values")
+		}
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		fail("value must be %v", c)
+	}
+
+	switch v := value.(type) {
+	case string:
+		n := float64(utf8.RuneCountInString(v))
+		if lo, ok := number(s["minLength"]); ok && n < lo {
+			fail("string shorter than %v characters", lo)
+		}
+		if hi, ok := number(s["maxLength"]); ok && n > hi {
+			fail("string longer than %v characters", hi)
+		}
+		if p, ok := s["pattern"].(string); ok && !regexp.MustCompile(p).MatchString(v) {
+			fail("string does not match pattern %q", p)
+		}
+	case float64:
+		if lo, ok := number(s["minimum"]); ok && v < lo {
+			fail("%v is less than minimum %v", v, lo)
+		}
+		if hi, ok := number(s["maximum"]); ok && v > hi {
+			fail("%v is greater than maximum %v", v, hi)
+		}
+		if lo, ok := number(s["exclusiveMinimum"]); ok && v <= lo {
+			fail("%v must be greater than %v", v, lo)
+		}
+		if hi, ok := number(s["exclusiveMaximum"]); ok && v >= hi {
+			fail("%v must be less than %v", v, hi)
+		}
+	case []any:
+		n := float64(len(v))
+		if lo, ok := number(s["minItems"]); ok && n < lo {
+			fail("array has fewer than %v items", lo)
+		}
+		if hi, ok := number(s["maxItems"]); ok && n > hi {
+			fail("array has more than %v items", hi)
+		}
+		if items, ok := s["items"]; ok {
+			for i, item := range v {
+				validateSchema(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	case map[string]any:
+		if required, ok := s["required"].([]any); ok {
+			for _, r := range required {
+				if name, ok := r.(string); ok {
+					if _, present := v[name]; !present {
+						fail("missing required property %q", name)
+					}
+				}
+			}
+		}
+		props, _ := s["properties"].(map[string]any)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := path + "." + k
+			if ps, ok := props[k]; ok {
+				validateSchema(ps, v[k], childPath, errs)
+				continue
+			}
+			if ap, ok := s["additionalProperties"]; ok {
+				validateSchema(ap, v[k], childPath, errs)
+			}
+		}
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			validateSchema(sub, value, path, errs)
+		}
+	}
+	for _, kw := range []string{"anyOf", "oneOf"} {
+		subs, ok := s[kw].([]any)
+		if !ok {
+			continue
+		}
+		matched := 0
+		for _, sub := range subs {
+			var subErrs []string
+			validateSchema(sub, value, path, &subErrs)
+			if len(subErrs) == 0 {
+				matched++
+			}
+		}
+		if matched == 0 {
+			fail("value does not match any %s alternative", kw)
+		} else if kw == "oneOf" && matched > 1 {
+			fail("value matches %d oneOf alternatives, expected exactly one", matched)
+		}
+	}
+	if not, ok := s["not"]; ok {
+		var subErrs []string
+		validateSchema(not, value, path, &subErrs)
+		if len(subErrs) == 0 {
+			fail("value must not match the \"not\" schema")
+		}
+	}
+}
+
+func matchesType(t any, value any) bool {
+	switch tt := t.(type) {
+	case string:
+		return typeIs(tt, value)
+	case []any:
+		for _, x := range tt {
+			if name, ok := x.(string); ok && typeIs(name, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func typeIs(name string, value any) bool {
+	switch name {
+	case "integer":
+		f, ok := value.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	default:
+		return jsonType(value) == name
+	}
+}
+
+func jsonType(value any) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
+
+func describeType(t any) string {
+	if list, ok := t.([]any); ok {
+		names := make([]string, 0, len(list))
+		for _, x := range list {
+			names = append(names, fmt.Sprint(x))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(t)
+}
+
+func number(v any) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
@@ -0,0 +1,81 @@
+package usecases
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func validate(t *testing.T, schema, doc string) []string {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatal(err)
+	}
+	errs, err := ValidateJSONSchema(json.RawMessage(schema), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return errs
+}
+
+func TestValidateJSONSchema_Object(t *testing.T) {
+	schema := `{
+		"type": "object",
+		"required": ["name", "age"],
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"age": {"type": "integer", "minimum": 0},
+			"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+			"kind": {"enum": ["cat", "dog"]}
+		},
+		"additionalProperties": false
+	}`
+	if errs := validate(t, schema, `{"name":"Rex","age":3,"tags":["a"],"kind":"dog"}`); len(errs) != 0 {
+		t.Errorf("expected valid document, got %v", errs)
+	}
+
+	errs := validate(t, schema, `{"name":"","age":2.5,"tags":["a",1,"c"],"kind":"cow","extra":true}`)
+	want := []string{
+		"$.name: string shorter than 1",
+		"$.age: expected integer",
+		"$.tags: array has more than 2",
+		"$.tags[1]: expected string",
+		"$.kind: value is not one of",
+		"$.extra: no value is allowed",
+	}
+	joined := strings.Join(errs, "\n")
+	for _, w := range want {
+		if !strings.Contains(joined, w) {
+			t.Errorf("missing error %q in:\n%s", w, joined)
+		}
+	}
+	if errs := validate(t, schema, `{"name":"x"}`); len(errs) != 1 || !strings.Contains(errs[0], `missing required property "age"`) {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
+
+func TestValidateJSONSchema_Combinators(t *testing.T) {
+	schema := `{"oneOf": [{"type": "string", "pattern": "^[a-z]+$"}, {"type": "number", "exclusiveMaximum": 10}]}`
+	if errs := validate(t, schema, `"abc"`); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if errs := validate(t, schema, `10`); len(errs) != 1 {
+		t.Errorf("expected oneOf failure, got %v", errs)
+	}
+	if errs := validate(t, `{"not": {"type": "null"}}`, `null`); len(errs) != 1 {
+		t.Errorf("expected not failure, got %v", errs)
+	}
+	if errs := validate(t, `{"type": ["string", "null"]}`, `null`); len(errs) != 0 {
+		t.Errorf("type list should accept null, got %v", errs)
+	}
+}
+
+func TestValidateJSONSchema_InvalidSchema(t *testing.T) {
+	if _, err := ValidateJSONSchema(json.RawMessage(`{"type":`), "x"); err == nil {
+		t.Error("expected error for malformed schema")
+	}
+	if _, err := ValidateJSONSchema(json.RawMessage(`{"pattern": "("}`), "x"); err == nil {
+		t.Error("expected error for invalid pattern")
+	}
+}
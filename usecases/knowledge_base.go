@@ -0,0 +1,156 @@
+package usecases
+
+import (
+	"context"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// knowledgeBase chunks and embeds documents into a VectorStorePort and retrieves
+// the chunks most similar to a query. Chunks and queries are embedded with the
+// same configured model so their vectors are comparable. A tenant's callers use
+// the tenant's own store, so collections of the same name never mix across tenants.
+type knowledgeBase struct {
+	embeddings   domain.EmbeddingPort
+	store        domain.VectorStorePort
+	tenantStores map[string]domain.VectorStorePort // tenant ID -> that tenant's store
+	logger       domain.LoggerPort
+
+	model     string
+	batchSize int
+	chunkSize int
+	overlap   int
+	topK      int
+}
+
+// NewKnowledgeBase constructs the default KnowledgeBasePort. store serves callers
+// outside any tenant and tenantStores each tenant's callers.
+func NewKnowledgeBase(embeddings domain.EmbeddingPort, store domain.VectorStorePort, tenantStores map[string]domain.VectorStorePort, logger domain.LoggerPort, cfg *config.Config) domain.KnowledgeBasePort {
+	return &knowledgeBase{
+		embeddings:   embeddings,
+		store:        store,
+		tenantStores: tenantStores,
+		logger:       logger,
+		model:        cfg.EmbedModel,
+		batchSize:    cfg.EmbedBatchSize,
+		chunkSize:    cfg.RAGChunkSize,
+		overlap:      cfg.RAGChunkOverlap,
+		topK:         cfg.RAGTopK,
+	}
+}
+
+// storeFor returns the vector store of the caller's tenant, or the shared store
+// for callers outside any tenant.
+func (kb *knowledgeBase) storeFor(ctx context.Context) (domain.VectorStorePort, error) {
+	caller, _ := domain.CallerFromContext(ctx)
+	if caller.Tenant == "" {
+		return kb.store, nil
+	}
+	store, ok := kb.tenantStores[caller.Tenant]
+	if !ok {
+		return nil, fmt.Errorf("no document store for tenant %q", caller.Tenant)
+	}
+	return store, nil
+}
+
+// Ingest implements KnowledgeBasePort
+func (kb *knowledgeBase) Ingest(ctx context.Context, req domain.DocumentRequest) (*domain.Document, error) {
+	store, err := kb.storeFor(ctx)
+	if err != nil {
+		kb.logger.LogError("document ingestion failed", err)
+		return nil, err
+	}
+	if req.ID == "" {
+		req.ID = uuid.New().String()
+	}
+	spans := ChunkText(req.Content, kb.chunkSize, kb.overlap, req.ContentType == domain.ContentTypeMarkdown)
+	texts := make([]string, len(spans))
+	for i, span := range spans {
+		texts[i] = req.Content[span.Start:span.End]
+	}
+	resp, err := embedBatches(ctx, kb.embeddings, kb.model, texts, kb.batchSize)
+	if err != nil {
+		kb.logger.LogError("document ingestion failed", err)
+		return nil, err
+	}
+
+	chunks := make([]domain.Chunk, len(spans))
+	for i, span := range spans {
+		chunks[i] = domain.Chunk{
+			DocumentID: req.ID,
+			Title:      req.Title,
+			Index:      i,
+			Start:      span.Start,
+			End:        span.End,
+			Text:       texts[i],
+			Embedding:  resp.Embeddings[i],
+		}
+	}
+	if err := store.Upsert(req.Collection, req.ID, chunks); err != nil {
+		err = fmt.Errorf("failed to store document: %w", err)
+		kb.logger.LogError("document ingestion failed", err)
+		return nil, err
+	}
+	kb.logger.LogInfo(fmt.Sprintf("ingested document %s into %s: %d chunks [reqID: %s]",
+		req.ID, req.Collection, len(chunks), domain.RequestIDFromContext(ctx)))
+	return &domain.Document{
+		ID:         req.ID,
+		Collection: req.Collection,
+		Title:      req.Title,
+		Chunks:     len(chunks),
+		Model:      resp.Model,
+		IngestedAt: time.Now().UTC(),
+	}, nil
+}
+
+// Retrieve implements KnowledgeBasePort
+func (kb *knowledgeBase) Retrieve(ctx context.Context, opts domain.RetrievalOptions, query string) ([]domain.ScoredChunk, error) {
+	store, err := kb.storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	k := opts.TopK
+	if k == 0 {
+		k = kb.topK
+	}
+	resp, err := embedBatches(ctx, kb.embeddings, kb.model, []string{query}, 1)
+	if err != nil {
+		return nil, err
+	}
+	return store.Search(opts.Collection, resp.Embeddings[0], k)
+}
+
+// retrievalPrompt is the system message that hands retrieved chunks to the model.
+func retrievalPrompt(chunks []domain.ScoredChunk) string {
+	var b strings.Builder
+	b.WriteString("Answer using the numbered excerpts below. Cite the excerpts you rely on as [1], [2], ... " +
+		"If they do not contain the answer, say so instead of guessing.\n")
+	for i, c := range chunks {
+		fmt.Fprintf(&b, "\n[%d]", i+1)
+		if c.Title != "" {
+			fmt.Fprintf(&b, " %s", c.Title)
+		}
+		fmt.Fprintf(&b, "\n%s\n", c.Text)
+	}
+	return b.String()
+}
+
+func citations(chunks []domain.ScoredChunk) []domain.Citation {
+	out := make([]domain.Citation, len(chunks))
+	for i, c := range chunks {
+		out[i] = domain.Citation{
+			DocumentID: c.DocumentID,
+			Title:      c.Title,
+			Chunk:      c.Index,
+			Start:      c.Start,
+			End:        c.End,
+			Score:      c.Score,
+		}
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"minivault/domain"
+	"minivault/mocks"
+	"strings"
+	"testing"
+)
+
+func TestKnowledgeBase_Ingest(t *testing.T) {
+	port := &mocks.MockEmbedding{Dimensions: 4, Model: "nomic-embed-text"}
+	store := &mocks.MockVectorStore{}
+	kb := &knowledgeBase{embeddings: port, store: store, logger: &mocks.MockLogger{}, chunkSize: 40, overlap: 10, batchSize: 2}
+	content := strings.Repeat("Alpha beta gamma delta. ", 10)
+	doc, err := kb.Ingest(context.Background(), domain.DocumentRequest{Collection: "docs", Title: "Greek", Content: content})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chunks := store.Upserted["docs"]
+	if doc.ID == "" || doc.Chunks != len(chunks) || doc.Chunks < 2 || doc.Model != "nomic-embed-text" {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	for i, c := range chunks {
+		if c.DocumentID != doc.ID || c.Index != i || c.Text != content[c.Start:c.End] || len(c.Embedding) != 4 || c.Title != "Greek" {
+			t.Errorf("unexpected chunk %d: %+v", i, c)
+		}
+	}
+	if len(port.Requests[0].Input) != 2 {
+		t.Error("chunks should be embedded in batches")
+	}
+}
+
+func TestKnowledgeBase_RetrieveDefaultsTopK(t *testing.T) {
+	store := &mocks.MockVectorStore{Hits: []domain.ScoredChunk{{Score: 0.9}}}
+	kb := &knowledgeBase{embeddings: &mocks.MockEmbedding{Dimensions: 4}, store: store, logger: &mocks.MockLogger{}, topK: 3}
+	hits, err := kb.Retrieve(context.Background(), domain.RetrievalOptions{Collection: "docs"}, "question")
+	if err != nil || len(hits) != 1 || store.LastK != 3 {
+		t.Errorf("unexpected retrieval: %+v %v (k=%d)", hits, err, store.LastK)
+	}
+}
+
+func TestKnowledgeBase_TenantsDoNotShareCollections(t *testing.T) {
+	shared, red, blue := &mocks.MockVectorStore{}, &mocks.MockVectorStore{}, &mocks.MockVectorStore{Error: domain.ErrCollectionNotFound}
+	kb := &knowledgeBase{embeddings: &mocks.MockEmbedding{Dimensions: 4}, store: shared, logger: &mocks.MockLogger{}, chunkSize: 40,
+		tenantStores: map[string]domain.VectorStorePort{"red": red, "blue": blue}}
+	redCtx := domain.WithCaller(context.Background(), domain.Caller{KeyID: "alice", Tenant: "red"})
+	blueCtx := domain.WithCaller(context.Background(), domain.Caller{KeyID: "bob", Tenant: "blue"})
+
+	if _, err := kb.Ingest(redCtx, domain.DocumentRequest{Collection: "docs", Content: "red team secrets"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(red.Upserted["docs"]) != 1 || len(shared.Upserted) != 0 {
+		t.Fatalf("expected the document in red's store only, got red %v shared %v", red.Upserted, shared.Upserted)
+	}
+	if _, err := kb.Retrieve(blueCtx, domain.RetrievalOptions{Collection: "docs"}, "secrets"); !errors.Is(err, domain.ErrCollectionNotFound) || red.LastK != 0 {
+		t.Errorf("another tenant must not reach red's collection, got %v", err)
+	}
+	ghost := domain.WithCaller(context.Background(), domain.Caller{KeyID: "carol", Tenant: "green"})
+	if _, err := kb.Retrieve(ghost, domain.RetrievalOptions{Collection: "docs"}, "secrets"); err == nil || shared.LastK != 0 {
+		t.Errorf("a tenant without a store must not fall back to the shared one, got %v", err)
+	}
+}
+
+func TestService_Generate_Retrieval(t *testing.T) {
+	kb := &mocks.MockKnowledgeBase{Chunks: []domain.ScoredChunk{{
+		Chunk: domain.Chunk{DocumentID: "handbook", Title: "Handbook", Index: 2, Start: 10, End: 40, Text: "Vacation is 25 days."},
+		Score: 0.83,
+	}}}
+	mockOllama := &mocks.MockOllama{Response: "25 days [1]"}
+	mockLogger := &mocks.MockLogger{}
+	g := &service{ollama: mockOllama, logger: mockLogger, kb: kb}
+	resp, err := g.Generate(context.Background(), domain.GenerateRequest{
+		Prompt:    "How much vacation do I get?",
+		Retrieval: &domain.RetrievalOptions{Collection: "hr", TopK: 2},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if kb.LastQuery != "How much vacation do I get?" || kb.LastOptions.TopK != 2 {
+		t.Errorf("unexpected retrieval call: %q %+v", kb.LastQuery, kb.LastOptions)
+	}
+	msgs := mockOllama.LastRequest.Messages
+	if len(msgs) != 2 || msgs[0].Role != "system" || !strings.Contains(msgs[0].Content, "[1] Handbook\nVacation is 25 days.") {
+		t.Errorf("retrieved chunks not injected: %+v", msgs)
+	}
+	want := domain.Citation{DocumentID: "handbook", Title: "Handbook", Chunk: 2, Start: 10, End: 40, Score: 0.83}
+	if len(resp.Citations) != 1 || resp.Citations[0] != want {
+		t.Errorf("unexpected citations: %+v", resp.Citations)
+	}
+	if mockLogger.Interactions[0].Prompt != "How much vacation do I get?" {
+		t.Error("the logged prompt should not include retrieved context")
+	}
+}
+
+func TestService_Generate_RetrievalError(t *testing.T) {
+	kb := &mocks.MockKnowledgeBase{Error: domain.ErrCollectionNotFound}
+	g := &service{ollama: &mocks.MockOllama{}, logger: &mocks.MockLogger{}, kb: kb}
+	_, err := g.Generate(context.Background(), domain.GenerateRequest{Prompt: "q", Retrieval: &domain.RetrievalOptions{Collection: "nope"}})
+	if !errors.Is(err, domain.ErrCollectionNotFound) {
+		t.Errorf("expected ErrCollectionNotFound, got %v", err)
+	}
+}
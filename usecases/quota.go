@@ -0,0 +1,52 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"minivault/domain"
+)
+
+// quotaGenerator enforces per-key quotas around another GeneratorPort. Check counts
+// the request before generation and Debit adds the tokens Ollama reported after it;
+// a failed generation still counts as a request, and its tokens are debited when it
+// reports them with a *domain.UsageError.
+type quotaGenerator struct {
+	next   domain.GeneratorPort
+	quotas domain.QuotaPort
+	logger domain.LoggerPort
+}
+
+// NewQuotaGenerator wraps next with quota enforcement. Returns next itself when
+// quotas is nil. Anonymous requests (authentication disabled) are not limited.
+func NewQuotaGenerator(next domain.GeneratorPort, quotas domain.QuotaPort, logger domain.LoggerPort) domain.GeneratorPort {
+	if quotas == nil {
+		return next
+	}
+	return &quotaGenerator{next: next, quotas: quotas, logger: logger}
+}
+
+// Generate implements GeneratorPort
+func (g *quotaGenerator) Generate(ctx context.Context, req domain.GenerateRequest) (*domain.GenerateResponse, error) {
+	caller, ok := domain.CallerFromContext(ctx)
+	if !ok || caller.KeyID == "" {
+		return g.next.Generate(ctx, req)
+	}
+	if err := g.quotas.Check(caller.KeyID); err != nil {
+		g.logger.LogWarn(fmt.Sprintf("request by %q refused: %v [reqID: %s]", caller.KeyID, err, domain.RequestIDFromContext(ctx)))
+		return nil, err
+	}
+	resp, err := g.next.Generate(ctx, req)
+	tokens := 0
+	var usageErr *domain.UsageError
+	switch {
+	case resp != nil && resp.Usage != nil:
+		tokens = resp.Usage.TotalTokens
+	case errors.As(err, &usageErr):
+		tokens = usageErr.Usage.TotalTokens
+	}
+	if debitErr := g.quotas.Debit(caller.KeyID, tokens); debitErr != nil {
+		g.logger.LogError(fmt.Sprintf("failed to record quota usage for %q", caller.KeyID), debitErr)
+	}
+	return resp, err
+}
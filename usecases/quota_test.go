@@ -0,0 +1,55 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"minivault/domain"
+	"minivault/mocks"
+	"testing"
+)
+
+func TestQuotaGenerator_DebitsCaller(t *testing.T) {
+	quotas := &mocks.MockQuota{}
+	next := &mocks.MockGenerator{Response: "ok", Usage: &domain.Usage{TotalTokens: 42}}
+	g := NewQuotaGenerator(next, quotas, &mocks.MockLogger{})
+	ctx := domain.WithCaller(context.Background(), domain.Caller{KeyID: "alice"})
+	if _, err := g.Generate(ctx, domain.GenerateRequest{Prompt: "p"}); err != nil {
+		t.Fatal(err)
+	}
+	next.Error, next.Usage = errors.New("fail"), nil
+	g.Generate(ctx, domain.GenerateRequest{Prompt: "p"})
+	if quotas.Requests["alice"] != 2 || quotas.Debited["alice"] != 42 {
+		t.Errorf("expected 2 requests and 42 tokens debited, got %v %v", quotas.Requests, quotas.Debited)
+	}
+	// a failure that used tokens is charged for them
+	next.Error = &domain.UsageError{Err: domain.ErrInvalidStructuredOutput, Usage: domain.Usage{TotalTokens: 8}}
+	g.Generate(ctx, domain.GenerateRequest{Prompt: "p"})
+	if quotas.Debited["alice"] != 50 {
+		t.Errorf("expected the failed generation's 8 tokens debited, got %v", quotas.Debited)
+	}
+
+	// anonymous requests are not limited
+	g.Generate(context.Background(), domain.GenerateRequest{Prompt: "p"})
+	if len(quotas.Requests) != 1 {
+		t.Errorf("anonymous request was debited: %v", quotas.Requests)
+	}
+}
+
+func TestQuotaGenerator_Refuses(t *testing.T) {
+	quotaErr := &domain.QuotaError{Quota: domain.QuotaTokensPerDay, Limit: 10, Used: 10}
+	quotas := &mocks.MockQuota{CheckError: quotaErr}
+	next := &mocks.MockGenerator{Response: "ok"}
+	logger := &mocks.MockLogger{}
+	g := NewQuotaGenerator(next, quotas, logger)
+	ctx := domain.WithCaller(context.Background(), domain.Caller{KeyID: "alice"})
+	resp, err := g.Generate(ctx, domain.GenerateRequest{Prompt: "p"})
+	if resp != nil || !errors.Is(err, domain.ErrQuotaExceeded) {
+		t.Fatalf("expected a quota error, got %v %v", resp, err)
+	}
+	if next.LastPrompt != "" || len(quotas.Requests) != 0 || len(logger.Warnings) != 1 {
+		t.Error("a refused request must not generate or be debited")
+	}
+	if NewQuotaGenerator(next, nil, logger) != domain.GeneratorPort(next) {
+		t.Error("expected the generator itself without quotas")
+	}
+}
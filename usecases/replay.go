@@ -0,0 +1,174 @@
+package usecases
+
+import (
+	"context"
+	"math"
+	"minivault/domain"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// replayer re-runs logged prompts against another model. It calls OllamaPort
+// directly so replays are not written back into the interaction log.
+type replayer struct {
+	ollama      domain.OllamaPort
+	model       string
+	concurrency int
+}
+
+// NewReplayer constructs a ReplayerPort that sends at most concurrency requests at once.
+func NewReplayer(ollama domain.OllamaPort, model string, concurrency int) domain.ReplayerPort {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &replayer{ollama: ollama, model: model, concurrency: concurrency}
+}
+
+// Run implements ReplayerPort
+func (r *replayer) Run(ctx context.Context, interactions []domain.Interaction, emit func(domain.ReplayResult)) domain.ReplaySummary {
+	results := make([]domain.ReplayResult, len(interactions))
+	done := make([]chan struct{}, len(interactions))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	sem := make(chan struct{}, r.concurrency)
+	var wg sync.WaitGroup
+	for i, interaction := range interactions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+				results[i] = r.replayOne(ctx, interaction)
+			case <-ctx.Done():
+				results[i] = failedReplay(interaction, r.model, ctx.Err())
+			}
+		}()
+	}
+
+	// emit in input order as soon as each result is ready
+	for i := range interactions {
+		<-done[i]
+		emit(results[i])
+	}
+	wg.Wait()
+	return SummarizeReplay(results, 10)
+}
+
+func (r *replayer) replayOne(ctx context.Context, interaction domain.Interaction) domain.ReplayResult {
+	start := time.Now()
+	resp, err := r.ollama.CallOllama(ctx, domain.OllamaChatRequest{
+		Model: r.model,
+		Messages: []domain.OllamaChatMessage{{
+			Role:    "user",
+			Content: interaction.Prompt,
+		}},
+	})
+	if err != nil {
+		return failedReplay(interaction, r.model, err)
+	}
+	result := domain.ReplayResult{
+		InteractionID: interaction.ID,
+		Prompt:        interaction.Prompt,
+		OldModel:      interaction.Model,
+		NewModel:      resp.Model,
+		OldResponse:   interaction.Response,
+		NewResponse:   resp.Message.Content,
+		Similarity:    Similarity(interaction.Response, resp.Message.Content),
+		LengthDelta:   len([]rune(resp.Message.Content)) - len([]rune(interaction.Response)),
+		OldLatencyMS:  interaction.LatencyMS,
+		NewLatencyMS:  time.Since(start).Milliseconds(),
+	}
+	if result.OldLatencyMS > 0 {
+		result.LatencyDeltaMS = result.NewLatencyMS - result.OldLatencyMS
+	}
+	return result
+}
+
+func failedReplay(interaction domain.Interaction, model string, err error) domain.ReplayResult {
+	return domain.ReplayResult{
+		InteractionID: interaction.ID,
+		Prompt:        interaction.Prompt,
+		OldModel:      interaction.Model,
+		NewModel:      model,
+		OldResponse:   interaction.Response,
+		Error:         err.Error(),
+	}
+}
+
+// SummarizeReplay aggregates results, listing up to worst of the least similar successful replays.
+func SummarizeReplay(results []domain.ReplayResult, worst int) domain.ReplaySummary {
+	summary := domain.ReplaySummary{Total: len(results)}
+	var ok []domain.ReplayResult
+	var latencyDeltas int
+	for _, res := range results {
+		if res.Error != "" {
+			summary.Failed++
+			continue
+		}
+		ok = append(ok, res)
+		summary.MeanSimilarity += res.Similarity
+		summary.MeanLengthDelta += float64(res.LengthDelta)
+		if res.OldLatencyMS > 0 {
+			summary.MeanLatencyDeltaMS += float64(res.LatencyDeltaMS)
+			latencyDeltas++
+		}
+	}
+	if len(ok) == 0 {
+		return summary
+	}
+	summary.MeanSimilarity /= float64(len(ok))
+	summary.MeanLengthDelta /= float64(len(ok))
+	if latencyDeltas > 0 {
+		summary.MeanLatencyDeltaMS /= float64(latencyDeltas)
+	}
+
+	sort.SliceStable(ok, func(i, j int) bool { return ok[i].Similarity < ok[j].Similarity })
+	mid := len(ok) / 2
+	summary.MedianSimilarity = ok[mid].Similarity
+	if len(ok)%2 == 0 {
+		summary.MedianSimilarity = (ok[mid-1].Similarity + ok[mid].Similarity) / 2
+	}
+	if worst > len(ok) {
+		worst = len(ok)
+	}
+	summary.LeastSimilar = ok[:worst]
+	return summary
+}
+
+// Similarity is the cosine similarity of the two texts' word-frequency vectors,
+// from 0 (no words in common) to 1 (same words in the same proportions).
+func Similarity(a, b string) float64 {
+	va, vb := termFrequencies(a), termFrequencies(b)
+	if len(va) == 0 && len(vb) == 0 {
+		return 1
+	}
+	var dot, na, nb float64
+	for term, fa := range va {
+		dot += fa * vb[term]
+		na += fa * fa
+	}
+	for _, fb := range vb {
+		nb += fb * fb
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return math.Min(1, dot/(math.Sqrt(na)*math.Sqrt(nb)))
+}
+
+func termFrequencies(s string) map[string]float64 {
+	tf := make(map[string]float64)
+	for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}) {
+		tf[word]++
+	}
+	return tf
+}
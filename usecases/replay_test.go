@@ -0,0 +1,59 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"math"
+	"minivault/domain"
+	"minivault/mocks"
+	"testing"
+)
+
+func TestSimilarity(t *testing.T) {
+	if s := Similarity("The cat sat.", "the CAT sat"); math.Abs(s-1) > 1e-9 {
+		t.Errorf("identical word bags should score 1, got %f", s)
+	}
+	if s := Similarity("red apple", "blue sky"); s != 0 {
+		t.Errorf("disjoint texts should score 0, got %f", s)
+	}
+	if s := Similarity("a b c d", "a b x y"); s <= 0 || s >= 1 {
+		t.Errorf("partial overlap should be between 0 and 1, got %f", s)
+	}
+}
+
+func TestReplayer_Run(t *testing.T) {
+	mockOllama := &mocks.MockOllama{Response: "new answer", Model: "llama3:8b"}
+	r := NewReplayer(mockOllama, "llama3:8b", 1)
+	interactions := []domain.Interaction{
+		{ID: "1", Prompt: "p1", Model: "gemma:2b", Response: "old answer", LatencyMS: 1},
+		{ID: "2", Prompt: "p2", Model: "gemma:2b", Response: "new answer"},
+	}
+
+	var got []domain.ReplayResult
+	summary := r.Run(context.Background(), interactions, func(res domain.ReplayResult) { got = append(got, res) })
+
+	if len(got) != 2 || got[0].InteractionID != "1" || got[1].InteractionID != "2" {
+		t.Fatalf("results should be emitted in input order: %+v", got)
+	}
+	if mockOllama.LastRequest.Model != "llama3:8b" {
+		t.Errorf("replay should use the chosen model, got %q", mockOllama.LastRequest.Model)
+	}
+	if got[0].OldModel != "gemma:2b" || got[0].NewModel != "llama3:8b" || got[0].LengthDelta != 0 || got[0].LatencyDeltaMS != got[0].NewLatencyMS-1 {
+		t.Errorf("unexpected result: %+v", got[0])
+	}
+	if math.Abs(got[1].Similarity-1) > 1e-9 {
+		t.Errorf("identical answers should score 1, got %f", got[1].Similarity)
+	}
+	if summary.Total != 2 || summary.Failed != 0 || summary.LeastSimilar[0].InteractionID != "1" {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+}
+
+func TestReplayer_Failures(t *testing.T) {
+	r := NewReplayer(&mocks.MockOllama{Error: errors.New("model not found")}, "missing", 4)
+	var got []domain.ReplayResult
+	summary := r.Run(context.Background(), []domain.Interaction{{ID: "1"}}, func(res domain.ReplayResult) { got = append(got, res) })
+	if len(got) != 1 || got[0].Error != "model not found" || summary.Failed != 1 {
+		t.Errorf("unexpected failure handling: %+v %+v", got, summary)
+	}
+}
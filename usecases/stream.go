@@ -0,0 +1,95 @@
+package usecases
+
+import (
+	"context"
+	"minivault/domain"
+	"strings"
+)
+
+// replyStream passes one model reply to the caller's domain.TokenSink as it
+// arrives, through the output filters when there are any.
+type replyStream struct {
+	sink   domain.TokenSink
+	filter domain.OutputStreamPort // nil without output filters
+	cancel context.CancelCauseFunc
+	sent   strings.Builder
+	chunks int   // received from the model, filtered or not
+	err    error // set when a filter blocked the reply
+}
+
+// streamReply prepares the context of one model call. The reply is streamed when
+// the caller put a sink in ctx and stream is true; otherwise the returned
+// *replyStream is nil and the call is not streamed.
+func (g *service) streamReply(ctx context.Context, stream bool) (context.Context, *replyStream) {
+	sink := domain.TokenSinkFromContext(ctx)
+	if sink == nil {
+		return ctx, nil
+	}
+	if !stream {
+		return domain.WithTokenSink(ctx, nil), nil
+	}
+	s := &replyStream{sink: sink}
+	if g.filter != nil {
+		s.filter = g.filter.Stream()
+	}
+	ctx, s.cancel = context.WithCancelCause(ctx)
+	return domain.WithTokenSink(ctx, s.write), s
+}
+
+// write filters a token and sends what is safe so far. A blocking filter stops the
+// model call.
+func (s *replyStream) write(token string) {
+	s.chunks++
+	if s.err != nil {
+		return
+	}
+	if s.filter != nil {
+		safe, err := s.filter.Write(token)
+		if err != nil {
+			s.err = err
+			s.cancel(err)
+			return
+		}
+		token = safe
+	}
+	s.send(token)
+}
+
+func (s *replyStream) send(text string) {
+	if text != "" {
+		s.sent.WriteString(text)
+		s.sink(text)
+	}
+}
+
+// streamed returns how many chunks the model sent; 0 for a nil stream.
+func (s *replyStream) streamed() int {
+	if s == nil {
+		return 0
+	}
+	return s.chunks
+}
+
+// stop releases the model call's context once it has returned.
+func (s *replyStream) stop() {
+	if s != nil {
+		s.cancel(nil)
+	}
+}
+
+// finish sends the text the filters held back and returns the whole reply as sent,
+// with what each filter did.
+func (s *replyStream) finish() (string, []domain.FilterResult, error) {
+	if s.err != nil {
+		return "", nil, s.err
+	}
+	if s.filter == nil {
+		return s.sent.String(), nil, nil
+	}
+	tail, results, err := s.filter.Close()
+	if err != nil {
+		return "", nil, err
+	}
+	s.send(tail)
+	return s.sent.String(), results, nil
+}
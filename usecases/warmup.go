@@ -0,0 +1,136 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"minivault/config"
+	"minivault/domain"
+	"strings"
+	"time"
+)
+
+// warmer preloads models at startup and keeps designated models resident by
+// re-loading them on a schedule, so requests do not pay Ollama's cold start.
+type warmer struct {
+	models domain.ModelManagerPort
+	logger domain.LoggerPort
+
+	warmupModels []string
+	timeout      time.Duration
+	keepAlive    domain.KeepAlive
+
+	keepWarmModels []string
+	interval       time.Duration
+	hours          *dailyWindow
+	now            func() time.Time
+}
+
+// NewWarmer constructs the default WarmerPort. It fails if KEEP_WARM_HOURS is malformed.
+func NewWarmer(models domain.ModelManagerPort, logger domain.LoggerPort, cfg *config.Config) (domain.WarmerPort, error) {
+	hours, err := parseDailyWindow(cfg.KeepWarmHours)
+	if err != nil {
+		return nil, err
+	}
+	return &warmer{
+		models:         models,
+		logger:         logger,
+		warmupModels:   cfg.WarmupModels,
+		timeout:        cfg.WarmupTimeout,
+		keepAlive:      domain.KeepAlive(cfg.OllamaKeepAlive),
+		keepWarmModels: cfg.KeepWarmModels,
+		interval:       cfg.KeepWarmInterval,
+		hours:          hours,
+		now:            time.Now,
+	}, nil
+}
+
+// Warmup implements WarmerPort. Models load one at a time so they do not compete
+// for memory; a model that fails to load does not stop the others.
+func (w *warmer) Warmup(ctx context.Context) error {
+	var errs []error
+	for _, model := range w.warmupModels {
+		start := time.Now()
+		loadCtx, cancel := context.WithTimeout(ctx, w.timeout)
+		err := w.models.LoadModel(loadCtx, model, w.keepAlive)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to warm up %s: %w", model, err))
+			continue
+		}
+		w.logger.LogInfo(fmt.Sprintf("warmed up %s in %s", model, time.Since(start).Round(time.Millisecond)))
+	}
+	return errors.Join(errs...)
+}
+
+// KeepWarm implements WarmerPort. Each ping asks Ollama to keep the model for two
+// intervals, so a model stays resident while pings continue and unloads on Ollama's
+// schedule once the daily window closes.
+func (w *warmer) KeepWarm(ctx context.Context) {
+	if len(w.keepWarmModels) == 0 || w.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		w.ping(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *warmer) ping(ctx context.Context) {
+	if w.hours != nil && !w.hours.contains(w.now()) {
+		return
+	}
+	keepAlive := domain.KeepAlive((2 * w.interval).String())
+	for _, model := range w.keepWarmModels {
+		pingCtx, cancel := context.WithTimeout(ctx, w.timeout)
+		err := w.models.LoadModel(pingCtx, model, keepAlive)
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			w.logger.LogError(fmt.Sprintf("keep-warm ping for %s failed", model), err)
+		}
+	}
+}
+
+// dailyWindow is a local time-of-day range; a window whose end is before its start
+// spans midnight.
+type dailyWindow struct {
+	start, end time.Duration // since midnight
+}
+
+// parseDailyWindow parses "HH:MM-HH:MM"; an empty string means no window.
+func parseDailyWindow(s string) (*dailyWindow, error) {
+	if s == "" {
+		return nil, nil
+	}
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return nil, fmt.Errorf("invalid KEEP_WARM_HOURS %q: want HH:MM-HH:MM", s)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return nil, fmt.Errorf("invalid KEEP_WARM_HOURS %q: %w", s, err)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return nil, fmt.Errorf("invalid KEEP_WARM_HOURS %q: %w", s, err)
+	}
+	return &dailyWindow{start: sinceMidnight(start), end: sinceMidnight(end)}, nil
+}
+
+func (d *dailyWindow) contains(t time.Time) bool {
+	at := sinceMidnight(t)
+	if d.start <= d.end {
+		return at >= d.start && at < d.end
+	}
+	return at >= d.start || at < d.end
+}
+
+func sinceMidnight(t time.Time) time.Duration {
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+}
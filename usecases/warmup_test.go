@@ -0,0 +1,93 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"minivault/config"
+	"minivault/domain"
+	"minivault/mocks"
+	"testing"
+	"time"
+)
+
+func TestWarmer_WarmupLoadsEachModel(t *testing.T) {
+	models := &mocks.MockModelManager{LoadErrors: map[string]error{"broken": errors.New("no such model")}}
+	logger := &mocks.MockLogger{}
+	w, err := NewWarmer(models, logger, &config.Config{
+		WarmupModels:    []string{"gemma:2b", "broken", "llama3:8b"},
+		WarmupTimeout:   time.Second,
+		OllamaKeepAlive: "-1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = w.Warmup(context.Background())
+	if err == nil {
+		t.Error("expected the failed model to be reported")
+	}
+	if len(models.Loaded) != 3 {
+		t.Errorf("a failure should not stop the other models, loaded %v", models.Loaded)
+	}
+	if models.KeepAlive != "-1" {
+		t.Errorf("expected configured keep_alive, got %q", models.KeepAlive)
+	}
+	if len(logger.Infos) != 2 {
+		t.Errorf("expected one log line per warmed model, got %v", logger.Infos)
+	}
+}
+
+func TestWarmer_PingRespectsHours(t *testing.T) {
+	models := &mocks.MockModelManager{}
+	w, err := NewWarmer(models, &mocks.MockLogger{}, &config.Config{
+		KeepWarmModels:   []string{"gemma:2b"},
+		KeepWarmInterval: 4 * time.Minute,
+		KeepWarmHours:    "08:00-18:00",
+		WarmupTimeout:    time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ww := w.(*warmer)
+
+	ww.now = func() time.Time { return time.Date(2025, 1, 1, 7, 59, 0, 0, time.Local) }
+	ww.ping(context.Background())
+	if len(models.Loaded) != 0 {
+		t.Errorf("expected no ping outside the window, got %v", models.Loaded)
+	}
+
+	ww.now = func() time.Time { return time.Date(2025, 1, 1, 12, 0, 0, 0, time.Local) }
+	ww.ping(context.Background())
+	if len(models.Loaded) != 1 || models.KeepAlive != domain.KeepAlive("8m0s") {
+		t.Errorf("expected one ping keeping the model for two intervals, got %v %q", models.Loaded, models.KeepAlive)
+	}
+}
+
+func TestDailyWindow(t *testing.T) {
+	at := func(h, m int) time.Time { return time.Date(2025, 1, 1, h, m, 0, 0, time.Local) }
+	cases := []struct {
+		window string
+		at     time.Time
+		want   bool
+	}{
+		{"08:00-18:00", at(8, 0), true},
+		{"08:00-18:00", at(18, 0), false},
+		{"22:00-06:00", at(23, 30), true},
+		{"22:00-06:00", at(5, 59), true},
+		{"22:00-06:00", at(12, 0), false},
+	}
+	for _, c := range cases {
+		d, err := parseDailyWindow(c.window)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := d.contains(c.at); got != c.want {
+			t.Errorf("%s at %s: got %v, want %v", c.window, c.at.Format("15:04"), got, c.want)
+		}
+	}
+	for _, bad := range []string{"8-18", "08:00", "25:00-26:00"} {
+		if _, err := parseDailyWindow(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}